@@ -31,6 +31,39 @@ type Config struct {
 	Replace *ReplaceConfig `yaml:"Replace"`
 	Lark    *LarkConfig    `yaml:"Lark"`
 	Coze    *CozeConfig    `yaml:"Coze"`
+	Sync    *SyncConfig    `yaml:"Sync"`
+	Score   *ScoreConfig   `yaml:"Score"`
+}
+
+type SyncConfig struct {
+	// 失败代码的重试轮数, 未配置时使用默认值, 配置为0表示不重试
+	RetryTimes *int `yaml:"retry_times"`
+	// 每轮重试前的等待时间, 单位毫秒
+	RetryInterval int `yaml:"retry_interval"`
+	// 按数据源配置并发数, key为数据源名称, 如baidu/eastmoney/xueqiu
+	Sources map[string]*SyncSourceConfig `yaml:"sources"`
+}
+
+type SyncSourceConfig struct {
+	Concurrency int `yaml:"concurrency"`
+	// 每次请求完成后的等待时间, 单位毫秒, 未配置时使用默认值, 配置为0表示不等待
+	Interval *int `yaml:"interval"`
+}
+
+type ScoreConfig struct {
+	// 默认使用的打分模型名称
+	Default string `yaml:"default"`
+	// 按名称配置多个打分模型
+	Models map[string]*ScoreModelConfig `yaml:"models"`
+}
+
+type ScoreModelConfig struct {
+	// 计算板块走势使用的天数
+	LookbackDays int `yaml:"lookback_days"`
+	// 输出得分最高的板块数量
+	TopN int `yaml:"top_n"`
+	// 启用的因子及其权重, 未配置的因子不参与计算
+	Factors map[string]float64 `yaml:"factors"`
 }
 
 type CozeConfig struct {
@@ -87,6 +120,20 @@ func GetCozeConfig() *CozeConfig {
 	return conf.Coze
 }
 
+func GetSyncConfig() *SyncConfig {
+	if conf == nil {
+		return nil
+	}
+	return conf.Sync
+}
+
+func GetScoreConfig() *ScoreConfig {
+	if conf == nil {
+		return nil
+	}
+	return conf.Score
+}
+
 func GetLocalHost() string {
 	if conf.Replace == nil {
 		return "http://localhost:6789"
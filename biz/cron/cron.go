@@ -42,6 +42,23 @@ func InitCron() {
 		service.CreateUnusualStock(ctx)
 
 		service.CreateUnusualPredict(ctx)
+
+		// 核对之前的异动预测是否命中
+		_, err := service.ReconcileUnusualPredict(ctx, &model.ReconcileUnusualPredictReq{})
+		if err != nil {
+			hlog.Errorf("ReconcileUnusualPredict failed, err: %v", err)
+		}
+	})
+
+	// 交易时段内轮询盯盘股票的实时行情, 按规则发送提醒
+	c.AddFunc("@every 30s", func() {
+		if !utils.IsTradingTime() {
+			return
+		}
+		_, err := service.RunWatcherMonitor(ctx, &model.RunWatcherMonitorReq{})
+		if err != nil {
+			hlog.Errorf("RunWatcherMonitor failed, err: %v", err)
+		}
 	})
 
 	c.Start()
@@ -57,9 +74,23 @@ func StartCronTask(ctx context.Context) {
 
 	// 同步股价数据
 	req := &model.SyncStockCodeReq{}
-	err = service.SyncStockCode(ctx, req)
+	syncResp, err := service.SyncStockCode(ctx, req)
 	if err != nil {
 		hlog.Errorf("SyncStockCode failed, err: %v", err)
+	} else if len(syncResp.Failed) > 0 {
+		hlog.Warnf("SyncStockCode has failed codes: %s", utils.ToJsonString(syncResp.Failed))
+	}
+
+	// 根据最新的行情检查更名、停牌和退市
+	lifecycleReq := &model.SyncStockLifecycleReq{}
+	if syncResp != nil {
+		for _, item := range syncResp.Failed {
+			lifecycleReq.SkipCodes = append(lifecycleReq.SkipCodes, item.Code)
+		}
+	}
+	_, err = service.SyncStockLifecycle(ctx, lifecycleReq)
+	if err != nil {
+		hlog.Errorf("SyncStockLifecycle failed, err: %v", err)
 	}
 
 	// 同步资金流向数据
@@ -69,8 +100,38 @@ func StartCronTask(ctx context.Context) {
 		hlog.Errorf("SyncFundFlow failed, err: %v", err)
 	}
 
+	// 更新股本数据, 用于板块的市值加权走势
+	err = service.SyncStockShares(ctx)
+	if err != nil {
+		hlog.Errorf("SyncStockShares failed, err: %v", err)
+	}
+
+	// 更新风险股票名单, 用于选股和报告中排除风险股票
+	_, err = service.SyncStockRisk(ctx, &model.SyncStockRiskReq{})
+	if err != nil {
+		hlog.Errorf("SyncStockRisk failed, err: %v", err)
+	}
+
+	// 计算个股的RPS
+	_, err = service.CalculateStockRps(ctx, &model.CalculateStockRpsReq{})
+	if err != nil {
+		hlog.Errorf("CalculateStockRps failed, err: %v", err)
+	}
+
+	// 扫描全市场的缠论买卖点
+	_, err = service.ScanTrendSignals(ctx, &model.ScanTrendSignalReq{})
+	if err != nil {
+		hlog.Errorf("ScanTrendSignals failed, err: %v", err)
+	}
+
 	// 计算报告数据
-	service.GetAnalyzeReport(ctx)
+	service.GetAnalyzeReport(ctx, &model.GetAnalyzeReportReq{})
+
+	// 统计并保存当日的涨停板数据
+	_, err = service.GetLimitUpReport(ctx, &model.GetLimitUpReportReq{})
+	if err != nil {
+		hlog.Errorf("GetLimitUpReport failed, err: %v", err)
+	}
 
 	// 分析量价关系并发送报告
 	service.GetPriceAnalyse(ctx, &model.GetPriceAnalyseReq{})
@@ -78,4 +139,10 @@ func StartCronTask(ctx context.Context) {
 
 	// 分析策略并发送报告
 	service.GetSubscribeStrategyReport(ctx)
+
+	// 运行保存的选股器并通知结果变化
+	err = service.GetScreenerReport(ctx)
+	if err != nil {
+		hlog.Errorf("GetScreenerReport failed, err: %v", err)
+	}
 }
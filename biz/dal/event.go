@@ -2,16 +2,38 @@ package dal
 
 import (
 	"context"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
+// likeEscapeReplacer 转义LIKE中的通配符, 关键字按原样匹配
+var likeEscapeReplacer = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type Event struct {
-	ID      uint   `gorm:"primaryKey"`
-	Date    string `gorm:"column:date"`
-	Event   string `gorm:"column:event"`
-	Comment string `gorm:"column:comment"`
-	Stocks  string `gorm:"column:stocks"`
+	ID       uint   `gorm:"primaryKey"`
+	Date     string `gorm:"column:date"`
+	Event    string `gorm:"column:event"`
+	Comment  string `gorm:"column:comment"`
+	Stocks   string `gorm:"column:stocks"`
+	Category string `gorm:"column:category"`
+	// 标签、行业代码和概念ID, 均以逗号分隔
+	Tags       string `gorm:"column:tags"`
+	Industries string `gorm:"column:industries"`
+	Concepts   string `gorm:"column:concepts"`
+}
+
+// EventFilter 事件查询条件, 为空的字段不作为条件
+type EventFilter struct {
+	StartDate    string
+	EndDate      string
+	Category     string
+	Tag          string
+	Stock        string
+	IndustryCode string
+	ConceptID    uint
+	// 在事件内容和备注中模糊匹配
+	Keyword string
 }
 
 func (e *Event) TableName() string {
@@ -52,3 +74,63 @@ func DeleteEvent(ctx context.Context, id uint) error {
 	result := db.WithContext(ctx).Delete(&Event{}, id)
 	return result.Error
 }
+
+// GetEventsByDate 获取日期区间内的事件，按日期升序排列，日期为空时不作为条件
+func GetEventsByDate(ctx context.Context, startDate string, endDate string) ([]*Event, error) {
+	var events []*Event
+	query := db.WithContext(ctx)
+	if startDate != "" {
+		query = query.Where("date >= ?", startDate)
+	}
+	if endDate != "" {
+		query = query.Where("date <= ?", endDate)
+	}
+	result := query.Order("date ASC").Find(&events)
+	return events, result.Error
+}
+
+// GetEventList 按条件分页查询事件，按日期降序排列，同时返回满足条件的总数
+func GetEventList(ctx context.Context, filter *EventFilter, offset int, limit int) ([]*Event, int64, error) {
+	query := db.WithContext(ctx).Model(&Event{})
+	if filter.StartDate != "" {
+		query = query.Where("date >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("date <= ?", filter.EndDate)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Tag != "" {
+		query = query.Where("FIND_IN_SET(?, tags) > 0", filter.Tag)
+	}
+	if filter.Stock != "" {
+		query = query.Where("FIND_IN_SET(?, stocks) > 0", filter.Stock)
+	}
+	if filter.IndustryCode != "" {
+		query = query.Where("FIND_IN_SET(?, industries) > 0", filter.IndustryCode)
+	}
+	if filter.ConceptID > 0 {
+		query = query.Where("FIND_IN_SET(?, concepts) > 0", filter.ConceptID)
+	}
+	if filter.Keyword != "" {
+		keyword := "%" + likeEscapeReplacer.Replace(filter.Keyword) + "%"
+		query = query.Where("event LIKE ? OR comment LIKE ?", keyword, keyword)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var events []*Event
+	result := query.Order("date DESC, id DESC").Offset(offset).Limit(limit).Find(&events)
+	return events, total, result.Error
+}
+
+// CreateEventList 批量创建事件
+func CreateEventList(ctx context.Context, events []*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	result := db.WithContext(ctx).CreateInBatches(events, 500)
+	return result.Error
+}
@@ -0,0 +1,94 @@
+package dal
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IndustryScore struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Date         time.Time `json:"date" gorm:"column:date"`
+	ScoreModel   string    `json:"score_model" gorm:"column:score_model"`
+	IndustryCode string    `json:"industry_code" gorm:"column:industry_code"`
+	IndustryName string    `json:"industry_name" gorm:"column:industry_name"`
+	Rank         int       `json:"rank" gorm:"column:rank"`
+	Score        float64   `json:"score" gorm:"column:score"`
+	ChangeScore  float64   `json:"change_score" gorm:"column:change_score"`
+	Slope5Score  float64   `json:"slope5_score" gorm:"column:slope5_score"`
+	Slope10Score float64   `json:"slope10_score" gorm:"column:slope10_score"`
+	Slope20Score float64   `json:"slope20_score" gorm:"column:slope20_score"`
+	Score5gt10   float64   `json:"score5gt10" gorm:"column:score5gt10"`
+	Score10gt20  float64   `json:"score10gt20" gorm:"column:score10gt20"`
+	Score20gt30  float64   `json:"score20gt30" gorm:"column:score20gt30"`
+	NewHighScore float64   `json:"new_high_score" gorm:"column:new_high_score"`
+	VolumeScore  float64   `json:"volume_score" gorm:"column:volume_score"`
+	RPS20Score   float64   `json:"rps20_score" gorm:"column:rps20_score"`
+	RPS5Score    float64   `json:"rps5_score" gorm:"column:rps5_score"`
+}
+
+func (IndustryScore) TableName() string {
+	return "industry_score"
+}
+
+// SaveIndustryScoreList 覆盖保存某个模型某一天的全部行业打分
+func SaveIndustryScoreList(ctx context.Context, scoreModel string, date time.Time, scoreList []*IndustryScore) error {
+	db := GetDB()
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("score_model = ? and date = ?", scoreModel, date).Delete(&IndustryScore{}).Error
+		if err != nil {
+			return err
+		}
+		if len(scoreList) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(scoreList, 500).Error
+	})
+}
+
+func GetIndustryScoreByDate(ctx context.Context, scoreModel string, date string) ([]*IndustryScore, error) {
+	var scoreList []*IndustryScore
+	db := GetDB()
+	err := db.WithContext(ctx).Where("score_model = ? and date = ?", scoreModel, date).Order("`rank` asc").Find(&scoreList).Error
+	if err != nil {
+		return nil, err
+	}
+	return scoreList, nil
+}
+
+// GetIndustryScoreList 获取日期区间内的打分, industryCode为空时返回全部行业
+func GetIndustryScoreList(ctx context.Context, scoreModel string, industryCode string, dateStart string, dateEnd string) ([]*IndustryScore, error) {
+	var scoreList []*IndustryScore
+	db := GetDB()
+	db = db.WithContext(ctx).Where("score_model = ?", scoreModel)
+	if industryCode != "" {
+		db = db.Where("industry_code = ?", industryCode)
+	}
+	if dateStart != "" {
+		db = db.Where("date >= ?", dateStart)
+	}
+	if dateEnd != "" {
+		db = db.Where("date <= ?", dateEnd)
+	}
+	err := db.Order("date asc, `rank` asc").Find(&scoreList).Error
+	if err != nil {
+		return nil, err
+	}
+	return scoreList, nil
+}
+
+// GetLastNIndustryScoreDate 获取截止到date的最近limit个打分日期, 倒序
+func GetLastNIndustryScoreDate(ctx context.Context, scoreModel string, date string, limit int) ([]time.Time, error) {
+	var dateList []time.Time
+	db := GetDB()
+	db = db.WithContext(ctx).Model(&IndustryScore{}).Where("score_model = ?", scoreModel)
+	if date != "" {
+		db = db.Where("date <= ?", date)
+	}
+	err := db.Distinct("date").Order("date desc").Limit(limit).Pluck("date", &dateList).Error
+	if err != nil {
+		return nil, err
+	}
+	return dateList, nil
+}
@@ -0,0 +1,52 @@
+package dal
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LimitUpStat struct {
+	ID                       uint      `json:"id" gorm:"primaryKey"`
+	Date                     time.Time `json:"date" gorm:"column:date"`
+	LimitUpCount             int       `json:"limit_up_count" gorm:"column:limit_up_count"`
+	OneWordCount             int       `json:"one_word_count" gorm:"column:one_word_count"`
+	BrokenCount              int       `json:"broken_count" gorm:"column:broken_count"`
+	LimitDownCount           int       `json:"limit_down_count" gorm:"column:limit_down_count"`
+	MaxCount                 int       `json:"max_count" gorm:"column:max_count"`
+	FirstBoardCount          int       `json:"first_board_count" gorm:"column:first_board_count"`
+	SecondBoardCount         int       `json:"second_board_count" gorm:"column:second_board_count"`
+	PromotionRate            float64   `json:"promotion_rate" gorm:"column:promotion_rate"`
+	ContinuousBoardCount     int       `json:"continuous_board_count" gorm:"column:continuous_board_count"`
+	ContinuousPromotionCount int       `json:"continuous_promotion_count" gorm:"column:continuous_promotion_count"`
+	ContinuousPromotionRate  float64   `json:"continuous_promotion_rate" gorm:"column:continuous_promotion_rate"`
+	BrokenRate               float64   `json:"broken_rate" gorm:"column:broken_rate"`
+}
+
+func (LimitUpStat) TableName() string {
+	return "limit_up_stat"
+}
+
+// SaveLimitUpStat 覆盖保存某一天的涨停板统计
+func SaveLimitUpStat(ctx context.Context, stat *LimitUpStat) error {
+	db := GetDB()
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("date = ?", stat.Date).Delete(&LimitUpStat{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(stat).Error
+	})
+}
+
+// GetLastNLimitUpStat 获取最近N天的涨停板统计, 按日期倒序
+func GetLastNLimitUpStat(ctx context.Context, limit int) ([]*LimitUpStat, error) {
+	var statList []*LimitUpStat
+	db := GetDB()
+	err := db.WithContext(ctx).Order("date desc").Limit(limit).Find(&statList).Error
+	if err != nil {
+		return nil, err
+	}
+	return statList, nil
+}
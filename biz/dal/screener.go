@@ -0,0 +1,120 @@
+package dal
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Screener struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Name         string    `json:"name" gorm:"column:name"`
+	ScreenerType int       `json:"screener_type" gorm:"column:screener_type"`
+	Params       string    `json:"params" gorm:"column:params"`
+	CreateTime   time.Time `json:"create_time" gorm:"column:create_time"`
+	Status       int       `json:"status" gorm:"column:status"`
+}
+
+func (Screener) TableName() string {
+	return "screener"
+}
+
+type ScreenerRun struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ScreenerID uint      `json:"screener_id" gorm:"column:screener_id"`
+	Date       time.Time `json:"date" gorm:"column:date"`
+	RunTime    time.Time `json:"run_time" gorm:"column:run_time"`
+	Codes      string    `json:"codes" gorm:"column:codes"`
+	Result     string    `json:"result" gorm:"column:result"`
+}
+
+func (ScreenerRun) TableName() string {
+	return "screener_run"
+}
+
+func CreateScreener(ctx context.Context, screener *Screener) error {
+	db := GetDB()
+	return db.WithContext(ctx).Create(screener).Error
+}
+
+func GetScreenerByID(ctx context.Context, id uint) (*Screener, error) {
+	db := GetDB()
+	var screener Screener
+	err := db.WithContext(ctx).Where("id = ? AND status = ?", id, StatusEnabled).First(&screener).Error
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return &screener, nil
+}
+
+func GetAllScreenerList(ctx context.Context) ([]*Screener, error) {
+	db := GetDB()
+	var screenerList []*Screener
+	err := db.WithContext(ctx).Where("status = ?", StatusEnabled).Order("id asc").Find(&screenerList).Error
+	if err != nil {
+		return nil, err
+	}
+	return screenerList, nil
+}
+
+func DeleteScreenerByID(ctx context.Context, id uint) error {
+	db := GetDB()
+	return db.WithContext(ctx).Model(&Screener{}).Where("id = ?", id).Update("status", StatusDisabled).Error
+}
+
+func CreateScreenerRun(ctx context.Context, run *ScreenerRun) error {
+	db := GetDB()
+	return db.WithContext(ctx).Create(run).Error
+}
+
+// SaveScreenerRun 保存运行结果, ID不为0时覆盖原有记录
+func SaveScreenerRun(ctx context.Context, run *ScreenerRun) error {
+	db := GetDB()
+	return db.WithContext(ctx).Save(run).Error
+}
+
+// GetScreenerRunByDate 获取选股器在某个交易日的运行结果
+func GetScreenerRunByDate(ctx context.Context, screenerID uint, date time.Time) (*ScreenerRun, error) {
+	db := GetDB()
+	var run ScreenerRun
+	err := db.WithContext(ctx).Where("screener_id = ? and date = ?", screenerID, date).Order("id desc").First(&run).Error
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return &run, nil
+}
+
+// GetLastScreenerRunBeforeDate 获取选股器在某个交易日之前最近一次的运行结果
+func GetLastScreenerRunBeforeDate(ctx context.Context, screenerID uint, date time.Time) (*ScreenerRun, error) {
+	db := GetDB()
+	var run ScreenerRun
+	err := db.WithContext(ctx).Where("screener_id = ? and date < ?", screenerID, date).Order("date desc, id desc").First(&run).Error
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return &run, nil
+}
+
+func GetScreenerRunList(ctx context.Context, screenerID uint, limit int) ([]*ScreenerRun, error) {
+	db := GetDB()
+	var runList []*ScreenerRun
+	db = db.WithContext(ctx).Where("screener_id = ?", screenerID).Order("date desc, id desc")
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	err := db.Find(&runList).Error
+	if err != nil {
+		return nil, err
+	}
+	return runList, nil
+}
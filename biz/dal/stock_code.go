@@ -2,6 +2,7 @@ package dal
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -17,6 +18,13 @@ type StockCode struct {
 	ListedDate    string `json:"listed_date" gorm:"column:listed_date"`
 	IsParsedPrice bool   `json:"is_parsed_price" gorm:"column:is_parsed_price"`
 	BdCompanyCode string `json:"bd_company_code" gorm:"column:bd_company_code"`
+	TotalShares   int64  `json:"total_shares" gorm:"column:total_shares"`
+	FloatShares   int64  `json:"float_shares" gorm:"column:float_shares"`
+	// 状态: 0: 正常, 1: 停牌, 2: 退市
+	Status        int        `json:"status" gorm:"column:status"`
+	LastTradeDate *time.Time `json:"last_trade_date" gorm:"column:last_trade_date"`
+	// 连续不在远程股票列表中的次数
+	RemoteMissTimes int `json:"remote_miss_times" gorm:"column:remote_miss_times"`
 }
 
 func (StockCode) TableName() string {
@@ -136,3 +144,27 @@ func UpdateStockCode(ctx context.Context, stockCode *StockCode) error {
 	db := GetDB()
 	return db.WithContext(ctx).Save(stockCode).Error
 }
+
+func UpdateStockCodeShares(ctx context.Context, code string, totalShares int64, floatShares int64) error {
+	db := GetDB()
+	return db.WithContext(ctx).Model(&StockCode{}).Where("company_code = ?", code).Updates(map[string]interface{}{
+		"total_shares": totalShares,
+		"float_shares": floatShares,
+	}).Error
+}
+
+// GetListedStockCode 获取未退市的股票代码
+func GetListedStockCode(ctx context.Context, delistedStatus int) ([]*StockCode, error) {
+	db := GetDB()
+	var stockCodeList []*StockCode
+	err := db.WithContext(ctx).Where("status != ?", delistedStatus).Find(&stockCodeList).Error
+	if err != nil {
+		return nil, err
+	}
+	return stockCodeList, nil
+}
+
+func UpdateStockCodeFields(ctx context.Context, code string, fields map[string]interface{}) error {
+	db := GetDB()
+	return db.WithContext(ctx).Model(&StockCode{}).Where("company_code = ?", code).Updates(fields).Error
+}
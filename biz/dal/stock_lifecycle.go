@@ -0,0 +1,48 @@
+package dal
+
+import (
+	"context"
+	"time"
+)
+
+type StockLifecycle struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"column:code"`
+	Date      time.Time `json:"date" gorm:"column:date"`
+	EventType int       `json:"event_type" gorm:"column:event_type"`
+	OldValue  string    `json:"old_value" gorm:"column:old_value"`
+	NewValue  string    `json:"new_value" gorm:"column:new_value"`
+}
+
+func (StockLifecycle) TableName() string {
+	return "stock_lifecycle"
+}
+
+func CreateStockLifecycleList(ctx context.Context, eventList []*StockLifecycle) error {
+	if len(eventList) == 0 {
+		return nil
+	}
+	db := GetDB()
+	return db.WithContext(ctx).Create(eventList).Error
+}
+
+// GetStockLifecycleList 查询生命周期事件, 按日期倒序, 参数为空时不作为条件
+func GetStockLifecycleList(ctx context.Context, code string, startDate string, eventType int) ([]*StockLifecycle, error) {
+	var eventList []*StockLifecycle
+	db := GetDB()
+	db = db.WithContext(ctx)
+	if code != "" {
+		db = db.Where("code = ?", code)
+	}
+	if startDate != "" {
+		db = db.Where("date >= ?", startDate)
+	}
+	if eventType > 0 {
+		db = db.Where("event_type = ?", eventType)
+	}
+	err := db.Order("date desc, id desc").Find(&eventList).Error
+	if err != nil {
+		return nil, err
+	}
+	return eventList, nil
+}
@@ -127,3 +127,82 @@ func UpdateStockPrice(ctx context.Context, stockPrice *StockPrice) error {
 	db := GetDB()
 	return db.WithContext(ctx).Save(stockPrice).Error
 }
+
+// GetLastNTradeDate 获取截止到date的最近limit个交易日, 倒序
+func GetLastNTradeDate(ctx context.Context, date string, limit int) ([]time.Time, error) {
+	var dateList []time.Time
+	db := GetDB()
+	db = db.WithContext(ctx).Model(&StockPrice{})
+	if date != "" {
+		db = db.Where("date <= ?", date)
+	}
+	err := db.Distinct("date").Order("date desc").Limit(limit).Pluck("date", &dateList).Error
+	if err != nil {
+		return nil, err
+	}
+	return dateList, nil
+}
+
+// GetTradeDateList 获取日期区间内的交易日, 正序
+func GetTradeDateList(ctx context.Context, dateStart string, dateEnd string) ([]time.Time, error) {
+	var dateList []time.Time
+	db := GetDB()
+	db = db.WithContext(ctx).Model(&StockPrice{})
+	if dateStart != "" {
+		db = db.Where("date >= ?", dateStart)
+	}
+	if dateEnd != "" {
+		db = db.Where("date <= ?", dateEnd)
+	}
+	err := db.Distinct("date").Order("date asc").Pluck("date", &dateList).Error
+	if err != nil {
+		return nil, err
+	}
+	return dateList, nil
+}
+
+// GetLastTradeDateMap 获取每只股票截止到date的最后一个有行情的日期, 不限制时间范围, 没有行情的股票不在结果中
+func GetLastTradeDateMap(ctx context.Context, codeList []string, date string) (map[string]time.Time, error) {
+	ret := make(map[string]time.Time)
+	if len(codeList) == 0 {
+		return ret, nil
+	}
+	var rows []struct {
+		CompanyCode string
+		LastDate    time.Time
+	}
+	db := GetDB()
+	db = db.WithContext(ctx).Model(&StockPrice{}).Where("company_code in ?", codeList)
+	if date != "" {
+		db = db.Where("date <= ?", date)
+	}
+	err := db.Select("company_code, MAX(date) AS last_date").Group("company_code").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		ret[row.CompanyCode] = row.LastDate
+	}
+	return ret, nil
+}
+
+// GetStockPriceByCodeListAndDate 批量获取多只股票在日期区间内的数据, 按代码和日期正序
+func GetStockPriceByCodeListAndDate(ctx context.Context, codeList []string, dateStart string, dateEnd string) ([]*StockPrice, error) {
+	var stockPriceList []*StockPrice
+	if len(codeList) == 0 {
+		return stockPriceList, nil
+	}
+	db := GetDB()
+	db = db.WithContext(ctx).Where("company_code in ?", codeList)
+	if dateStart != "" {
+		db = db.Where("date >= ?", dateStart)
+	}
+	if dateEnd != "" {
+		db = db.Where("date <= ?", dateEnd)
+	}
+	err := db.Order("company_code asc, date asc").Find(&stockPriceList).Error
+	if err != nil {
+		return nil, err
+	}
+	return stockPriceList, nil
+}
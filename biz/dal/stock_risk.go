@@ -0,0 +1,77 @@
+package dal
+
+import (
+	"context"
+	"time"
+)
+
+type StockRisk struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Code      string     `json:"code" gorm:"column:code"`
+	Name      string     `json:"name" gorm:"column:name"`
+	RiskType  int        `json:"risk_type" gorm:"column:risk_type"`
+	StartDate time.Time  `json:"start_date" gorm:"column:start_date"`
+	EndDate   *time.Time `json:"end_date" gorm:"column:end_date"`
+	Reason    string     `json:"reason" gorm:"column:reason"`
+}
+
+func (StockRisk) TableName() string {
+	return "stock_risk"
+}
+
+func CreateStockRisk(ctx context.Context, risk *StockRisk) error {
+	db := GetDB()
+	return db.WithContext(ctx).Create(risk).Error
+}
+
+// CloseStockRisk 设置风险的截止日期
+func CloseStockRisk(ctx context.Context, id uint, endDate time.Time) error {
+	db := GetDB()
+	return db.WithContext(ctx).Model(&StockRisk{}).Where("id = ?", id).Update("end_date", endDate).Error
+}
+
+// GetOpenStockRiskList 获取尚未设置截止日期的风险记录
+func GetOpenStockRiskList(ctx context.Context) ([]*StockRisk, error) {
+	var riskList []*StockRisk
+	db := GetDB()
+	err := db.WithContext(ctx).Where("end_date IS NULL").Find(&riskList).Error
+	if err != nil {
+		return nil, err
+	}
+	return riskList, nil
+}
+
+// GetActiveStockRiskList 获取在某一天有效的风险记录
+func GetActiveStockRiskList(ctx context.Context, date string) ([]*StockRisk, error) {
+	var riskList []*StockRisk
+	db := GetDB()
+	err := db.WithContext(ctx).Where("start_date <= ? AND (end_date IS NULL OR end_date >= ?)", date, date).
+		Order("code asc, risk_type asc").Find(&riskList).Error
+	if err != nil {
+		return nil, err
+	}
+	return riskList, nil
+}
+
+// GetStockRiskListByCode 获取股票全部的风险记录, 按生效日期倒序
+func GetStockRiskListByCode(ctx context.Context, code string) ([]*StockRisk, error) {
+	var riskList []*StockRisk
+	db := GetDB()
+	err := db.WithContext(ctx).Where("code = ?", code).Order("start_date desc").Find(&riskList).Error
+	if err != nil {
+		return nil, err
+	}
+	return riskList, nil
+}
+
+// ExistStockRisk 判断同一生效日期的风险记录是否已经存在
+func ExistStockRisk(ctx context.Context, code string, riskType int, startDate time.Time) (bool, error) {
+	var count int64
+	db := GetDB()
+	err := db.WithContext(ctx).Model(&StockRisk{}).
+		Where("code = ? AND risk_type = ? AND start_date = ?", code, riskType, startDate).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,79 @@
+package dal
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type StockRps struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"column:code"`
+	Date      time.Time `json:"date" gorm:"column:date"`
+	Change50  float64   `json:"change50" gorm:"column:change50"`
+	Change120 float64   `json:"change120" gorm:"column:change120"`
+	Change250 float64   `json:"change250" gorm:"column:change250"`
+	Rps50     float64   `json:"rps50" gorm:"column:rps50"`
+	Rps120    float64   `json:"rps120" gorm:"column:rps120"`
+	Rps250    float64   `json:"rps250" gorm:"column:rps250"`
+}
+
+func (StockRps) TableName() string {
+	return "stock_rps"
+}
+
+// SaveStockRpsList 覆盖保存某一天全部股票的RPS
+func SaveStockRpsList(ctx context.Context, date time.Time, rpsList []*StockRps) error {
+	db := GetDB()
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("date = ?", date).Delete(&StockRps{}).Error
+		if err != nil {
+			return err
+		}
+		if len(rpsList) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rpsList, 500).Error
+	})
+}
+
+func GetStockRpsListByDate(ctx context.Context, date string) ([]*StockRps, error) {
+	var rpsList []*StockRps
+	db := GetDB()
+	err := db.WithContext(ctx).Where("date = ?", date).Find(&rpsList).Error
+	if err != nil {
+		return nil, err
+	}
+	return rpsList, nil
+}
+
+func GetLastStockRps(ctx context.Context, code string) (*StockRps, error) {
+	var rps StockRps
+	db := GetDB()
+	err := db.WithContext(ctx).Where("code = ?", code).Order("date desc").First(&rps).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rps, nil
+}
+
+func GetLastStockRpsDate(ctx context.Context, date string) (string, error) {
+	var dateList []time.Time
+	db := GetDB()
+	db = db.WithContext(ctx).Model(&StockRps{})
+	if date != "" {
+		db = db.Where("date <= ?", date)
+	}
+	err := db.Order("date desc").Limit(1).Pluck("date", &dateList).Error
+	if err != nil {
+		return "", err
+	}
+	if len(dateList) == 0 {
+		return "", nil
+	}
+	return dateList[0].Format("2006-01-02"), nil
+}
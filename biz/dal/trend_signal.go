@@ -0,0 +1,66 @@
+package dal
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+type TrendSignal struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"column:code"`
+	Date      time.Time `json:"date" gorm:"column:date"`
+	PointType int       `json:"point_type" gorm:"column:point_type"`
+	Price     float64   `json:"price" gorm:"column:price"`
+	ScanDate  time.Time `json:"scan_date" gorm:"column:scan_date"`
+}
+
+func (TrendSignal) TableName() string {
+	return "trend_signal"
+}
+
+// CreateTrendSignalList 批量写入买卖点, 已存在的买卖点保持不变, 返回新写入的数量
+func CreateTrendSignalList(ctx context.Context, signalList []*TrendSignal) (int64, error) {
+	if len(signalList) == 0 {
+		return 0, nil
+	}
+	db := GetDB()
+	result := db.WithContext(ctx).Clauses(clause.Insert{Modifier: "IGNORE"}).CreateInBatches(signalList, 500)
+	return result.RowsAffected, result.Error
+}
+
+// GetTrendSignalList 按首次扫描到的日期查询买卖点, 为空的条件不生效
+func GetTrendSignalList(ctx context.Context, scanDate string, pointType *int, code string) ([]*TrendSignal, error) {
+	var signalList []*TrendSignal
+	db := GetDB()
+	db = db.WithContext(ctx)
+	if scanDate != "" {
+		db = db.Where("scan_date = ?", scanDate)
+	}
+	if pointType != nil {
+		db = db.Where("point_type = ?", *pointType)
+	}
+	if code != "" {
+		db = db.Where("code = ?", code)
+	}
+	err := db.Order("scan_date desc, date desc, code asc").Find(&signalList).Error
+	if err != nil {
+		return nil, err
+	}
+	return signalList, nil
+}
+
+// GetLastTrendSignalScanDate 获取最近一次扫描到新买卖点的日期, 没有数据时返回空
+func GetLastTrendSignalScanDate(ctx context.Context) (string, error) {
+	var date []time.Time
+	db := GetDB()
+	err := db.WithContext(ctx).Model(&TrendSignal{}).Order("scan_date desc").Limit(1).Pluck("scan_date", &date).Error
+	if err != nil {
+		return "", err
+	}
+	if len(date) == 0 {
+		return "", nil
+	}
+	return date[0].Format("2006-01-02"), nil
+}
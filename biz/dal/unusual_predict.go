@@ -2,6 +2,7 @@ package dal
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -72,3 +73,18 @@ func DeleteUnusualPredictByDate(ctx context.Context, date string) error {
 	result := db.WithContext(ctx).Where("date = ?", date).Delete(&UnusualPredict{})
 	return result.Error
 }
+
+// GetUnusualPredictDateList 获取日期区间内有预测数据的日期, 按日期正序
+func GetUnusualPredictDateList(ctx context.Context, dateStart string, dateEnd string) ([]time.Time, error) {
+	db := GetDB()
+	var dateList []time.Time
+	db = db.WithContext(ctx).Model(&UnusualPredict{})
+	if dateStart != "" {
+		db = db.Where("date >= ?", dateStart)
+	}
+	if dateEnd != "" {
+		db = db.Where("date <= ?", dateEnd)
+	}
+	err := db.Distinct("date").Order("date asc").Pluck("date", &dateList).Error
+	return dateList, err
+}
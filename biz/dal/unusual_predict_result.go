@@ -0,0 +1,55 @@
+package dal
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UnusualPredictResult 次日异动预测与实际严重异常波动的核对结果
+type UnusualPredictResult struct {
+	ID          uint      `gorm:"primaryKey"`
+	PredictDate time.Time `gorm:"column:predict_date"`
+	TriggerDate time.Time `gorm:"column:trigger_date"`
+	Code        string    `gorm:"column:code"`
+	Name        string    `gorm:"column:name"`
+	Source      int       `gorm:"column:source"`
+	RuleType    int       `gorm:"column:rule_type"`
+	Predicted   bool      `gorm:"column:predicted"`
+	Actual      bool      `gorm:"column:actual"`
+}
+
+func (u *UnusualPredictResult) TableName() string {
+	return "unusual_predict_result"
+}
+
+// SaveUnusualPredictResultList 覆盖保存某个预测日期的核对结果
+func SaveUnusualPredictResultList(ctx context.Context, predictDate time.Time, resultList []*UnusualPredictResult) error {
+	db := GetDB()
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("predict_date = ?", predictDate).Delete(&UnusualPredictResult{}).Error
+		if err != nil {
+			return err
+		}
+		if len(resultList) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(resultList, 500).Error
+	})
+}
+
+// GetUnusualPredictResultList 获取预测日期区间内的核对结果
+func GetUnusualPredictResultList(ctx context.Context, dateStart string, dateEnd string) ([]*UnusualPredictResult, error) {
+	db := GetDB()
+	var resultList []*UnusualPredictResult
+	db = db.WithContext(ctx)
+	if dateStart != "" {
+		db = db.Where("predict_date >= ?", dateStart)
+	}
+	if dateEnd != "" {
+		db = db.Where("predict_date <= ?", dateEnd)
+	}
+	err := db.Order("predict_date asc").Find(&resultList).Error
+	return resultList, err
+}
@@ -53,3 +53,30 @@ func GetUnusualStockByCodeTypeEndDate(ctx context.Context, code string, t int, e
 	}
 	return &stock, err
 }
+
+// GetUnusualStockByTypeEndDate 获取指定类型在某一天结束的异常记录
+func GetUnusualStockByTypeEndDate(ctx context.Context, t int, endDate string) ([]*UnusualStock, error) {
+	db := GetDB()
+	var stocks []*UnusualStock
+
+	result := db.WithContext(ctx).Where("type = ? AND end_date = ?", t, endDate).Find(&stocks)
+	return stocks, result.Error
+}
+
+// GetUnusualStockListByCode 获取股票全部的异常记录, 按结束日期正序
+func GetUnusualStockListByCode(ctx context.Context, code string) ([]*UnusualStock, error) {
+	db := GetDB()
+	var stocks []*UnusualStock
+
+	result := db.WithContext(ctx).Where("code = ?", code).Order("end_date asc").Find(&stocks)
+	return stocks, result.Error
+}
+
+// GetUnusualStockListByType 获取某种类型在日期之后结束的异常记录
+func GetUnusualStockListByType(ctx context.Context, t int, endDateStart string) ([]*UnusualStock, error) {
+	db := GetDB()
+	var stocks []*UnusualStock
+
+	result := db.WithContext(ctx).Where("type = ? AND end_date >= ?", t, endDateStart).Find(&stocks)
+	return stocks, result.Error
+}
@@ -0,0 +1,86 @@
+package dal
+
+import (
+	"context"
+	"time"
+)
+
+type WatcherRule struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	WatcherID  uint      `json:"watcher_id" gorm:"column:watcher_id"`
+	Code       string    `json:"code" gorm:"column:code"`
+	RuleType   int       `json:"rule_type" gorm:"column:rule_type"`
+	Threshold  float64   `json:"threshold" gorm:"column:threshold"`
+	Cooldown   int       `json:"cooldown" gorm:"column:cooldown"`
+	CreateTime time.Time `json:"create_time" gorm:"column:create_time"`
+	Status     int       `json:"status" gorm:"column:status"`
+}
+
+func (WatcherRule) TableName() string {
+	return "watcher_rule"
+}
+
+type WatcherAlert struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	WatcherID uint      `json:"watcher_id" gorm:"column:watcher_id"`
+	RuleID    uint      `json:"rule_id" gorm:"column:rule_id"`
+	Code      string    `json:"code" gorm:"column:code"`
+	Name      string    `json:"name" gorm:"column:name"`
+	RuleType  int       `json:"rule_type" gorm:"column:rule_type"`
+	Price     float64   `json:"price" gorm:"column:price"`
+	Percent   float64   `json:"percent" gorm:"column:percent"`
+	Message   string    `json:"message" gorm:"column:message"`
+	AlertTime time.Time `json:"alert_time" gorm:"column:alert_time"`
+}
+
+func (WatcherAlert) TableName() string {
+	return "watcher_alert"
+}
+
+func CreateWatcherRule(ctx context.Context, rule *WatcherRule) error {
+	db := GetDB()
+	return db.WithContext(ctx).Create(rule).Error
+}
+
+// GetWatcherRuleList 获取生效的规则, watcherID 为0时返回全部盯盘的规则
+func GetWatcherRuleList(ctx context.Context, watcherID uint) ([]*WatcherRule, error) {
+	db := GetDB()
+	var ruleList []*WatcherRule
+	query := db.WithContext(ctx).Where("status = ?", StatusEnabled)
+	if watcherID != 0 {
+		query = query.Where("watcher_id = ?", watcherID)
+	}
+	err := query.Order("id asc").Find(&ruleList).Error
+	if err != nil {
+		return nil, err
+	}
+	return ruleList, nil
+}
+
+func DeleteWatcherRule(ctx context.Context, id uint) error {
+	db := GetDB()
+	return db.WithContext(ctx).Model(&WatcherRule{}).Where("id = ?", id).Update("status", StatusDisabled).Error
+}
+
+func CreateWatcherAlertList(ctx context.Context, alertList []*WatcherAlert) error {
+	if len(alertList) == 0 {
+		return nil
+	}
+	db := GetDB()
+	return db.WithContext(ctx).Create(alertList).Error
+}
+
+// GetWatcherAlertList 获取时间区间内的提醒记录, 按时间倒序, watcherID 为0时不作为条件
+func GetWatcherAlertList(ctx context.Context, watcherID uint, start time.Time, end time.Time) ([]*WatcherAlert, error) {
+	db := GetDB()
+	var alertList []*WatcherAlert
+	query := db.WithContext(ctx).Where("alert_time >= ? and alert_time < ?", start, end)
+	if watcherID != 0 {
+		query = query.Where("watcher_id = ?", watcherID)
+	}
+	err := query.Order("alert_time desc, id desc").Find(&alertList).Error
+	if err != nil {
+		return nil, err
+	}
+	return alertList, nil
+}
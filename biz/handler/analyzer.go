@@ -140,6 +140,30 @@ func AnalyzeThirdBuyCode(ctx context.Context, c *app.RequestContext) {
 	return
 }
 
+func AnalyzeMultiLevelTrendCode(ctx context.Context, c *app.RequestContext) {
+	var req model.AnalyzeMultiLevelTrendReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	if req.Code == "" || req.StartDate == "" {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.AnalyzeMultiLevelTrendCode(ctx, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("internal server error: %v", err),
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, data)
+}
+
 func FilterThirdBuyCode(ctx context.Context, c *app.RequestContext) {
 	var req model.FilterThirdBuyCodeReq
 	if c.BindJSON(&req) != nil {
@@ -160,7 +184,14 @@ func FilterThirdBuyCode(ctx context.Context, c *app.RequestContext) {
 }
 
 func GetAnalyzeReport(ctx context.Context, c *app.RequestContext) {
-	data, err := service.GetAnalyzeReport(ctx)
+	var req model.GetAnalyzeReportReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.GetAnalyzeReport(ctx, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.H{
 			"message": fmt.Sprintf("%v", err),
@@ -193,7 +224,32 @@ func GetVolumeReport(ctx context.Context, c *app.RequestContext) {
 }
 
 func GetLimitUpReport(ctx context.Context, c *app.RequestContext) {
-	data, err := service.GetLimitUpReport(ctx)
+	var req model.GetLimitUpReportReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.GetLimitUpReport(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func GetLimitUpHistory(ctx context.Context, c *app.RequestContext) {
+	var req model.GetLimitUpHistoryReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.GetLimitUpHistory(ctx, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.H{
 			"message": fmt.Sprintf("%v", err),
@@ -204,7 +260,176 @@ func GetLimitUpReport(ctx context.Context, c *app.RequestContext) {
 }
 
 func GetUpTrendReport(ctx context.Context, c *app.RequestContext) {
-	data, err := service.GetUpTrendReport(ctx)
+	var req model.GetUpTrendReportReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.GetUpTrendReport(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func ScanTrendSignals(ctx context.Context, c *app.RequestContext) {
+	var req model.ScanTrendSignalReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.ScanTrendSignals(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func GetTrendSignals(ctx context.Context, c *app.RequestContext) {
+	var req model.GetTrendSignalReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.GetTrendSignals(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func EvaluateSignals(ctx context.Context, c *app.RequestContext) {
+	var req model.EvaluateSignalReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.EvaluateSignals(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func GetIndustryScoreHistory(ctx context.Context, c *app.RequestContext) {
+	var req model.GetIndustryScoreHistoryReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.GetIndustryScoreHistory(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func GetIndustryScoreMovers(ctx context.Context, c *app.RequestContext) {
+	var req model.GetIndustryScoreMoversReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.GetIndustryScoreMovers(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func AnalyzeScoreFactorIC(ctx context.Context, c *app.RequestContext) {
+	var req model.AnalyzeScoreFactorICReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.AnalyzeScoreFactorIC(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func GetStockRps(ctx context.Context, c *app.RequestContext) {
+	var req model.GetStockRpsReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.GetStockRps(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func GetStockRisk(ctx context.Context, c *app.RequestContext) {
+	var req model.GetStockRiskReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.GetStockRisk(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func GetStockLifecycle(ctx context.Context, c *app.RequestContext) {
+	var req model.GetStockLifecycleReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.GetStockLifecycle(ctx, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.H{
 			"message": fmt.Sprintf("%v", err),
@@ -138,3 +138,23 @@ func DeleteConceptStock(ctx context.Context, c *app.RequestContext) {
 		"message": "success",
 	})
 }
+
+// GetConceptTrend 获取概念的加权走势
+func GetConceptTrend(ctx context.Context, c *app.RequestContext) {
+	var req model.GetConceptTrendReq
+	if err := c.BindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	data, err := service.GetConceptTrend(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
@@ -2,8 +2,12 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
+	"strings"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/utils"
@@ -79,7 +83,15 @@ func DeleteEvent(ctx context.Context, c *app.RequestContext) {
 
 // GetEventTimeline 获取事件时间轴
 func GetEventTimeline(ctx context.Context, c *app.RequestContext) {
-	timeline, err := service.GetEventTimeline(ctx)
+	var req model.GetEventTimelineReq
+	if err := c.BindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	timeline, err := service.GetEventTimeline(ctx, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.H{
 			"message": fmt.Sprintf("%v", err),
@@ -88,3 +100,72 @@ func GetEventTimeline(ctx context.Context, c *app.RequestContext) {
 	}
 	c.JSON(http.StatusOK, timeline)
 }
+
+// AnalyzeEventImpact 分析事件之后关联股票和行业的走势
+func AnalyzeEventImpact(ctx context.Context, c *app.RequestContext) {
+	var req model.AnalyzeEventImpactReq
+	if err := c.BindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	resp, err := service.AnalyzeEventImpact(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ImportEvent 批量导入事件, 支持 multipart 上传文件或直接把文件内容作为请求体
+func ImportEvent(ctx context.Context, c *app.RequestContext) {
+	var req model.ImportEventReq
+	if err := c.BindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	data := c.Request.Body()
+	if file, err := c.FormFile("file"); err == nil {
+		if req.Format == "" {
+			req.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.H{
+				"message": "bad request",
+			})
+			return
+		}
+		defer f.Close()
+		data, err = io.ReadAll(f)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.H{
+				"message": "bad request",
+			})
+			return
+		}
+	}
+
+	resp, err := service.ImportEvent(ctx, &req, data)
+	var formatErr *service.EventImportFormatError
+	if errors.As(err, &formatErr) {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": fmt.Sprintf("bad request: %v", err),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
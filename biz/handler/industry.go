@@ -75,3 +75,45 @@ func GetIndustryRelationData(ctx context.Context, c *app.RequestContext) {
 	}
 	c.JSON(http.StatusOK, data)
 }
+
+func GetCorrelationMatrix(ctx context.Context, c *app.RequestContext) {
+	var req model.GetCorrelationMatrixReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	if req.Days > 360 {
+		req.Days = 360
+	}
+	data, err := service.GetCorrelationMatrix(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func AnalyzeLeadLag(ctx context.Context, c *app.RequestContext) {
+	var req model.AnalyzeLeadLagReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	if req.Days > 360 {
+		req.Days = 360
+	}
+	data, err := service.AnalyzeLeadLag(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/biz/service"
+)
+
+func QueryScreener(ctx context.Context, c *app.RequestContext) {
+	var req model.ScreenerQueryReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	data, err := service.QueryScreener(ctx, &req)
+	var exprErr *service.ScreenerExprError
+	if errors.As(err, &exprErr) {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": fmt.Sprintf("bad request: %v", err),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, data)
+}
+
+func AddScreener(ctx context.Context, c *app.RequestContext) {
+	var req model.AddScreenerReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	err := service.AddScreener(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, utils.H{
+		"message": "success",
+	})
+}
+
+func GetScreeners(ctx context.Context, c *app.RequestContext) {
+	var req model.GetScreenerReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	data, err := service.GetScreeners(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, data)
+}
+
+func DeleteScreener(ctx context.Context, c *app.RequestContext) {
+	var req model.DeleteScreenerReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	err := service.DeleteScreener(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, utils.H{
+		"message": "success",
+	})
+}
+
+func RunScreener(ctx context.Context, c *app.RequestContext) {
+	var req model.RunScreenerReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	data, err := service.RunScreener(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, data)
+}
+
+func GetScreenerRuns(ctx context.Context, c *app.RequestContext) {
+	var req model.GetScreenerRunsReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	data, err := service.GetScreenerRuns(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, data)
+}
@@ -22,7 +22,7 @@ func SyncStockCode(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	err := service.SyncStockCode(ctx, &req)
+	resp, err := service.SyncStockCode(ctx, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.H{
 			"message": fmt.Sprintf("internal server error: %v", err),
@@ -30,9 +30,7 @@ func SyncStockCode(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	c.JSON(consts.StatusOK, utils.H{
-		"message": "success",
-	})
+	c.JSON(consts.StatusOK, resp)
 }
 
 func GetAllCode(ctx context.Context, c *app.RequestContext) {
@@ -90,6 +88,20 @@ func SyncFundFlow(ctx context.Context, c *app.RequestContext) {
 	})
 }
 
+func SyncStockShares(ctx context.Context, c *app.RequestContext) {
+	err := service.SyncStockShares(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+
+	c.JSON(consts.StatusOK, utils.H{
+		"message": "success",
+	})
+}
+
 func GetStockInfo(ctx context.Context, c *app.RequestContext) {
 	var req model.GetStockInfoReq
 	if c.BindQuery(&req) != nil {
@@ -116,3 +128,75 @@ func StartCronTask(ctx context.Context, c *app.RequestContext) {
 		"message": "success",
 	})
 }
+
+func CalculateStockRps(ctx context.Context, c *app.RequestContext) {
+	var req model.CalculateStockRpsReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.CalculateStockRps(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, data)
+}
+
+func SyncStockRisk(ctx context.Context, c *app.RequestContext) {
+	var req model.SyncStockRiskReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.SyncStockRisk(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, data)
+}
+
+func SyncStockLifecycle(ctx context.Context, c *app.RequestContext) {
+	var req model.SyncStockLifecycleReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.SyncStockLifecycle(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, data)
+}
+
+func RunWatcherMonitor(ctx context.Context, c *app.RequestContext) {
+	var req model.RunWatcherMonitorReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	data, err := service.RunWatcherMonitor(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+	c.JSON(consts.StatusOK, data)
+}
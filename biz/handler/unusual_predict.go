@@ -7,6 +7,7 @@ import (
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/zhikongming/stock/biz/model"
 	"github.com/zhikongming/stock/biz/service"
 )
 
@@ -37,3 +38,83 @@ func GetUnusualPredictList(ctx context.Context, c *app.RequestContext) {
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// GetLocalUnusualPredict 根据本地股价数据计算异动预测
+func GetLocalUnusualPredict(ctx context.Context, c *app.RequestContext) {
+	var req model.GetLocalUnusualPredictReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	resp, err := service.GetLocalUnusualPredict(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CheckUnusualPredict 对比本地计算与东方财富的异动预测
+func CheckUnusualPredict(ctx context.Context, c *app.RequestContext) {
+	var req model.CheckUnusualPredictReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	resp, err := service.CheckUnusualPredict(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ReconcileUnusualPredict 核对异动预测与实际严重异常波动
+func ReconcileUnusualPredict(ctx context.Context, c *app.RequestContext) {
+	var req model.ReconcileUnusualPredictReq
+	if c.BindJSON(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	resp, err := service.ReconcileUnusualPredict(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetUnusualPredictAccuracy 获取异动预测的准确率统计
+func GetUnusualPredictAccuracy(ctx context.Context, c *app.RequestContext) {
+	var req model.GetUnusualPredictAccuracyReq
+	if c.BindQuery(&req) != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	resp, err := service.GetUnusualPredictAccuracy(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/zhikongming/stock/biz/model"
 	"github.com/zhikongming/stock/biz/service"
 )
 
@@ -37,3 +38,37 @@ func GetUnusualStockList(ctx context.Context, c *app.RequestContext) {
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// GetUnusualStockHistory 获取单只股票的历史异常记录
+func GetUnusualStockHistory(ctx context.Context, c *app.RequestContext) {
+	var req model.GetUnusualStockHistoryReq
+	err := c.BindQuery(&req)
+	if err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+	resp, err := service.GetUnusualStockHistory(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetUnusualSeriousStat 获取全市场严重异常波动之后的收益统计
+func GetUnusualSeriousStat(ctx context.Context, c *app.RequestContext) {
+	resp, err := service.GetUnusualSeriousStat(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
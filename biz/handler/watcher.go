@@ -2,11 +2,14 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol/http1/resp"
 	"github.com/zhikongming/stock/biz/model"
 	"github.com/zhikongming/stock/biz/service"
 )
@@ -74,3 +77,142 @@ func DeleteWatcher(ctx context.Context, c *app.RequestContext) {
 		"message": "success",
 	})
 }
+
+func AddWatcherRule(ctx context.Context, c *app.RequestContext) {
+	var req model.AddWatcherRuleReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	err := service.AddWatcherRule(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{
+		"message": "success",
+	})
+}
+
+func GetWatcherRules(ctx context.Context, c *app.RequestContext) {
+	var req model.GetWatcherRulesReq
+	if err := c.BindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	rules, err := service.GetWatcherRules(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{
+		"message": "success",
+		"data":    rules,
+	})
+}
+
+func DeleteWatcherRule(ctx context.Context, c *app.RequestContext) {
+	var req model.DeleteWatcherRuleReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	err := service.DeleteWatcherRule(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("%v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{
+		"message": "success",
+	})
+}
+
+func GetWatcherAlerts(ctx context.Context, c *app.RequestContext) {
+	var req model.GetWatcherAlertsReq
+	if err := c.BindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	alerts, err := service.GetWatcherAlerts(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{
+		"message": "success",
+		"data":    alerts,
+	})
+}
+
+// StreamWatcher 通过 SSE 推送盯盘股票的实时行情和分时数据
+func StreamWatcher(ctx context.Context, c *app.RequestContext) {
+	var req model.WatcherStreamReq
+	if err := c.BindPath(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{
+			"message": "bad request",
+		})
+		return
+	}
+
+	subscriber, err := service.SubscribeWatcherStream(ctx, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{
+			"message": fmt.Sprintf("error: %v", err),
+		})
+		return
+	}
+	defer service.UnsubscribeWatcherStream(subscriber)
+
+	c.SetStatusCode(http.StatusOK)
+	c.Response.Header.Set("Content-Type", "text/event-stream")
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Response.Header.Set("Connection", "keep-alive")
+	c.Response.HijackWriter(resp.NewChunkedBodyWriter(&c.Response, c.GetWriter()))
+
+	// Hertz 不会在客户端断开时取消 ctx, 断开的连接只能在写入失败时发现, 没有行情时依靠心跳写入
+	heartbeat := time.NewTicker(service.WatcherStreamHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		var message []byte
+		select {
+		case event, ok := <-subscriber.Events():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				continue
+			}
+			message = []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event.Event, data))
+		case <-heartbeat.C:
+			message = []byte(": ping\n\n")
+		}
+		// 写入失败说明客户端已经断开
+		if _, err := c.Write(message); err != nil {
+			return
+		}
+		if err := c.Flush(); err != nil {
+			return
+		}
+	}
+}
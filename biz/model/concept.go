@@ -42,6 +42,19 @@ type GetConceptStocksReq struct {
 	ConceptID int64 `query:"concept_id"`
 }
 
+// 概念走势请求, ConceptID为0时返回全部概念
+type GetConceptTrendReq struct {
+	ConceptID int64  `query:"concept_id"`
+	Days      int    `query:"days"`
+	EndDate   string `query:"end_date"`
+	Weighting string `query:"weighting"`
+}
+
+type GetConceptTrendResp struct {
+	Weighting         string                `json:"weighting"`
+	ConceptPriceTrend []*IndustryPriceTrend `json:"concept_price_trend"`
+}
+
 // 添加概念股票请求
 type AddConceptStockReq struct {
 	ConceptID int64  `json:"concept_id"`
@@ -0,0 +1,87 @@
+package model
+
+const (
+	CorrelationTypeIndustry = "industry"
+	CorrelationTypeConcept  = "concept"
+)
+
+type GetCorrelationMatrixReq struct {
+	// 板块类型: industry/concept, 默认industry
+	Type      string `query:"type"`
+	Days      int    `query:"days"`
+	EndDate   string `query:"end_date"`
+	Weighting string `query:"weighting"`
+	// 聚类切分的相关系数阈值, 簇间平均相关系数低于该值时不再合并
+	Threshold float64 `query:"threshold"`
+}
+
+type GetCorrelationMatrixResp struct {
+	Type      string       `json:"type"`
+	Weighting string       `json:"weighting"`
+	StartDate string       `json:"start_date"`
+	EndDate   string       `json:"end_date"`
+	Threshold float64      `json:"threshold"`
+	Codes     []*CodeBasic `json:"codes"`
+	// 与Codes顺序一致的相关系数矩阵, 共同交易日不足时为0
+	Matrix   [][]float64           `json:"matrix"`
+	Linkage  []*ClusterLinkage     `json:"linkage"`
+	Clusters []*CorrelationCluster `json:"clusters"`
+}
+
+// ClusterLinkage 层次聚类的一次合并, 0~N-1为Codes中的板块, N+i为第i次合并生成的簇
+type ClusterLinkage struct {
+	ID          int     `json:"id"`
+	Left        int     `json:"left"`
+	Right       int     `json:"right"`
+	Correlation float64 `json:"correlation"`
+	Size        int     `json:"size"`
+}
+
+type CorrelationCluster struct {
+	// 簇内两两相关系数的平均值, 单个板块时为1
+	AvgCorrelation float64      `json:"avg_correlation"`
+	Members        []*CodeBasic `json:"members"`
+}
+
+type AnalyzeLeadLagReq struct {
+	Days      int    `query:"days"`
+	EndDate   string `query:"end_date"`
+	Weighting string `query:"weighting"`
+	// 只输出包含该行业的板块对, 为空则输出全部
+	IndustryCode string `query:"industry_code"`
+	// 最大滞后天数, 不超过5
+	MaxLag int `query:"max_lag"`
+	// 滚动窗口的长度和步长, 用于评估领先关系的稳定性
+	Window int `query:"window"`
+	Step   int `query:"step"`
+	// 滞后相关系数阈值以及窗口内满足阈值的最低比例
+	MinCorrelation float64 `query:"min_correlation"`
+	MinStability   float64 `query:"min_stability"`
+	Limit          int     `query:"limit"`
+}
+
+type AnalyzeLeadLagResp struct {
+	Weighting string         `json:"weighting"`
+	StartDate string         `json:"start_date"`
+	EndDate   string         `json:"end_date"`
+	Window    int            `json:"window"`
+	Windows   int            `json:"windows"`
+	Pairs     []*LeadLagPair `json:"pairs"`
+}
+
+// LeadLagPair 领先板块第T天的涨跌幅与跟随板块第T+Lag天的涨跌幅相关
+type LeadLagPair struct {
+	LeaderCode   string `json:"leader_code"`
+	LeaderName   string `json:"leader_name"`
+	FollowerCode string `json:"follower_code"`
+	FollowerName string `json:"follower_name"`
+	Lag          int    `json:"lag"`
+	// 全区间的滞后相关系数, 以及反方向(跟随板块领先)同样滞后的相关系数
+	Correlation        float64 `json:"correlation"`
+	ReverseCorrelation float64 `json:"reverse_correlation"`
+	// 当日的相关系数, 用于对比
+	Contemporaneous float64 `json:"contemporaneous"`
+	// 滚动窗口中滞后相关系数不低于阈值的比例(%)及窗口内的平均值
+	Stability            float64 `json:"stability"`
+	AvgWindowCorrelation float64 `json:"avg_window_correlation"`
+}
@@ -1,20 +1,40 @@
 package model
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	DefaultEventPageSize = 50
+	MaxEventPageSize     = 500
+)
+
 // 事件响应结构
 type EventResp struct {
-	ID      uint         `json:"id"`
-	Date    string       `json:"date"`
-	Event   string       `json:"event"`
-	Comment string       `json:"comment"`
-	Stocks  []*CodeBasic `json:"stocks"`
+	ID         uint         `json:"id"`
+	Date       string       `json:"date"`
+	Event      string       `json:"event"`
+	Comment    string       `json:"comment"`
+	Stocks     []*CodeBasic `json:"stocks"`
+	Category   string       `json:"category"`
+	Tags       []string     `json:"tags"`
+	Industries []*CodeBasic `json:"industries"`
+	Concepts   []*CodeBasic `json:"concepts"`
 }
 
 // 创建事件请求
 type CreateEventReq struct {
-	Date    string `json:"date"`
-	Event   string `json:"event"`
-	Comment string `json:"comment"`
-	Stocks  string `json:"stocks"`
+	Date     string `json:"date"`
+	Event    string `json:"event"`
+	Comment  string `json:"comment"`
+	Stocks   string `json:"stocks"`
+	Category string `json:"category"`
+	// 标签、行业代码和概念ID, 均以逗号分隔
+	Tags          string `json:"tags"`
+	IndustryCodes string `json:"industry_codes"`
+	ConceptIDs    string `json:"concept_ids"`
 }
 
 // 更新事件请求
@@ -24,6 +44,11 @@ type UpdateEventReq struct {
 	Event   string `json:"event"`
 	Comment string `json:"comment"`
 	Stocks  string `json:"stocks"`
+	// 为nil时不修改, 为空字符串时清空
+	Category      *string `json:"category"`
+	Tags          *string `json:"tags"`
+	IndustryCodes *string `json:"industry_codes"`
+	ConceptIDs    *string `json:"concept_ids"`
 }
 
 // 删除事件请求
@@ -31,8 +56,174 @@ type DeleteEventReq struct {
 	ID uint `json:"id"`
 }
 
+// 时间轴查询请求, 条件为空时不过滤
+type GetEventTimelineReq struct {
+	StartDate    string `query:"start_date"`
+	EndDate      string `query:"end_date"`
+	Category     string `query:"category"`
+	Tag          string `query:"tag"`
+	Stock        string `query:"stock"`
+	IndustryCode string `query:"industry_code"`
+	ConceptID    uint   `query:"concept_id"`
+	// 在事件内容和备注中搜索
+	Keyword  string `query:"keyword"`
+	Page     int    `query:"page"`
+	PageSize int    `query:"page_size"`
+}
+
+type GetEventTimelineResp struct {
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+	Items    []*TimelineEventResp `json:"items"`
+}
+
 // 时间轴事件响应
 type TimelineEventResp struct {
 	Date   string       `json:"date"`
 	Events []*EventResp `json:"events"`
 }
+
+// 事件影响分析请求, 指定ID时只分析该事件
+type AnalyzeEventImpactReq struct {
+	ID        uint   `query:"id"`
+	StartDate string `query:"start_date"`
+	EndDate   string `query:"end_date"`
+	Category  string `query:"category"`
+	Tag       string `query:"tag"`
+	// 统计窗口的交易日数量, 逗号分隔, 默认1,3,5,10
+	Windows string `query:"windows"`
+	// 基准指数代码, 默认上证指数
+	Benchmark string `query:"benchmark"`
+	// 行业收益的加权方式: equal/total_cap/float_cap/amount, 为空则等权
+	Weighting string `query:"weighting"`
+}
+
+type AnalyzeEventImpactResp struct {
+	Benchmark  string                  `json:"benchmark"`
+	Windows    []int                   `json:"windows"`
+	Events     []*EventImpact          `json:"events"`
+	Categories []*EventImpactGroupStat `json:"categories"`
+	Tags       []*EventImpactGroupStat `json:"tags"`
+}
+
+type EventImpact struct {
+	ID       uint     `json:"id"`
+	Date     string   `json:"date"`
+	Event    string   `json:"event"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+	// 收益的基准日, 即事件日期之前的最后一个交易日
+	BaseDate   string               `json:"base_date"`
+	Benchmark  []*EventWindowReturn `json:"benchmark"`
+	Stocks     []*EventImpactItem   `json:"stocks"`
+	Industries []*EventImpactItem   `json:"industries"`
+}
+
+type EventImpactItem struct {
+	Code    string               `json:"code"`
+	Name    string               `json:"name"`
+	Returns []*EventWindowReturn `json:"returns"`
+}
+
+// EventWindowReturn 窗口内的累计收益(%), Excess为相对基准的超额收益
+type EventWindowReturn struct {
+	Days   int     `json:"days"`
+	Return float64 `json:"return"`
+	Excess float64 `json:"excess"`
+}
+
+// EventImpactGroupStat 按分类或标签汇总事件的超额收益, 每个事件取关联股票的平均值, 一个事件可以属于多个标签
+type EventImpactGroupStat struct {
+	Name    string                   `json:"name"`
+	Events  int                      `json:"events"`
+	Windows []*EventImpactWindowStat `json:"windows"`
+}
+
+type EventImpactWindowStat struct {
+	Days int `json:"days"`
+	// 窗口已到期且有数据的事件数量
+	Count     int     `json:"count"`
+	AvgReturn float64 `json:"avg_return"`
+	AvgExcess float64 `json:"avg_excess"`
+	// 超额收益为正的比例(%)
+	WinRate float64 `json:"win_rate"`
+}
+
+const (
+	MaxEventImportRows = 5000
+
+	EventImportFormatCSV  = "csv"
+	EventImportFormatJSON = "json"
+
+	EventImportStatusCreated   = "created"
+	EventImportStatusValid     = "valid"
+	EventImportStatusDuplicate = "duplicate"
+	EventImportStatusInvalid   = "invalid"
+)
+
+// ImportEventReq 批量导入事件, 文件内容通过 multipart 的 file 字段或请求体上传
+type ImportEventReq struct {
+	// csv 或 json, 为空时根据文件扩展名判断
+	Format string `query:"format"`
+	// 只校验不写入
+	DryRun bool `query:"dry_run"`
+}
+
+// ImportEventRow 导入的单行事件, 股票和标签可以是逗号分隔的字符串或数组, 股票支持代码或名称
+type ImportEventRow struct {
+	Date          string          `json:"date"`
+	Event         string          `json:"event"`
+	Title         string          `json:"title"`
+	Comment       string          `json:"comment"`
+	Stocks        EventImportList `json:"stocks"`
+	Category      string          `json:"category"`
+	Tags          EventImportList `json:"tags"`
+	IndustryCodes EventImportList `json:"industry_codes"`
+	ConceptIDs    EventImportList `json:"concept_ids"`
+	// CSV 中的行号或 JSON 数组中的序号, 解析时填充
+	Line int `json:"-"`
+}
+
+// EventImportList 兼容字符串和字符串数组两种写法, 统一转换为逗号分隔的字符串
+type EventImportList string
+
+func (l *EventImportList) UnmarshalJSON(data []byte) error {
+	var list []interface{}
+	if err := json.Unmarshal(data, &list); err == nil {
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			items = append(items, fmt.Sprintf("%v", item))
+		}
+		*l = EventImportList(strings.Join(items, ","))
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value != nil {
+		*l = EventImportList(fmt.Sprintf("%v", value))
+	}
+	return nil
+}
+
+type ImportEventResp struct {
+	DryRun    bool                    `json:"dry_run"`
+	Total     int                     `json:"total"`
+	Created   int                     `json:"created"`
+	Valid     int                     `json:"valid"`
+	Duplicate int                     `json:"duplicate"`
+	Invalid   int                     `json:"invalid"`
+	Rows      []*ImportEventRowResult `json:"rows"`
+}
+
+type ImportEventRowResult struct {
+	// CSV 为文件中的行号, 表头为第 1 行; JSON 为数组中的序号, 从 1 开始
+	Row     int      `json:"row"`
+	Date    string   `json:"date"`
+	Event   string   `json:"event"`
+	Stocks  []string `json:"stocks"`
+	Status  string   `json:"status"`
+	Message string   `json:"message,omitempty"`
+}
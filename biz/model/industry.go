@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/zhikongming/stock/utils"
@@ -33,14 +34,39 @@ type GetIndustryRelationDataReq struct {
 	IsSplitIndustry bool   `json:"is_split_industry" query:"is_split_industry"`
 }
 
+const (
+	// 板块走势中成分股的加权方式
+	TrendWeightingEqual    = "equal"
+	TrendWeightingTotalCap = "total_cap"
+	TrendWeightingFloatCap = "float_cap"
+	TrendWeightingAmount   = "amount"
+)
+
+// ParseTrendWeighting 校验加权方式, 为空时使用等权
+func ParseTrendWeighting(weighting string) (string, error) {
+	switch weighting {
+	case "":
+		return TrendWeightingEqual, nil
+	case TrendWeightingEqual, TrendWeightingTotalCap, TrendWeightingFloatCap, TrendWeightingAmount:
+		return weighting, nil
+	default:
+		return "", fmt.Errorf("invalid weighting %s", weighting)
+	}
+}
+
 type GetIndustryTrendDataReq struct {
 	Days         int    `json:"days" query:"days"`
 	SyncPrice    bool   `json:"sync_price" query:"sync_price"`
 	IndustryCode string `json:"industry_code" query:"industry_code"`
 	EndDate      string `json:"end_date" query:"end_date"`
+	// 加权方式: equal/total_cap/float_cap/amount, 为空则等权
+	Weighting string `json:"weighting" query:"weighting"`
+	// 是否包含风险名单中的成分股, 默认排除
+	IncludeRisk bool `json:"include_risk" query:"include_risk"`
 }
 
 type GetIndustryTrendDataResp struct {
+	Weighting          string                `json:"weighting,omitempty"`
 	IndustryPriceTrend []*IndustryPriceTrend `json:"industry_price_trend"`
 	IndustryCodeTrend  []*IndustryCodeTrend  `json:"industry_code_trend"`
 }
@@ -48,6 +74,7 @@ type GetIndustryTrendDataResp struct {
 type IndustryPriceTrend struct {
 	IndustryCode   string        `json:"industry_code"`
 	IndustryName   string        `json:"industry_name"`
+	Weighting      string        `json:"weighting"`
 	PriceTrendList []*PriceTrend `json:"price_trend_list"`
 }
 
@@ -172,6 +199,9 @@ type CodeDiffPrice struct {
 	Diff   float64
 	Price  float64
 	Amount int64
+	// 计算板块当日涨跌幅和累计走势时的权重
+	DiffWeight  float64
+	PriceWeight float64
 }
 
 type ValidFuncInflowCounter struct {
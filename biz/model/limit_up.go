@@ -3,9 +3,51 @@ package model
 import "time"
 
 type LimitUpReportItem struct {
-	Code         string    `json:"code"`
-	Name         string    `json:"name"`
-	Count        int       `json:"count"`
-	IndustryName string    `json:"industry_name"`
-	LastDate     time.Time `json:"-"`
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	Count        int    `json:"count"`
+	IndustryName string `json:"industry_name"`
+	// 前一交易日的连板数量
+	PrevCount int `json:"prev_count"`
+	// 开盘价、最高价、最低价相同的一字板
+	OneWord  bool      `json:"one_word"`
+	LastDate time.Time `json:"-"`
+}
+
+type LimitUpReport struct {
+	Date string `json:"date"`
+	// 按连板数量分组, 下标即为连板数量
+	Ladder [][]*LimitUpReportItem `json:"ladder"`
+	// 盘中触及涨停但收盘未封住的股票
+	BrokenList    []*LimitUpReportItem `json:"broken_list"`
+	LimitDownList []*LimitUpReportItem `json:"limit_down_list"`
+	Stat          *LimitUpStat         `json:"stat"`
+}
+
+type LimitUpStat struct {
+	Date           string `json:"date"`
+	LimitUpCount   int    `json:"limit_up_count"`
+	OneWordCount   int    `json:"one_word_count"`
+	BrokenCount    int    `json:"broken_count"`
+	LimitDownCount int    `json:"limit_down_count"`
+	MaxCount       int    `json:"max_count"`
+	// 前一交易日首板在当日晋级二板的数量和比例(%)
+	FirstBoardCount  int     `json:"first_board_count"`
+	SecondBoardCount int     `json:"second_board_count"`
+	PromotionRate    float64 `json:"promotion_rate"`
+	// 前一交易日全部涨停股在当日继续涨停的数量和比例(%)
+	ContinuousBoardCount     int     `json:"continuous_board_count"`
+	ContinuousPromotionCount int     `json:"continuous_promotion_count"`
+	ContinuousPromotionRate  float64 `json:"continuous_promotion_rate"`
+	// 炸板数量占触及涨停数量的比例(%)
+	BrokenRate float64 `json:"broken_rate"`
+}
+
+type GetLimitUpReportReq struct {
+	// 是否包含风险名单中的股票, 默认排除, 包含时不保存统计数据
+	IncludeRisk bool `query:"include_risk"`
+}
+
+type GetLimitUpHistoryReq struct {
+	Days int `query:"days"`
 }
@@ -55,6 +55,11 @@ type VolumeReportItem struct {
 	IndustryName  string  `json:"industry_name"`
 }
 
+type GetUpTrendReportReq struct {
+	// 是否包含风险名单中的股票, 默认排除
+	IncludeRisk bool `query:"include_risk"`
+}
+
 // UpTrendReportItem 上升趋势报告项
 // 包含股票名称、所属板块、均线金叉日期、持续天数
 // 金叉：短期均线上穿长期均线，通常是买入信号
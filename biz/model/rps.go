@@ -0,0 +1,53 @@
+package model
+
+const (
+	RpsSortBy50  = "rps50"
+	RpsSortBy120 = "rps120"
+	RpsSortBy250 = "rps250"
+)
+
+type CalculateStockRpsReq struct {
+	// 计算日期, 为空则使用最新交易日
+	Date string `json:"date"`
+}
+
+type CalculateStockRpsResp struct {
+	Date  string `json:"date"`
+	Total int    `json:"total"`
+	Cost  string `json:"cost"`
+}
+
+type GetStockRpsReq struct {
+	// 为空则使用最近一次计算的日期
+	Date         string  `query:"date"`
+	MinRps50     float64 `query:"min_rps50"`
+	MinRps120    float64 `query:"min_rps120"`
+	MinRps250    float64 `query:"min_rps250"`
+	IndustryCode string  `query:"industry_code"`
+	ConceptID    int64   `query:"concept_id"`
+	// 排序字段: rps50/rps120/rps250, 默认rps50
+	SortBy string `query:"sort_by"`
+	Limit  int    `query:"limit"`
+	// 是否包含风险名单中的股票, 默认排除
+	IncludeRisk bool `query:"include_risk"`
+}
+
+type GetStockRpsResp struct {
+	Date    string          `json:"date"`
+	Total   int             `json:"total"`
+	Matched int             `json:"matched"`
+	Items   []*StockRpsItem `json:"items"`
+}
+
+type StockRpsItem struct {
+	Code         string  `json:"code"`
+	Name         string  `json:"name,omitempty"`
+	IndustryName string  `json:"industry_name,omitempty"`
+	Date         string  `json:"date"`
+	Change50     float64 `json:"change50"`
+	Change120    float64 `json:"change120"`
+	Change250    float64 `json:"change250"`
+	Rps50        float64 `json:"rps50"`
+	Rps120       float64 `json:"rps120"`
+	Rps250       float64 `json:"rps250"`
+}
@@ -0,0 +1,111 @@
+package model
+
+const (
+	ScreenerOrderAsc  = "asc"
+	ScreenerOrderDesc = "desc"
+
+	ScreenerDefaultLimit = 100
+)
+
+type ScreenerQueryReq struct {
+	// 选股表达式, 如: close > ma20 and pct_chg_20d between 10 and 40
+	Expression string `json:"expression"`
+	// 数据日期, 为空则使用每只股票的最新数据
+	Date string `json:"date"`
+	// 排序表达式, 为空则按代码排序
+	SortBy string `json:"sort_by"`
+	Order  string `json:"order"`
+	Limit  int    `json:"limit"`
+	// 范围限定, 同时指定时取交集
+	IndustryCode string `json:"industry_code"`
+	ConceptID    int64  `json:"concept_id"`
+	// 额外需要输出的字段
+	Fields []string `json:"fields"`
+	// 是否包含风险名单中的股票, 默认排除
+	IncludeRisk bool `json:"include_risk"`
+}
+
+type ScreenerQueryResp struct {
+	Expression string          `json:"expression"`
+	Total      int             `json:"total"`
+	Matched    int             `json:"matched"`
+	Items      []*ScreenerItem `json:"items"`
+}
+
+type ScreenerItem struct {
+	Code      string             `json:"code"`
+	Name      string             `json:"name"`
+	Date      string             `json:"date"`
+	SortValue float64            `json:"sort_value"`
+	Values    map[string]float64 `json:"values"`
+}
+
+type ScreenerType int
+
+const (
+	ScreenerTypeFilterStockCode ScreenerType = 0
+	ScreenerTypeThirdBuy        ScreenerType = 1
+	ScreenerTypeQuery           ScreenerType = 2
+)
+
+func (t ScreenerType) String() string {
+	switch t {
+	case ScreenerTypeFilterStockCode:
+		return "指标筛选"
+	case ScreenerTypeThirdBuy:
+		return "第三类买点"
+	case ScreenerTypeQuery:
+		return "表达式选股"
+	}
+	return "未知"
+}
+
+type AddScreenerReq struct {
+	Name         string       `json:"name"`
+	ScreenerType ScreenerType `json:"screener_type"`
+	// 按类型填写对应的参数, 与 /filter/stock/code, /filter/third/buy, /screener/query 的请求一致
+	FilterStockCode *FilterStockCodeReq    `json:"filter_stock_code,omitempty"`
+	FilterThirdBuy  *FilterThirdBuyCodeReq `json:"filter_third_buy,omitempty"`
+	Query           *ScreenerQueryReq      `json:"query,omitempty"`
+}
+
+type GetScreenerReq struct {
+	ID int `query:"id"`
+}
+
+type DeleteScreenerReq struct {
+	ID int `json:"id"`
+}
+
+type RunScreenerReq struct {
+	ID int `json:"id"`
+	// 是否发送通知
+	Notify bool `json:"notify"`
+}
+
+type GetScreenerRunsReq struct {
+	ID    int `query:"id"`
+	Limit int `query:"limit"`
+}
+
+type ScreenerResp struct {
+	ID           uint            `json:"id"`
+	Name         string          `json:"name"`
+	ScreenerType ScreenerType    `json:"screener_type"`
+	TypeName     string          `json:"type_name"`
+	Params       *AddScreenerReq `json:"params"`
+	CreateTime   string          `json:"create_time"`
+}
+
+type ScreenerRunResult struct {
+	ScreenerID uint   `json:"screener_id"`
+	Name       string `json:"name"`
+	Date       string `json:"date"`
+	RunTime    string `json:"run_time"`
+	// 本次选中的股票
+	Codes []*CodeBasic `json:"codes"`
+	// 相比上一次运行新进入的股票
+	Entries []*CodeBasic `json:"entries"`
+	// 相比上一次运行被剔除的股票
+	Exits []*CodeBasic `json:"exits"`
+}
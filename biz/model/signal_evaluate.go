@@ -0,0 +1,53 @@
+package model
+
+const (
+	// 第三类买点的信号类型名称, 与缠论买卖点B1~S3并列
+	SignalTypeThirdBuy = "TB"
+)
+
+type EvaluateSignalReq struct {
+	// 信号出现的日期区间
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	// 行业名称, 为空则评估全市场
+	IndustryName string `json:"industry_name"`
+	// 参与评估的信号类型, 如B1/B2/B3/S1/S2/S3/TB, 为空则全部评估
+	SignalTypes []string `json:"signal_types"`
+	// 回放的步长, 每隔Step个交易日重新分析一次
+	Step int `json:"step"`
+	// 缠论分析使用的日线数量
+	Window int `json:"window"`
+	// 第三类买点分析使用的日线数量以及阈值, 与FilterThirdBuyCodeReq一致
+	ThirdBuyDays       int     `json:"third_buy_days"`
+	ThresholdUp        float64 `json:"threshold_up"`
+	ThresholdPullback  float64 `json:"threshold_pullback"`
+	ThresholdDeviation float64 `json:"threshold_deviation"`
+	ThresholdProfit    float64 `json:"threshold_profit"`
+}
+
+type EvaluateSignalResp struct {
+	StartDate  string                `json:"start_date"`
+	EndDate    string                `json:"end_date"`
+	Total      int                   `json:"total"`
+	ByType     []*SignalEvaluateStat `json:"by_type"`
+	ByIndustry []*SignalEvaluateStat `json:"by_industry"`
+	Cost       string                `json:"cost"`
+}
+
+type SignalEvaluateStat struct {
+	SignalType   string `json:"signal_type"`
+	IndustryName string `json:"industry_name,omitempty"`
+	Count        int    `json:"count"`
+	// 最大不利偏移(%), 买点为持有期内最低价相对入场价的跌幅, 卖点为最高价的涨幅, 均以负数表示
+	AvgMae   float64                    `json:"avg_mae"`
+	WorstMae float64                    `json:"worst_mae"`
+	Horizons []*SignalForwardReturnStat `json:"horizons"`
+}
+
+type SignalForwardReturnStat struct {
+	Days      int     `json:"days"`
+	Count     int     `json:"count"`
+	AvgReturn float64 `json:"avg_return"`
+	// 买点收益为正、卖点收益为负记为命中
+	HitRate float64 `json:"hit_rate"`
+}
@@ -70,3 +70,26 @@ func (s UnusualStockSorter) Less(i, j int) bool {
 	}
 	return iEndDate.Before(jEndDate)
 }
+
+type ScreenerItemSorter struct {
+	Items []*ScreenerItem
+	Asc   bool
+}
+
+func (s ScreenerItemSorter) Len() int {
+	return len(s.Items)
+}
+
+func (s ScreenerItemSorter) Swap(i, j int) {
+	s.Items[i], s.Items[j] = s.Items[j], s.Items[i]
+}
+
+func (s ScreenerItemSorter) Less(i, j int) bool {
+	if s.Items[i].SortValue == s.Items[j].SortValue {
+		return s.Items[i].Code < s.Items[j].Code
+	}
+	if s.Asc {
+		return s.Items[i].SortValue < s.Items[j].SortValue
+	}
+	return s.Items[i].SortValue > s.Items[j].SortValue
+}
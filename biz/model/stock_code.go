@@ -3,6 +3,7 @@ package model
 import (
 	"sort"
 	"strings"
+	"time"
 )
 
 type StockStrategy string
@@ -45,6 +46,55 @@ type SyncStockCodeReq struct {
 	BusinessType int    `json:"business_type"`
 }
 
+type SyncStockCodeResp struct {
+	Total int `json:"total"`
+	// 成功拉取了新数据的代码
+	Succeeded []string `json:"succeeded"`
+	// 已是最新数据, 跳过的代码
+	Skipped []string          `json:"skipped"`
+	Failed  []*SyncFailedCode `json:"failed"`
+	Cost    string            `json:"cost"`
+}
+
+type SyncFailedCode struct {
+	Code       string `json:"code"`
+	Error      string `json:"error"`
+	RetryTimes int    `json:"retry_times"`
+}
+
+func NewSyncStockCodeResp(total int) *SyncStockCodeResp {
+	return &SyncStockCodeResp{
+		Total:     total,
+		Succeeded: make([]string, 0),
+		Skipped:   make([]string, 0),
+		Failed:    make([]*SyncFailedCode, 0),
+	}
+}
+
+func (r *SyncStockCodeResp) AddResult(code string, updated bool) {
+	if updated {
+		r.Succeeded = append(r.Succeeded, code)
+	} else {
+		r.Skipped = append(r.Skipped, code)
+	}
+}
+
+func (r *SyncStockCodeResp) AddFailed(code string, err error, retryTimes int) {
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	r.Failed = append(r.Failed, &SyncFailedCode{
+		Code:       code,
+		Error:      errMsg,
+		RetryTimes: retryTimes,
+	})
+}
+
+func (r *SyncStockCodeResp) SetCost(cost time.Duration) {
+	r.Cost = cost.String()
+}
+
 type SyncStockIndustryReq struct {
 }
 
@@ -87,6 +137,8 @@ type FilterStockCodeReq struct {
 	MaFilter      *MaFilter      `json:"ma_filter,omitempty"`
 	BollingFilter *BollingFilter `json:"bolling_filter,omitempty"`
 	KdjFilter     *KdjFilter     `json:"kdj_filter,omitempty"`
+	// 是否包含风险名单中的股票, 默认排除
+	IncludeRisk bool `json:"include_risk,omitempty"`
 }
 
 type FilterStockCodeItem struct {
@@ -96,6 +148,23 @@ type FilterStockCodeItem struct {
 	LastDate    string                                  `json:"last_date"`
 }
 
+type FilterStockCodeResp struct {
+	Items []*FilterStockCodeItem `json:"items"`
+	Stats *FilterStockCodeStats  `json:"stats"`
+}
+
+type FilterStockCodeStats struct {
+	Total int `json:"total"`
+	// 完成分析的代码数量
+	Analyzed int `json:"analyzed"`
+	// 数据不足跳过的代码数量
+	Skipped     int    `json:"skipped"`
+	Matched     int    `json:"matched"`
+	LoadCost    string `json:"load_cost"`
+	AnalyzeCost string `json:"analyze_cost"`
+	TotalCost   string `json:"total_cost"`
+}
+
 type MaOrderData struct {
 	MaType  StockMaType `json:"ma_type"`
 	MaPrice float64     `json:"ma_price"`
@@ -302,6 +371,36 @@ type DivergencePointItem struct {
 	Price     float64 `json:"price"`
 }
 
+type AnalyzeMultiLevelTrendReq struct {
+	Code      string `json:"code"`
+	StartDate string `json:"start_date,omitempty"`
+	EndDate   string `json:"end_date,omitempty"`
+}
+
+type AnalyzeMultiLevelTrendResp struct {
+	Day   *AnalyzeTrendCodeResp `json:"day"`
+	Min30 *AnalyzeTrendCodeResp `json:"min30"`
+	// 日线笔及其内部的30分钟中枢和买卖点
+	Segments []*MultiLevelSegmentItem `json:"segments"`
+	// 区间套确认的买卖点
+	NestedPoints []*NestedDivergencePointItem `json:"nested_points"`
+}
+
+type MultiLevelSegmentItem struct {
+	StartDate           string                 `json:"start_date"`
+	EndDate             string                 `json:"end_date"`
+	Class               ClassType              `json:"class"`
+	PriceStart          float64                `json:"price_start"`
+	PriceEnd            float64                `json:"price_end"`
+	SubPivots           []*PivotItem           `json:"sub_pivots"`
+	SubDivergencePoints []*DivergencePointItem `json:"sub_divergence_points"`
+}
+
+type NestedDivergencePointItem struct {
+	Point      *DivergencePointItem `json:"point"`
+	LowerPoint *DivergencePointItem `json:"lower_point"`
+}
+
 type CodeInfo struct {
 	Code string `json:"code"`
 	Name string `json:"name"`
@@ -319,6 +418,7 @@ type StockInfo struct {
 	VolumePriceInfo         *GetVolumePriceResp        `json:"volume_price_info"`
 	BusinessAnalysisInfo    *GetBusinessAnalysisResp   `json:"business_analysis_info"`
 	ShareholderAnalysisInfo *ShareholderAnalysisReport `json:"shareholder_analysis_info"`
+	RpsInfo                 *StockRpsItem              `json:"rps_info"`
 }
 
 type GetStockInfoReq struct {
@@ -85,6 +85,15 @@ type FundFlowData struct {
 	Date                     string  `json:"date"`
 }
 
+type StockShareData struct {
+	Code  string  `json:"code"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+	// 总市值和流通市值, 单位元
+	TotalMarketCap float64 `json:"total_market_cap"`
+	FloatMarketCap float64 `json:"float_market_cap"`
+}
+
 type EMGetRemoteDailyFundFlowResp struct {
 	Data *EMDailyFundFlowData `json:"data"`
 }
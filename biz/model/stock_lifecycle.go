@@ -0,0 +1,81 @@
+package model
+
+type StockStatus int
+
+const (
+	StockStatusNormal StockStatus = iota
+	StockStatusSuspended
+	StockStatusDelisted
+)
+
+func (s StockStatus) String() string {
+	switch s {
+	case StockStatusNormal:
+		return "正常"
+	case StockStatusSuspended:
+		return "停牌"
+	case StockStatusDelisted:
+		return "退市"
+	}
+	return "未知"
+}
+
+type StockLifecycleEventType int
+
+const (
+	StockLifecycleEventRename StockLifecycleEventType = iota + 1
+	StockLifecycleEventRiskChange
+	StockLifecycleEventSuspend
+	StockLifecycleEventResume
+	StockLifecycleEventDelist
+	StockLifecycleEventRelist
+)
+
+func (t StockLifecycleEventType) String() string {
+	switch t {
+	case StockLifecycleEventRename:
+		return "更名"
+	case StockLifecycleEventRiskChange:
+		return "ST状态变化"
+	case StockLifecycleEventSuspend:
+		return "停牌"
+	case StockLifecycleEventResume:
+		return "复牌"
+	case StockLifecycleEventDelist:
+		return "退市"
+	case StockLifecycleEventRelist:
+		return "重新上市"
+	}
+	return "未知"
+}
+
+type SyncStockLifecycleReq struct {
+	// 检查日期, 为空则使用最新交易日
+	Date string `json:"date"`
+	// 本次同步行情失败的代码, 不做停牌判断
+	SkipCodes []string `json:"skip_codes"`
+}
+
+type SyncStockLifecycleResp struct {
+	Date      string `json:"date"`
+	Renamed   int    `json:"renamed"`
+	Suspended int    `json:"suspended"`
+	Resumed   int    `json:"resumed"`
+	Delisted  int    `json:"delisted"`
+	Relisted  int    `json:"relisted"`
+}
+
+type GetStockLifecycleReq struct {
+	Code      string `query:"code"`
+	StartDate string `query:"start_date"`
+	EventType int    `query:"event_type"`
+}
+
+type StockLifecycleEvent struct {
+	Code      string                  `json:"code"`
+	Date      string                  `json:"date"`
+	EventType StockLifecycleEventType `json:"event_type"`
+	EventName string                  `json:"event_name"`
+	OldValue  string                  `json:"old_value"`
+	NewValue  string                  `json:"new_value"`
+}
@@ -130,3 +130,22 @@ type StockMinuteData struct {
 	Price   float64 `json:"price"`
 	Percent float64 `json:"percent"`
 }
+
+// StockQuote 股票或板块的实时行情
+type StockQuote struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Percent  float64 `json:"percent"`
+	Open     float64 `json:"open"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	PreClose float64 `json:"pre_close"`
+	// 成交量(手)和成交额(元)
+	Volume float64 `json:"volume"`
+	Amount float64 `json:"amount"`
+	// 量比
+	VolumeRatio float64 `json:"volume_ratio"`
+	// 行情时间戳(秒)
+	UpdateTime int64 `json:"update_time"`
+}
@@ -0,0 +1,76 @@
+package model
+
+import "strings"
+
+type StockRiskType int
+
+const (
+	StockRiskTypeNone StockRiskType = iota
+	StockRiskTypeST
+	StockRiskTypeStarST
+	StockRiskTypeDelisting
+	StockRiskTypeMarketRisk
+	StockRiskTypeSuspended
+)
+
+func (t StockRiskType) String() string {
+	switch t {
+	case StockRiskTypeNone:
+		return "正常"
+	case StockRiskTypeST:
+		return "ST"
+	case StockRiskTypeStarST:
+		return "*ST"
+	case StockRiskTypeDelisting:
+		return "退市"
+	case StockRiskTypeMarketRisk:
+		return "交易所风险提示"
+	case StockRiskTypeSuspended:
+		return "停牌"
+	}
+	return "未知"
+}
+
+// GetStockRiskTypeByName 根据股票名称识别ST、*ST和退市风险
+func GetStockRiskTypeByName(name string) StockRiskType {
+	name = strings.ToUpper(name)
+	switch {
+	case strings.Contains(name, "退"):
+		return StockRiskTypeDelisting
+	case strings.Contains(name, "*ST"):
+		return StockRiskTypeStarST
+	case strings.Contains(name, "ST"):
+		return StockRiskTypeST
+	}
+	return StockRiskTypeNone
+}
+
+type StockRisk struct {
+	Code     string        `json:"code"`
+	Name     string        `json:"name"`
+	RiskType StockRiskType `json:"risk_type"`
+	RiskName string        `json:"risk_name"`
+	// 生效日期, 截止日期为空表示仍然有效
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Reason    string `json:"reason"`
+}
+
+type SyncStockRiskReq struct {
+	// 同步日期, 为空则使用最新交易日
+	Date string `json:"date"`
+}
+
+type SyncStockRiskResp struct {
+	Date   string `json:"date"`
+	Active int    `json:"active"`
+	Added  int    `json:"added"`
+	Closed int    `json:"closed"`
+}
+
+type GetStockRiskReq struct {
+	// 为空则使用当天
+	Date     string `query:"date"`
+	Code     string `query:"code"`
+	RiskType int    `query:"risk_type"`
+}
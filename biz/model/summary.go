@@ -2,6 +2,13 @@ package model
 
 type AnalyzeReport struct{}
 
+type GetAnalyzeReportReq struct {
+	// 打分模型名称, 为空则使用配置的默认模型
+	Model string `query:"model"`
+	// 板块成分股是否包含风险名单中的股票, 默认排除
+	IncludeRisk bool `query:"include_risk"`
+}
+
 type CodeScore struct {
 	Code  string  `json:"code"`
 	Value float64 `json:"value"`
@@ -64,9 +71,108 @@ type ScoreResultDiff struct {
 	OrderDiff string `json:"order_diff"`
 }
 
+type GetIndustryScoreHistoryReq struct {
+	Model        string `query:"model"`
+	IndustryCode string `query:"industry_code"`
+	// 最近的打分天数
+	Days int `query:"days"`
+}
+
+type IndustryScoreSeries struct {
+	Code  string                `json:"code"`
+	Name  string                `json:"name"`
+	Items []*IndustryScorePoint `json:"items"`
+}
+
+type IndustryScorePoint struct {
+	Date         string  `json:"date"`
+	Rank         int     `json:"rank"`
+	Score        float64 `json:"score"`
+	ChangeScore  float64 `json:"change_score"`
+	Slope5Score  float64 `json:"slope5_score"`
+	Slope10Score float64 `json:"slope10_score"`
+	Slope20Score float64 `json:"slope20_score"`
+	Score5gt10   float64 `json:"score5gt10"`
+	Score10gt20  float64 `json:"score10gt20"`
+	Score20gt30  float64 `json:"score20gt30"`
+	NewHighScore float64 `json:"new_high_score"`
+	VolumeScore  float64 `json:"volume_score"`
+	RPS20Score   float64 `json:"rps20_score"`
+	RPS5Score    float64 `json:"rps5_score"`
+}
+
+type GetIndustryScoreMoversReq struct {
+	Model string `query:"model"`
+	// 对比的交易日间隔
+	Days  int `query:"days"`
+	Limit int `query:"limit"`
+}
+
+type IndustryScoreMoversResp struct {
+	Date        string                `json:"date"`
+	BaseDate    string                `json:"base_date"`
+	RisingStars []*IndustryScoreMover `json:"rising_stars"`
+	Fading      []*IndustryScoreMover `json:"fading"`
+}
+
+type IndustryScoreMover struct {
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	Rank      int     `json:"rank"`
+	PrevRank  int     `json:"prev_rank"`
+	RankDiff  int     `json:"rank_diff"`
+	Score     float64 `json:"score"`
+	PrevScore float64 `json:"prev_score"`
+}
+
 type GoldCrossResult struct {
 	Date            string
 	Days            int
 	LastPrice       float64
 	PriceChangeRate float64
 }
+
+type AnalyzeScoreFactorICReq struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	// 打分模型名称, 决定回看天数、总分权重以及篮子大小
+	Model string `json:"model"`
+}
+
+type AnalyzeScoreFactorICResp struct {
+	Model     string                   `json:"model"`
+	StartDate string                   `json:"start_date"`
+	EndDate   string                   `json:"end_date"`
+	Dates     int                      `json:"dates"`
+	TopN      int                      `json:"top_n"`
+	Factors   []*ScoreFactorICStat     `json:"factors"`
+	Basket    []*ScoreBasketReturnStat `json:"basket"`
+}
+
+type ScoreFactorICStat struct {
+	Factor   string               `json:"factor"`
+	Weight   float64              `json:"weight"`
+	Horizons []*ScoreFactorICItem `json:"horizons"`
+}
+
+type ScoreFactorICItem struct {
+	Days  int `json:"days"`
+	Count int `json:"count"`
+	// 每日截面秩相关系数的均值、标准差以及均值/标准差
+	MeanIC float64 `json:"mean_ic"`
+	ICStd  float64 `json:"ic_std"`
+	ICIR   float64 `json:"ic_ir"`
+	// IC为正的天数占比
+	PositiveRate float64 `json:"positive_rate"`
+}
+
+type ScoreBasketReturnStat struct {
+	Days  int `json:"days"`
+	Count int `json:"count"`
+	// 总分前N名板块的平均收益与全部板块平均收益(%)
+	TopReturn float64 `json:"top_return"`
+	AvgReturn float64 `json:"avg_return"`
+	Excess    float64 `json:"excess"`
+	// 超额收益为正的天数占比
+	WinRate float64 `json:"win_rate"`
+}
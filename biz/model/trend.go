@@ -62,3 +62,43 @@ func (d DivergencePointType) ToString() string {
 		return "no"
 	}
 }
+
+func ParseDivergencePointType(s string) (DivergencePointType, bool) {
+	for _, t := range []DivergencePointType{DivergencePointBuy1, DivergencePointBuy2, DivergencePointBuy3, DivergencePointSell1, DivergencePointSell2, DivergencePointSell3} {
+		if t.ToString() == s {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+type ScanTrendSignalReq struct {
+	// 扫描截止日期, 为空则使用最新数据
+	Date string `json:"date"`
+}
+
+type ScanTrendSignalResp struct {
+	Total    int    `json:"total"`
+	Analyzed int    `json:"analyzed"`
+	Created  int    `json:"created"`
+	Cost     string `json:"cost"`
+}
+
+type GetTrendSignalReq struct {
+	// 买卖点首次被扫描到的日期
+	Date string `query:"date"`
+	Type string `query:"type"`
+	Code string `query:"code"`
+}
+
+type TrendSignalItem struct {
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	IndustryName string `json:"industry_name"`
+	// 买卖点所在K线的日期
+	Date      string  `json:"date"`
+	PointType string  `json:"point_type"`
+	Price     float64 `json:"price"`
+	// 首次扫描到的日期
+	ScanDate string `json:"scan_date"`
+}
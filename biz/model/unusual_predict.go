@@ -8,6 +8,15 @@ const (
 	PredictTypeNextDayUnusual                    // 2: 次日预测异动
 )
 
+const (
+	PredictRuleMainSameDirection = 1 // 主板同向异常波动次数
+	PredictRuleStarSameDirection = 3 // 科创板同向异常波动次数, 创业板规则相同
+	PredictRuleDeviation10Up     = 4
+	PredictRuleDeviation10Down   = 5
+	PredictRuleDeviation30Up     = 6
+	PredictRuleDeviation30Down   = 7
+)
+
 var (
 	PredictRuleTypeMap = map[int]string{
 		1: "主板连续10个交易日内4次出现同向异常波动",
@@ -50,6 +59,118 @@ type MergedUnusualPredict struct {
 	Rule           string       `json:"rule"`             // 规则描述
 }
 
+// LocalUnusualPredict 根据本地股价数据计算的异动预测
+type LocalUnusualPredict struct {
+	UnusualPredict
+	IndexCode string  `json:"index_code"` // 计算偏离值使用的基准指数
+	Close     float64 `json:"close"`      // 当日收盘价
+	// 次日触发异动需要达到的收盘价, 按基准指数次日不涨不跌估算
+	TriggerPrice float64 `json:"trigger_price"`
+	// 同向异常波动规则中统计周期内已出现的次数
+	Count int `json:"count,omitempty"`
+}
+
+type GetLocalUnusualPredictReq struct {
+	Date string `query:"date"`
+	Code string `query:"code"`
+}
+
+type GetLocalUnusualPredictResp struct {
+	Date  string                 `json:"date"`
+	Items []*LocalUnusualPredict `json:"items"`
+}
+
+type CheckUnusualPredictReq struct {
+	Date string `query:"date"`
+}
+
+// CheckUnusualPredictResp 本地计算结果与东方财富数据的对比
+type CheckUnusualPredictResp struct {
+	Date        string                     `json:"date"`
+	LocalCount  int                        `json:"local_count"`
+	RemoteCount int                        `json:"remote_count"`
+	Matched     []*UnusualPredictCheckItem `json:"matched"`
+	LocalOnly   []*LocalUnusualPredict     `json:"local_only"`
+	RemoteOnly  []*UnusualPredict          `json:"remote_only"`
+}
+
+type UnusualPredictCheckItem struct {
+	Code                string      `json:"code"`
+	Name                string      `json:"name"`
+	PredictType         PredictType `json:"predict_type"`
+	RuleType            int         `json:"rule_type"`
+	LocalDeviationRate  float64     `json:"local_deviation_rate"`
+	RemoteDeviationRate float64     `json:"remote_deviation_rate"`
+	LocalPredictRate    float64     `json:"local_predict_rate"`
+	RemotePredictRate   float64     `json:"remote_predict_rate"`
+}
+
+type ReconcileUnusualPredictReq struct {
+	// 预测日期区间, 为空则核对最近30天
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+type ReconcileUnusualPredictResp struct {
+	Dates     int `json:"dates"`
+	Predicted int `json:"predicted"`
+	Actual    int `json:"actual"`
+	Hit       int `json:"hit"`
+}
+
+const (
+	// 核对结果的记录来源
+	UnusualResultSourcePredict = 1
+	UnusualResultSourceNotice  = 2
+)
+
+const (
+	// 异动预测后的走势分组: 预测命中、预测未命中、实际异动中被预测到的、实际异动中未被预测到的
+	UnusualReturnGroupPredictHit        = "predict_hit"
+	UnusualReturnGroupPredictMiss       = "predict_miss"
+	UnusualReturnGroupNoticePredicted   = "notice_predicted"
+	UnusualReturnGroupNoticeUnpredicted = "notice_unpredicted"
+)
+
+type GetUnusualPredictAccuracyReq struct {
+	StartDate string `query:"start_date"`
+	EndDate   string `query:"end_date"`
+}
+
+type UnusualPredictAccuracyResp struct {
+	StartDate string                    `json:"start_date"`
+	EndDate   string                    `json:"end_date"`
+	Dates     int                       `json:"dates"`
+	Total     *UnusualPredictRuleStat   `json:"total"`
+	ByRule    []*UnusualPredictRuleStat `json:"by_rule"`
+	// 预测分组以预测日收盘价为基准, 实际异动分组以触发日收盘价为基准
+	AfterPredict []*UnusualForwardReturnGroup `json:"after_predict"`
+	AfterNotice  []*UnusualForwardReturnGroup `json:"after_notice"`
+}
+
+type UnusualPredictRuleStat struct {
+	RuleType  int    `json:"rule_type"`
+	Rule      string `json:"rule"`
+	Predicted int    `json:"predicted"`
+	Actual    int    `json:"actual"`
+	Hit       int    `json:"hit"`
+	// 精确率和召回率(%)
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+}
+
+type UnusualForwardReturnGroup struct {
+	Group    string                      `json:"group"`
+	Horizons []*UnusualForwardReturnStat `json:"horizons"`
+}
+
+type UnusualForwardReturnStat struct {
+	Days      int     `json:"days"`
+	Count     int     `json:"count"`
+	AvgReturn float64 `json:"avg_return"`
+	UpRate    float64 `json:"up_rate"`
+}
+
 type EMUnusualPredictResp struct {
 	Result int    `json:"result"`
 	Msg    string `json:"msg"`
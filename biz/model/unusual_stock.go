@@ -18,3 +18,37 @@ type UnusualStock struct {
 	UnusualType   string      `json:"unusual_type"`   // 异常类型
 	UnusualReason string      `json:"unusual_reason"` // 异常原因
 }
+
+type GetUnusualStockHistoryReq struct {
+	Code string `query:"code"`
+}
+
+type GetUnusualStockHistoryResp struct {
+	Code    string                     `json:"code"`
+	Name    string                     `json:"name"`
+	Records []*UnusualStockHistoryItem `json:"records"`
+	// 该股票严重异常波动之后的收益统计, 以触发日收盘价为基准
+	SeriousStat []*UnusualForwardReturnStat `json:"serious_stat"`
+}
+
+type GetUnusualSeriousStatResp struct {
+	StartDate string `json:"start_date"`
+	// 统计周期内严重异常波动的次数
+	Count int                         `json:"count"`
+	Stat  []*UnusualForwardReturnStat `json:"stat"`
+}
+
+type UnusualStockHistoryItem struct {
+	UnusualStock
+	// 价格走势的基准日期, 异常波动为结束日期, 风险提示为开始日期
+	AnchorDate string               `json:"anchor_date"`
+	PricePath  []*UnusualPricePoint `json:"price_path"`
+}
+
+type UnusualPricePoint struct {
+	Offset int     `json:"offset"`
+	Date   string  `json:"date"`
+	Close  float64 `json:"close"`
+	// 相对基准日收盘价的涨跌幅(%)
+	Change float64 `json:"change"`
+}
@@ -53,3 +53,118 @@ func (s MultiCodeInfoSorter) Less(i, j int) bool {
 func (s MultiCodeInfoSorter) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
+
+type WatcherRuleType int
+
+const (
+	WatcherRuleTypeNone WatcherRuleType = iota
+	// 日内涨跌幅绝对值超过阈值
+	WatcherRuleTypeChange
+	// 价格向上或向下穿越阈值
+	WatcherRuleTypePriceCross
+	// 量比(当前成交量相对过去5日同一时刻的平均成交量)超过阈值
+	WatcherRuleTypeVolumeRatio
+)
+
+const (
+	// 规则未设置间隔时, 同一股票两次提醒的最小间隔(分钟)
+	DefaultWatcherRuleCooldown = 30
+)
+
+func (t WatcherRuleType) String() string {
+	switch t {
+	case WatcherRuleTypeChange:
+		return "涨跌幅"
+	case WatcherRuleTypePriceCross:
+		return "价格穿越"
+	case WatcherRuleTypeVolumeRatio:
+		return "量比放大"
+	default:
+		return "未知"
+	}
+}
+
+type AddWatcherRuleReq struct {
+	WatcherID int64 `json:"watcher_id"`
+	// 为空表示盯盘内的全部股票和板块, 价格穿越规则必须指定
+	Code      string          `json:"code"`
+	RuleType  WatcherRuleType `json:"rule_type"`
+	Threshold float64         `json:"threshold"`
+	// 同一股票两次提醒的最小间隔(分钟)
+	Cooldown int `json:"cooldown"`
+}
+
+type GetWatcherRulesReq struct {
+	WatcherID int64 `json:"watcher_id" query:"watcher_id"`
+}
+
+type DeleteWatcherRuleReq struct {
+	ID int64 `json:"id"`
+}
+
+type WatcherRule struct {
+	ID           uint            `json:"id"`
+	WatcherID    uint            `json:"watcher_id"`
+	Code         string          `json:"code"`
+	Name         string          `json:"name"`
+	RuleType     WatcherRuleType `json:"rule_type"`
+	RuleTypeName string          `json:"rule_type_name"`
+	Threshold    float64         `json:"threshold"`
+	Cooldown     int             `json:"cooldown"`
+}
+
+type GetWatcherAlertsReq struct {
+	WatcherID int64 `json:"watcher_id" query:"watcher_id"`
+	// 默认当天
+	Date string `json:"date" query:"date"`
+}
+
+type WatcherAlert struct {
+	WatcherID    uint            `json:"watcher_id"`
+	WatcherName  string          `json:"watcher_name"`
+	RuleID       uint            `json:"rule_id"`
+	Code         string          `json:"code"`
+	Name         string          `json:"name"`
+	RuleType     WatcherRuleType `json:"rule_type"`
+	RuleTypeName string          `json:"rule_type_name"`
+	Price        float64         `json:"price"`
+	Percent      float64         `json:"percent"`
+	Message      string          `json:"message"`
+	AlertTime    string          `json:"alert_time"`
+}
+
+type RunWatcherMonitorReq struct {
+	// 忽略交易时间限制, 用于手动检查
+	Force bool `json:"force"`
+}
+
+type RunWatcherMonitorResp struct {
+	QuoteCount int             `json:"quote_count"`
+	Alerts     []*WatcherAlert `json:"alerts"`
+	// 因为频率限制而没有发送的提醒数量
+	Suppressed int `json:"suppressed"`
+	// 上一次轮询还没有结束, 本次跳过
+	Skipped bool `json:"skipped"`
+}
+
+const (
+	WatcherStreamEventQuote  = "quote"
+	WatcherStreamEventMinute = "minute"
+)
+
+type WatcherStreamReq struct {
+	ID int64 `path:"id"`
+}
+
+// WatcherStreamEvent 推送给客户端的一条 SSE 消息
+type WatcherStreamEvent struct {
+	Event string
+	Data  interface{}
+}
+
+// WatcherMinuteBars 分时数据, Reset 为 true 时 Bars 是当天的全部数据, 否则只包含新增的部分
+type WatcherMinuteBars struct {
+	Code  string             `json:"code"`
+	Reset bool               `json:"reset"`
+	Bars  []*StockMinuteData `json:"bars"`
+}
@@ -190,6 +190,10 @@ func AnalyzeBolling(ctx context.Context, req model.AnalyzeStockCodeReq) (*model.
 	}
 	stockPriceList = utils.ListSwap(stockPriceList)
 
+	return analyzeBollingPoint(ctx, stockPriceList)
+}
+
+func analyzeBollingPoint(ctx context.Context, stockPriceList []*dal.StockPrice) (*model.AnalyzeStockCodeResp, error) {
 	lastStockPrice := stockPriceList[len(stockPriceList)-1]
 	// 布林线只有三条线，主要是看当前所在的区间即可
 	bollingValue := &model.BollingValue{
@@ -245,9 +249,6 @@ func AnalyzeBolling(ctx context.Context, req model.AnalyzeStockCodeReq) (*model.
 }
 
 func AnalyzeMacd(ctx context.Context, req model.AnalyzeStockCodeReq) (*model.AnalyzeStockCodeResp, error) {
-	resp := model.AnalyzeStockCodeResp{
-		SuggestOperation: model.StockSuggestOperationNone,
-	}
 	// 根据macd分析买点
 	limit := 50
 	stockPriceList, err := dal.GetStockPriceByDate(ctx, req.Code, "", req.Date, limit)
@@ -258,6 +259,14 @@ func AnalyzeMacd(ctx context.Context, req model.AnalyzeStockCodeReq) (*model.Ana
 		return nil, fmt.Errorf("no stock price data, please sync first")
 	}
 	stockPriceList = utils.ListSwap(stockPriceList)
+
+	return analyzeMacdPoint(ctx, stockPriceList)
+}
+
+func analyzeMacdPoint(ctx context.Context, stockPriceList []*dal.StockPrice) (*model.AnalyzeStockCodeResp, error) {
+	resp := model.AnalyzeStockCodeResp{
+		SuggestOperation: model.StockSuggestOperationNone,
+	}
 	// 分析macd
 
 	buyPointResult := AnalyzeMacdBuyPoint(stockPriceList)
@@ -310,6 +310,10 @@ func (c *BaiduClient) GetRemoteStockMinute(ctx context.Context, code string) ([]
 	return result, nil
 }
 
+func (c *BaiduClient) GetRemoteStockQuote(ctx context.Context, codeList []string) ([]*model.StockQuote, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func (c *BaiduClient) GetRemoteUnusualStock(ctx context.Context) ([]*model.UnusualStock, error) {
 	return nil, fmt.Errorf("not implemented")
 }
@@ -322,6 +326,10 @@ func (c *BaiduClient) GetRemoteMarketRisk(ctx context.Context) ([]*model.Unusual
 	return nil, fmt.Errorf("not implemented")
 }
 
+func (c *BaiduClient) GetRemoteStockShares(ctx context.Context) ([]*model.StockShareData, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func (c *BaiduClient) GetRemoteUnusualPredict(ctx context.Context) ([]*model.UnusualPredict, error) {
 	return nil, fmt.Errorf("not implemented")
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sort"
 	"strings"
 	"time"
@@ -15,6 +16,8 @@ import (
 const (
 	ConceptCacheKey  = "concept_key"
 	MaxConceptJobNum = 3
+	// 概念走势默认的天数
+	DefaultConceptTrendDays = 30
 )
 
 // GetConcepts 获取所有概念列表
@@ -343,3 +346,74 @@ func DeleteConceptStock(ctx context.Context, req *model.DeleteConceptStockReq) e
 
 	return dal.UpdateConcept(ctx, concept)
 }
+
+// GetConceptTrend 按加权方式计算概念的走势, 走势数据为相对起始日的涨跌幅
+func GetConceptTrend(ctx context.Context, req *model.GetConceptTrendReq) (*model.GetConceptTrendResp, error) {
+	weighting, err := model.ParseTrendWeighting(req.Weighting)
+	if err != nil {
+		return nil, err
+	}
+	groupList, err := getConceptTrendGroupList(ctx, req.ConceptID)
+	if err != nil {
+		return nil, err
+	}
+	days := req.Days
+	if days <= 0 {
+		days = DefaultConceptTrendDays
+	}
+	trendList, err := wrapGetGroupTrendDetail(ctx, &model.GetIndustryTrendDataReq{
+		Days:        days,
+		EndDate:     req.EndDate,
+		Weighting:   weighting,
+		IncludeRisk: true,
+	}, groupList)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range trendList {
+		for _, priceTrend := range item.PriceTrendList {
+			priceTrend.Price = utils.Float64KeepDecimal((priceTrend.Price-1)*100, 2)
+		}
+	}
+	return &model.GetConceptTrendResp{
+		Weighting:         weighting,
+		ConceptPriceTrend: trendList,
+	}, nil
+}
+
+// getConceptTrendGroupList 将概念转换为计算走势的股票分组, conceptID为0时返回全部概念
+func getConceptTrendGroupList(ctx context.Context, conceptID int64) ([]*trendGroup, error) {
+	var err error
+	concepts := make([]*dal.Concept, 0)
+	if conceptID > 0 {
+		concept, err := dal.GetConcept(ctx, uint(conceptID))
+		if err != nil {
+			return nil, err
+		}
+		if concept == nil {
+			return nil, errors.New("concept not found")
+		}
+		concepts = append(concepts, concept)
+	} else {
+		concepts, err = dal.GetConcepts(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	groupList := make([]*trendGroup, 0, len(concepts))
+	for _, concept := range concepts {
+		group := &trendGroup{
+			Code:          fmt.Sprintf("%d", concept.ID),
+			Name:          concept.Name,
+			StockCodeList: make([]string, 0),
+		}
+		for _, code := range strings.Split(concept.Stocks, ",") {
+			code = strings.TrimSpace(code)
+			if code != "" {
+				group.StockCodeList = append(group.StockCodeList, code)
+			}
+		}
+		groupList = append(groupList, group)
+	}
+	return groupList, nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	DefaultCorrelationDays      = 60
+	DefaultCorrelationThreshold = 0.6
+	// 计算相关系数最少需要的共同交易日数量
+	CorrelationMinSamples = 10
+)
+
+// GetCorrelationMatrix 使用日收益率计算全部行业或概念两两之间的相关系数, 并按平均距离做层次聚类
+func GetCorrelationMatrix(ctx context.Context, req *model.GetCorrelationMatrixReq) (*model.GetCorrelationMatrixResp, error) {
+	corrType := req.Type
+	if corrType == "" {
+		corrType = model.CorrelationTypeIndustry
+	}
+	weighting, err := model.ParseTrendWeighting(req.Weighting)
+	if err != nil {
+		return nil, err
+	}
+	days := req.Days
+	if days <= 0 {
+		days = DefaultCorrelationDays
+	}
+	threshold := req.Threshold
+	if threshold == 0 {
+		threshold = DefaultCorrelationThreshold
+	}
+	trendReq := &model.GetIndustryTrendDataReq{
+		Days:        days + 1,
+		EndDate:     req.EndDate,
+		Weighting:   weighting,
+		IncludeRisk: true,
+	}
+	var trendList []*model.IndustryPriceTrend
+	switch corrType {
+	case model.CorrelationTypeIndustry:
+		trendList, err = GetIndustryTrendDetail(ctx, trendReq)
+	case model.CorrelationTypeConcept:
+		groupList, groupErr := getConceptTrendGroupList(ctx, 0)
+		if groupErr != nil {
+			return nil, groupErr
+		}
+		trendList, err = wrapGetGroupTrendDetail(ctx, trendReq, groupList)
+	default:
+		return nil, fmt.Errorf("invalid type %s", corrType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &model.GetCorrelationMatrixResp{
+		Type:      corrType,
+		Weighting: weighting,
+		Threshold: threshold,
+		Codes:     make([]*model.CodeBasic, 0, len(trendList)),
+		Matrix:    make([][]float64, 0, len(trendList)),
+		Linkage:   make([]*model.ClusterLinkage, 0),
+		Clusters:  make([]*model.CorrelationCluster, 0),
+	}
+	diffMapList := make([]map[string]float64, 0, len(trendList))
+	for _, trend := range trendList {
+		ret.Codes = append(ret.Codes, &model.CodeBasic{
+			Code: trend.IndustryCode,
+			Name: trend.IndustryName,
+		})
+		// 走势中的Diff为当日的涨跌幅
+		diffMap := make(map[string]float64)
+		for _, item := range trend.PriceTrendList {
+			diffMap[item.DateString] = item.Diff
+			if ret.StartDate == "" || item.DateString < ret.StartDate {
+				ret.StartDate = item.DateString
+			}
+			if item.DateString > ret.EndDate {
+				ret.EndDate = item.DateString
+			}
+		}
+		diffMapList = append(diffMapList, diffMap)
+	}
+	n := len(trendList)
+	for i := 0; i < n; i++ {
+		ret.Matrix = append(ret.Matrix, make([]float64, n))
+		ret.Matrix[i][i] = 1
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			correlation := calReturnCorrelation(diffMapList[i], diffMapList[j])
+			if math.IsNaN(correlation) {
+				correlation = 0
+			}
+			correlation = utils.Float64KeepDecimal(correlation, 4)
+			ret.Matrix[i][j] = correlation
+			ret.Matrix[j][i] = correlation
+		}
+	}
+	ret.Linkage = calAverageLinkage(ret.Matrix)
+	for _, members := range cutClusterLinkage(n, ret.Linkage, threshold) {
+		cluster := &model.CorrelationCluster{
+			AvgCorrelation: 1,
+			Members:        make([]*model.CodeBasic, 0, len(members)),
+		}
+		sum, count := 0.0, 0
+		for i, a := range members {
+			cluster.Members = append(cluster.Members, ret.Codes[a])
+			for _, b := range members[i+1:] {
+				sum += ret.Matrix[a][b]
+				count++
+			}
+		}
+		if count > 0 {
+			cluster.AvgCorrelation = utils.Float64KeepDecimal(sum/float64(count), 4)
+		}
+		ret.Clusters = append(ret.Clusters, cluster)
+	}
+	sort.SliceStable(ret.Clusters, func(i, j int) bool {
+		return len(ret.Clusters[i].Members) > len(ret.Clusters[j].Members)
+	})
+	return ret, nil
+}
+
+// calReturnCorrelation 按共同交易日对齐两组日收益率后计算皮尔逊系数, 样本不足时返回NaN
+func calReturnCorrelation(diffMap1, diffMap2 map[string]float64) float64 {
+	dateList := make([]string, 0, len(diffMap1))
+	for date := range diffMap1 {
+		if _, ok := diffMap2[date]; ok {
+			dateList = append(dateList, date)
+		}
+	}
+	if len(dateList) < CorrelationMinSamples {
+		return math.NaN()
+	}
+	x := make([]float64, 0, len(dateList))
+	y := make([]float64, 0, len(dateList))
+	for _, date := range dateList {
+		x = append(x, diffMap1[date])
+		y = append(y, diffMap2[date])
+	}
+	return calPearsonCorrelation(x, y)
+}
+
+// calAverageLinkage 以相关系数为相似度做平均连接的层次聚类, 每次合并平均相关系数最高的两个簇
+func calAverageLinkage(matrix [][]float64) []*model.ClusterLinkage {
+	n := len(matrix)
+	ret := make([]*model.ClusterLinkage, 0, n)
+	if n < 2 {
+		return ret
+	}
+	// 当前存活的簇, 记录簇的编号和大小, 以及簇之间的平均相关系数
+	idList := make([]int, n)
+	sizeList := make([]int, n)
+	sim := make([][]float64, n)
+	for i := 0; i < n; i++ {
+		idList[i] = i
+		sizeList[i] = 1
+		sim[i] = make([]float64, n)
+		copy(sim[i], matrix[i])
+	}
+	alive := make([]bool, n)
+	for i := range alive {
+		alive[i] = true
+	}
+	for step := 0; step < n-1; step++ {
+		a, b := -1, -1
+		best := math.Inf(-1)
+		for i := 0; i < n; i++ {
+			if !alive[i] {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if alive[j] && sim[i][j] > best {
+					best = sim[i][j]
+					a, b = i, j
+				}
+			}
+		}
+		size := sizeList[a] + sizeList[b]
+		ret = append(ret, &model.ClusterLinkage{
+			ID:          n + step,
+			Left:        idList[a],
+			Right:       idList[b],
+			Correlation: utils.Float64KeepDecimal(best, 4),
+			Size:        size,
+		})
+		// 合并后的簇放在a的位置, 与其他簇的相似度按簇大小加权
+		for k := 0; k < n; k++ {
+			if !alive[k] || k == a || k == b {
+				continue
+			}
+			s := (sim[a][k]*float64(sizeList[a]) + sim[b][k]*float64(sizeList[b])) / float64(size)
+			sim[a][k] = s
+			sim[k][a] = s
+		}
+		idList[a] = n + step
+		sizeList[a] = size
+		alive[b] = false
+	}
+	return ret
+}
+
+// cutClusterLinkage 只执行平均相关系数不低于阈值的合并, 返回各个簇包含的板块下标
+func cutClusterLinkage(n int, linkage []*model.ClusterLinkage, threshold float64) [][]int {
+	memberMap := make(map[int][]int)
+	for i := 0; i < n; i++ {
+		memberMap[i] = []int{i}
+	}
+	for _, link := range linkage {
+		// 平均连接的合并相似度单调不增, 之后的合并都低于阈值
+		if link.Correlation < threshold {
+			break
+		}
+		memberMap[link.ID] = append(memberMap[link.Left], memberMap[link.Right]...)
+		delete(memberMap, link.Left)
+		delete(memberMap, link.Right)
+	}
+	idList := make([]int, 0, len(memberMap))
+	for id := range memberMap {
+		idList = append(idList, id)
+	}
+	sort.Ints(idList)
+	ret := make([][]int, 0, len(idList))
+	for _, id := range idList {
+		ret = append(ret, memberMap[id])
+	}
+	return ret
+}
@@ -26,9 +26,15 @@ const (
 	EastMoneyFundFlowPath       = "/api/qt/stock/fflow/daykline/get"
 	EastMoneyMarketRiskPath     = "/emcfg/stock_monitor.json"
 	EastMoneyUnusualPredictPath = "/price-anomaly/list"
+	EastMoneyStockQuotePath     = "/api/qt/ulist.np/get"
 
 	KLineTypeDay   = "101"
 	KLineType30Min = "30"
+
+	// 批量获取实时行情时每次请求的代码数量
+	EastMoneyQuoteBatchSize = 100
+	// 东方财富的板块市场编号
+	EastMoneyIndustryMarket = "90"
 )
 
 var (
@@ -421,6 +427,70 @@ func (c *EastMoneyClient) GetLatestRemoteFundFlow(ctx context.Context) ([]*model
 	return data, nil
 }
 
+// GetRemoteStockShares 获取全市场股票的最新价格以及总市值和流通市值
+func (c *EastMoneyClient) GetRemoteStockShares(ctx context.Context) ([]*model.StockShareData, error) {
+	data := make([]*model.StockShareData, 0)
+
+	path := fmt.Sprintf("%s%s", EastMoneyDomain2, EastMoneyIndustryPath)
+	pageSize := 100
+	params := map[string]string{
+		"fid":    "f12",
+		"po":     "0",
+		"pz":     fmt.Sprintf("%d", pageSize),
+		"np":     "1",
+		"fltt":   "2",
+		"invt":   "2",
+		"fs":     "m:0+t:6+f:!2,m:0+t:13+f:!2,m:0+t:80+f:!2,m:1+t:2+f:!2,m:1+t:23+f:!2,m:0+t:7+f:!2,m:1+t:3+f:!2",
+		"fields": "f2,f12,f14,f20,f21",
+	}
+	pageOffset := 1
+	for {
+		params["pn"] = fmt.Sprintf("%d", pageOffset)
+		resp, err := DoGet(ctx, path, params, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var ret model.EMGetRemoteFundFlowResp
+		err = json.Unmarshal(resp, &ret)
+		if err != nil {
+			log.Printf("json unmarshal failed: %v", err)
+			return nil, err
+		}
+
+		if ret.Data == nil || ret.Data.Total <= 0 {
+			break
+		}
+		for _, item := range ret.Data.Diff {
+			d := &model.StockShareData{}
+			if code, ok := item["f12"]; ok {
+				d.Code = c.GetFullStockCode(fmt.Sprintf("%v", code))
+			}
+			if name, ok := item["f14"]; ok {
+				d.Name = fmt.Sprintf("%v", name)
+			}
+			if price, ok := item["f2"]; ok {
+				d.Price = utils.ToFloat64(price)
+			}
+			if totalMarketCap, ok := item["f20"]; ok {
+				d.TotalMarketCap = utils.ToFloat64(totalMarketCap)
+			}
+			if floatMarketCap, ok := item["f21"]; ok {
+				d.FloatMarketCap = utils.ToFloat64(floatMarketCap)
+			}
+			data = append(data, d)
+		}
+
+		if ret.Data.Total <= pageSize*pageOffset {
+			break
+		}
+
+		pageOffset++
+	}
+
+	return data, nil
+}
+
 func (c *EastMoneyClient) GetRemoteFundFlowByCode(ctx context.Context, code string) ([]*model.FundFlowData, error) {
 	data := make([]*model.FundFlowData, 0)
 
@@ -478,6 +548,68 @@ func (c *EastMoneyClient) GetRemoteStockMinute(ctx context.Context, code string)
 	return nil, fmt.Errorf("not implemented")
 }
 
+func (c *EastMoneyClient) GetRemoteStockQuote(ctx context.Context, codeList []string) ([]*model.StockQuote, error) {
+	path := fmt.Sprintf("%s%s", EastMoneyDomain2, EastMoneyStockQuotePath)
+	data := make([]*model.StockQuote, 0, len(codeList))
+	for start := 0; start < len(codeList); start += EastMoneyQuoteBatchSize {
+		end := start + EastMoneyQuoteBatchSize
+		if end > len(codeList) {
+			end = len(codeList)
+		}
+		secidList := make([]string, 0, end-start)
+		for _, code := range codeList[start:end] {
+			if utils.IsIndustryCode(code) {
+				secidList = append(secidList, fmt.Sprintf("%s.%s", EastMoneyIndustryMarket, code))
+			} else {
+				secidList = append(secidList, c.GetEastMoneyId(code))
+			}
+		}
+		params := map[string]string{
+			"fltt":   "2",
+			"invt":   "2",
+			"secids": strings.Join(secidList, ","),
+			"fields": "f2,f3,f5,f6,f10,f12,f13,f14,f15,f16,f17,f18,f124",
+		}
+		resp, err := DoGet(ctx, path, params, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var ret model.EMGetRemoteFundFlowResp
+		err = json.Unmarshal(resp, &ret)
+		if err != nil {
+			log.Printf("json unmarshal failed: %v", err)
+			return nil, err
+		}
+		if ret.Data == nil {
+			continue
+		}
+		for _, item := range ret.Data.Diff {
+			d := &model.StockQuote{
+				Name:        fmt.Sprintf("%v", item["f14"]),
+				Price:       utils.ToFloat64(item["f2"]),
+				Percent:     utils.ToFloat64(item["f3"]),
+				Volume:      utils.ToFloat64(item["f5"]),
+				Amount:      utils.ToFloat64(item["f6"]),
+				VolumeRatio: utils.ToFloat64(item["f10"]),
+				High:        utils.ToFloat64(item["f15"]),
+				Low:         utils.ToFloat64(item["f16"]),
+				Open:        utils.ToFloat64(item["f17"]),
+				PreClose:    utils.ToFloat64(item["f18"]),
+				UpdateTime:  int64(utils.ToFloat64(item["f124"])),
+			}
+			code := fmt.Sprintf("%v", item["f12"])
+			if fmt.Sprintf("%v", item["f13"]) == EastMoneyIndustryMarket {
+				d.Code = code
+			} else {
+				d.Code = c.GetFullStockCode(code)
+			}
+			data = append(data, d)
+		}
+	}
+	return data, nil
+}
+
 func (c *EastMoneyClient) GetRemoteUnusualStock(ctx context.Context) ([]*model.UnusualStock, error) {
 	path := fmt.Sprintf("%s%s", EastMoneyDomain, EastMoneyBasicPath)
 	params := map[string]string{
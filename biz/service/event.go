@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/zhikongming/stock/biz/dal"
@@ -17,37 +18,170 @@ func CreateEvent(ctx context.Context, req *model.CreateEventReq) error {
 	}
 
 	// 处理一下股票数据
-	stocks := make([]string, 0)
-	slices := strings.Split(req.Stocks, ",")
+	stocks, err := normalizeEventStockList(ctx, req.Stocks, nil)
+	if err != nil {
+		return err
+	}
+	industries, err := normalizeEventIndustryList(ctx, req.IndustryCodes)
+	if err != nil {
+		return err
+	}
+	concepts, err := normalizeEventConceptList(ctx, req.ConceptIDs)
+	if err != nil {
+		return err
+	}
+
+	event := &dal.Event{
+		Date:       req.Date,
+		Event:      req.Event,
+		Comment:    req.Comment,
+		Stocks:     strings.Join(stocks, ","),
+		Category:   strings.TrimSpace(req.Category),
+		Tags:       strings.Join(normalizeEventTagList(req.Tags), ","),
+		Industries: strings.Join(industries, ","),
+		Concepts:   strings.Join(concepts, ","),
+	}
+
+	return dal.CreateEvent(ctx, event)
+}
+
+// normalizeEventStockList 将逗号分隔的股票转换为带前缀的股票代码, 支持纯数字代码、"代码(名称)"格式和股票名称
+// nameMap 为名称到代码的映射, 为空时按名称逐个查询
+func normalizeEventStockList(ctx context.Context, stocks string, nameMap map[string]string) ([]string, error) {
+	ret := make([]string, 0)
+	slices := strings.Split(stocks, ",")
 	for _, item := range slices {
 		stock := strings.TrimSpace(item)
 		if len(stock) == 0 {
 			continue
 		}
+		var code string
 		if utils.IsStockCodeWithPrefix(stock) {
-			stocks = append(stocks, stock)
+			code = stock
 		} else if utils.IsStockNumber(stock) {
-			stocks = append(stocks, utils.GetFullStockCodeOfNumber(stock))
+			code = utils.GetFullStockCodeOfNumber(stock)
+		} else if len(stock) >= 8 && utils.IsStockCodeWithPrefix(stock[:8]) {
+			code = stock[:8]
 		} else {
-			if len(stock) < 8 {
-				return fmt.Errorf("stock code %s is invalid", stock)
+			var err error
+			code, err = getEventStockCodeByName(ctx, stock, nameMap)
+			if err != nil {
+				return nil, err
 			}
-			stock = stock[:8]
-			if !utils.IsStockCodeWithPrefix(stock) {
-				return fmt.Errorf("stock code %s is invalid", stock)
+			if code == "" {
+				return nil, fmt.Errorf("stock code %s is invalid", stock)
 			}
-			stocks = append(stocks, stock)
+		}
+		if !utils.In(code, ret) {
+			ret = append(ret, code)
 		}
 	}
+	return ret, nil
+}
 
-	event := &dal.Event{
-		Date:    req.Date,
-		Event:   req.Event,
-		Comment: req.Comment,
-		Stocks:  strings.Join(stocks, ","),
+func getEventStockCodeByName(ctx context.Context, name string, nameMap map[string]string) (string, error) {
+	if nameMap != nil {
+		return nameMap[name], nil
+	}
+	stockCode, err := dal.GetStockCodeByName(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if stockCode == nil {
+		return "", nil
 	}
+	return stockCode.CompanyCode, nil
+}
 
-	return dal.CreateEvent(ctx, event)
+// normalizeEventTagList 标签去除空白和重复, 标签内不能包含逗号
+func normalizeEventTagList(tags string) []string {
+	return splitEventField(tags)
+}
+
+// splitEventField 拆分逗号分隔的字段, 去除空白和重复
+func splitEventField(value string) []string {
+	ret := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" && !utils.In(item, ret) {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+func normalizeEventIndustryList(ctx context.Context, industryCodes string) ([]string, error) {
+	if strings.TrimSpace(industryCodes) == "" {
+		return make([]string, 0), nil
+	}
+	industrySet, err := getEventIndustrySet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return checkEventIndustryList(industryCodes, industrySet)
+}
+
+func getEventIndustrySet(ctx context.Context) (map[string]bool, error) {
+	industryList, err := dal.GetAllStockIndustry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	industrySet := make(map[string]bool)
+	for _, industry := range industryList {
+		industrySet[industry.Code] = true
+	}
+	return industrySet, nil
+}
+
+// checkEventIndustryList 去重并校验行业代码是否存在
+func checkEventIndustryList(industryCodes string, industrySet map[string]bool) ([]string, error) {
+	ret := make([]string, 0)
+	for _, item := range strings.Split(industryCodes, ",") {
+		code := strings.TrimSpace(item)
+		if code == "" || utils.In(code, ret) {
+			continue
+		}
+		if !industrySet[code] {
+			return nil, fmt.Errorf("industry code %s is invalid", code)
+		}
+		ret = append(ret, code)
+	}
+	return ret, nil
+}
+
+func normalizeEventConceptList(ctx context.Context, conceptIDs string) ([]string, error) {
+	if strings.TrimSpace(conceptIDs) == "" {
+		return make([]string, 0), nil
+	}
+	conceptList, err := dal.GetConcepts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conceptSet := make(map[uint]bool)
+	for _, concept := range conceptList {
+		conceptSet[concept.ID] = true
+	}
+	return checkEventConceptList(conceptIDs, conceptSet)
+}
+
+// checkEventConceptList 去重并校验概念ID是否存在
+func checkEventConceptList(conceptIDs string, conceptSet map[uint]bool) ([]string, error) {
+	ret := make([]string, 0)
+	for _, item := range strings.Split(conceptIDs, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || utils.In(item, ret) {
+			continue
+		}
+		id, err := strconv.ParseUint(item, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("concept id %s is invalid", item)
+		}
+		if !conceptSet[uint(id)] {
+			return nil, fmt.Errorf("concept %d not found", id)
+		}
+		ret = append(ret, item)
+	}
+	return ret, nil
 }
 
 // UpdateEvent 更新事件
@@ -69,28 +203,30 @@ func UpdateEvent(ctx context.Context, req *model.UpdateEventReq) error {
 	if req.Comment != "" {
 		event.Comment = req.Comment
 	}
-	// 处理一下股票数据
-	stocks := make([]string, 0)
-	slices := strings.Split(req.Stocks, ",")
-	for _, item := range slices {
-		stock := strings.TrimSpace(item)
-		if len(stock) == 0 {
-			continue
+	if req.Category != nil {
+		event.Category = strings.TrimSpace(*req.Category)
+	}
+	if req.Tags != nil {
+		event.Tags = strings.Join(normalizeEventTagList(*req.Tags), ",")
+	}
+	if req.IndustryCodes != nil {
+		industries, err := normalizeEventIndustryList(ctx, *req.IndustryCodes)
+		if err != nil {
+			return err
 		}
-		if utils.IsStockCodeWithPrefix(stock) {
-			stocks = append(stocks, stock)
-		} else if utils.IsStockNumber(stock) {
-			stocks = append(stocks, utils.GetFullStockCodeOfNumber(stock))
-		} else {
-			if len(stock) < 8 {
-				return fmt.Errorf("stock code %s is invalid", stock)
-			}
-			stock = stock[:8]
-			if !utils.IsStockCodeWithPrefix(stock) {
-				return fmt.Errorf("stock code %s is invalid", stock)
-			}
-			stocks = append(stocks, stock)
+		event.Industries = strings.Join(industries, ",")
+	}
+	if req.ConceptIDs != nil {
+		concepts, err := normalizeEventConceptList(ctx, *req.ConceptIDs)
+		if err != nil {
+			return err
 		}
+		event.Concepts = strings.Join(concepts, ",")
+	}
+	// 处理一下股票数据
+	stocks, err := normalizeEventStockList(ctx, req.Stocks, nil)
+	if err != nil {
+		return err
 	}
 	event.Stocks = strings.Join(stocks, ",")
 
@@ -110,72 +246,119 @@ func DeleteEvent(ctx context.Context, req *model.DeleteEventReq) error {
 	return dal.DeleteEvent(ctx, req.ID)
 }
 
-// GetEventTimeline 获取事件时间轴
-func GetEventTimeline(ctx context.Context) ([]*model.TimelineEventResp, error) {
-	events, err := dal.GetEvents(ctx)
+// GetEventTimeline 按条件分页获取事件时间轴, 数据库已按日期降序返回, 同一天的事件合并为一组
+func GetEventTimeline(ctx context.Context, req *model.GetEventTimelineReq) (*model.GetEventTimelineResp, error) {
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = model.DefaultEventPageSize
+	}
+	pageSize = min(pageSize, model.MaxEventPageSize)
+	filter := &dal.EventFilter{
+		StartDate:    req.StartDate,
+		EndDate:      req.EndDate,
+		Category:     strings.TrimSpace(req.Category),
+		Tag:          strings.TrimSpace(req.Tag),
+		IndustryCode: strings.TrimSpace(req.IndustryCode),
+		ConceptID:    req.ConceptID,
+		Keyword:      strings.TrimSpace(req.Keyword),
+	}
+	if req.Stock != "" {
+		stocks, err := normalizeEventStockList(ctx, req.Stock, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(stocks) > 0 {
+			filter.Stock = stocks[0]
+		}
+	}
+	events, total, err := dal.GetEventList(ctx, filter, (page-1)*pageSize, pageSize)
 	if err != nil {
 		return nil, err
 	}
+	ret := &model.GetEventTimelineResp{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Items:    make([]*model.TimelineEventResp, 0),
+	}
+	if len(events) == 0 {
+		return ret, nil
+	}
 
-	// 按日期分组
-	dateMap := make(map[string][]*model.EventResp)
+	// 填充股票、行业和概念的名称
 	allStockCodeList := make([]string, 0)
 	for _, event := range events {
-		event.Date = utils.FormatDate(utils.ParseDateWithRegion(event.Date))
-		if _, ok := dateMap[event.Date]; !ok {
-			dateMap[event.Date] = make([]*model.EventResp, 0)
-		}
-		stockCodeList := strings.Split(event.Stocks, ",")
-		allStockCodeList = append(allStockCodeList, stockCodeList...)
-		eventResp := &model.EventResp{
-			ID:      event.ID,
-			Date:    event.Date,
-			Event:   event.Event,
-			Comment: event.Comment,
-		}
-		for _, stockCode := range stockCodeList {
-			eventResp.Stocks = append(eventResp.Stocks, &model.CodeBasic{
-				Code: stockCode,
-				Name: "",
-			})
-		}
-		dateMap[event.Date] = append(dateMap[event.Date], eventResp)
+		allStockCodeList = append(allStockCodeList, getEventStockCodeList(event)...)
 	}
-	// 填充股票数据
 	allStockBasicList, err := GetCodeBasicByCodeList(ctx, allStockCodeList)
 	if err != nil {
 		return nil, err
 	}
-	for _, eventList := range dateMap {
-		for _, eventResp := range eventList {
-			for _, stock := range eventResp.Stocks {
-				for _, stockBasic := range allStockBasicList {
-					if stock.Code == stockBasic.Code {
-						stock.Name = stockBasic.Name
-						break
-					}
-				}
-			}
-		}
+	stockNameMap := make(map[string]string)
+	for _, stockBasic := range allStockBasicList {
+		stockNameMap[stockBasic.Code] = stockBasic.Name
 	}
-
-	// 转换为时间轴格式
-	result := make([]*model.TimelineEventResp, 0)
-	for date, eventList := range dateMap {
-		result = append(result, &model.TimelineEventResp{
-			Date:   date,
-			Events: eventList,
-		})
+	industryList, err := dal.GetAllStockIndustry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	industryNameMap := make(map[string]string)
+	for _, industry := range industryList {
+		industryNameMap[industry.Code] = industry.Name
+	}
+	conceptList, err := dal.GetConcepts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conceptNameMap := make(map[string]string)
+	for _, concept := range conceptList {
+		conceptNameMap[utils.ToString(concept.ID)] = concept.Name
 	}
 
-	// 按日期排序（降序）
-	for i := 0; i < len(result)-1; i++ {
-		for j := i + 1; j < len(result); j++ {
-			if result[j].Date > result[i].Date {
-				result[i], result[j] = result[j], result[i]
+	var group *model.TimelineEventResp
+	for _, event := range events {
+		event.Date = utils.FormatDate(utils.ParseDateWithRegion(event.Date))
+		if group == nil || group.Date != event.Date {
+			group = &model.TimelineEventResp{
+				Date:   event.Date,
+				Events: make([]*model.EventResp, 0),
 			}
+			ret.Items = append(ret.Items, group)
+		}
+		eventResp := &model.EventResp{
+			ID:         event.ID,
+			Date:       event.Date,
+			Event:      event.Event,
+			Comment:    event.Comment,
+			Category:   event.Category,
+			Stocks:     make([]*model.CodeBasic, 0),
+			Tags:       splitEventField(event.Tags),
+			Industries: make([]*model.CodeBasic, 0),
+			Concepts:   make([]*model.CodeBasic, 0),
+		}
+		for _, stockCode := range getEventStockCodeList(event) {
+			eventResp.Stocks = append(eventResp.Stocks, &model.CodeBasic{
+				Code: stockCode,
+				Name: stockNameMap[stockCode],
+			})
+		}
+		for _, code := range splitEventField(event.Industries) {
+			eventResp.Industries = append(eventResp.Industries, &model.CodeBasic{
+				Code: code,
+				Name: industryNameMap[code],
+			})
 		}
+		for _, id := range splitEventField(event.Concepts) {
+			eventResp.Concepts = append(eventResp.Concepts, &model.CodeBasic{
+				Code: id,
+				Name: conceptNameMap[id],
+			})
+		}
+		group.Events = append(group.Events, eventResp)
 	}
-
-	return result, nil
+	return ret, nil
 }
@@ -0,0 +1,470 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	DefaultEventImpactDays = 365
+	MaxEventImpactWindow   = 60
+	// 未设置分类的事件在汇总时使用的分类名称
+	EventImpactDefaultCategory = "未分类"
+)
+
+var DefaultEventImpactWindows = []int{1, 3, 5, 10}
+
+// AnalyzeEventImpact 以事件前一个交易日的收盘价为基准, 计算关联股票及其行业在各个窗口内的累计收益和相对基准指数的超额收益, 并按分类和标签汇总
+func AnalyzeEventImpact(ctx context.Context, req *model.AnalyzeEventImpactReq) (*model.AnalyzeEventImpactResp, error) {
+	windows, err := parseEventImpactWindows(req.Windows)
+	if err != nil {
+		return nil, err
+	}
+	benchmark := req.Benchmark
+	if benchmark == "" {
+		benchmark = utils.GetBasicStockCode()
+	}
+	var eventList []*dal.Event
+	if req.ID > 0 {
+		event, err := dal.GetEvent(ctx, req.ID)
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			return nil, fmt.Errorf("event not found")
+		}
+		eventList = append(eventList, event)
+	} else {
+		startDate := req.StartDate
+		if startDate == "" {
+			startDate = utils.FormatDate(time.Now().AddDate(0, 0, -DefaultEventImpactDays))
+		}
+		eventList, err = dal.GetEventsByDate(ctx, startDate, req.EndDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ret := &model.AnalyzeEventImpactResp{
+		Benchmark:  benchmark,
+		Windows:    windows,
+		Events:     make([]*model.EventImpact, 0),
+		Categories: make([]*model.EventImpactGroupStat, 0),
+		Tags:       make([]*model.EventImpactGroupStat, 0),
+	}
+	filteredList := make([]*dal.Event, 0, len(eventList))
+	for _, event := range eventList {
+		event.Date = utils.FormatDate(utils.ParseDateWithRegion(event.Date))
+		if req.Category != "" && event.Category != req.Category {
+			continue
+		}
+		if req.Tag != "" && !utils.In(req.Tag, splitEventField(event.Tags)) {
+			continue
+		}
+		filteredList = append(filteredList, event)
+	}
+	eventList = filteredList
+	if len(eventList) == 0 {
+		return ret, nil
+	}
+
+	// 关联股票所属的行业以及行业的成分股
+	industryList, err := dal.GetAllStockIndustry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	industryNameMap := make(map[string]string)
+	for _, industry := range industryList {
+		industryNameMap[industry.Code] = industry.Name
+	}
+	relationList, err := dal.GetAllStockIndustryRelation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stockIndustryMap := make(map[string]string)
+	industryStockMap := make(map[string][]string)
+	for _, relation := range relationList {
+		stockIndustryMap[relation.CompanyCode] = relation.IndustryCode
+		industryStockMap[relation.IndustryCode] = append(industryStockMap[relation.IndustryCode], relation.CompanyCode)
+	}
+	codeSet := make(map[string]bool)
+	industrySet := make(map[string]bool)
+	for _, event := range eventList {
+		for _, code := range getEventStockCodeList(event) {
+			codeSet[code] = true
+			if industryCode, ok := stockIndustryMap[code]; ok {
+				industrySet[industryCode] = true
+			}
+		}
+	}
+	codeList := make([]string, 0, len(codeSet))
+	for code := range codeSet {
+		codeList = append(codeList, code)
+	}
+	nameMap, _, err := getStockNameAndIndustryMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate := utils.ParseDate(eventList[0].Date).AddDate(0, 0, -15)
+	endDate := utils.ParseDate(eventList[len(eventList)-1].Date).AddDate(0, 0, windows[len(windows)-1]*2+10)
+	if endDate.After(time.Now()) {
+		endDate = time.Now()
+	}
+	priceMap, err := BatchGetStockPriceByDate(ctx, codeList, utils.FormatDate(startDate), utils.FormatDate(endDate))
+	if err != nil {
+		return nil, err
+	}
+	industryTrendMap, err := getEventIndustryTrendMap(ctx, req.Weighting, industrySet, industryNameMap, industryStockMap, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	benchmarkList, err := getEventBenchmarkPriceList(ctx, benchmark, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryMap := make(map[string]*eventImpactGroup)
+	tagMap := make(map[string]*eventImpactGroup)
+	for _, event := range eventList {
+		baseIdx := sort.Search(len(benchmarkList), func(i int) bool {
+			return benchmarkList[i].Date >= event.Date
+		}) - 1
+		if baseIdx < 0 {
+			continue
+		}
+		impact := &model.EventImpact{
+			ID:         event.ID,
+			Date:       event.Date,
+			Event:      event.Event,
+			Category:   event.Category,
+			Tags:       splitEventField(event.Tags),
+			BaseDate:   benchmarkList[baseIdx].Date,
+			Benchmark:  make([]*model.EventWindowReturn, 0, len(windows)),
+			Stocks:     make([]*model.EventImpactItem, 0),
+			Industries: make([]*model.EventImpactItem, 0),
+		}
+		// 各个窗口的结束日期和基准收益, 未到期的窗口不统计
+		windowEndMap := make(map[int]string)
+		benchmarkReturnMap := make(map[int]float64)
+		for _, days := range windows {
+			if baseIdx+days >= len(benchmarkList) {
+				break
+			}
+			windowEndMap[days] = benchmarkList[baseIdx+days].Date
+			benchmarkReturnMap[days] = (benchmarkList[baseIdx+days].Price/benchmarkList[baseIdx].Price - 1) * 100
+			impact.Benchmark = append(impact.Benchmark, &model.EventWindowReturn{
+				Days:   days,
+				Return: utils.Float64KeepDecimal(benchmarkReturnMap[days], 2),
+			})
+		}
+		category := event.Category
+		if category == "" {
+			category = EventImpactDefaultCategory
+		}
+		groupList := []*eventImpactGroup{getEventImpactGroup(categoryMap, category)}
+		for _, tag := range splitEventField(event.Tags) {
+			groupList = append(groupList, getEventImpactGroup(tagMap, tag))
+		}
+		for _, group := range groupList {
+			group.events++
+		}
+
+		// 同一事件的关联股票先取平均, 再按事件汇总到分类和标签, 避免关联股票多的事件权重过大
+		eventReturnMap := make(map[int][][2]float64)
+		eventIndustrySet := make(map[string]bool)
+		for _, code := range getEventStockCodeList(event) {
+			item := &model.EventImpactItem{
+				Code:    code,
+				Name:    nameMap[code],
+				Returns: make([]*model.EventWindowReturn, 0, len(windows)),
+			}
+			for _, days := range windows {
+				endDate, ok := windowEndMap[days]
+				if !ok {
+					break
+				}
+				r, ok := calEventWindowReturn(priceMap[code], impact.BaseDate, endDate)
+				if !ok {
+					continue
+				}
+				excess := r - benchmarkReturnMap[days]
+				item.Returns = append(item.Returns, &model.EventWindowReturn{
+					Days:   days,
+					Return: utils.Float64KeepDecimal(r, 2),
+					Excess: utils.Float64KeepDecimal(excess, 2),
+				})
+				eventReturnMap[days] = append(eventReturnMap[days], [2]float64{r, excess})
+			}
+			impact.Stocks = append(impact.Stocks, item)
+			if industryCode, ok := stockIndustryMap[code]; ok {
+				eventIndustrySet[industryCode] = true
+			}
+		}
+		for days, returnList := range eventReturnMap {
+			sumReturn, sumExcess := 0.0, 0.0
+			for _, r := range returnList {
+				sumReturn += r[0]
+				sumExcess += r[1]
+			}
+			n := float64(len(returnList))
+			for _, group := range groupList {
+				group.returnMap[days] = append(group.returnMap[days], [2]float64{sumReturn / n, sumExcess / n})
+			}
+		}
+		// 行业收益使用与行业走势一致的加权方式
+		industryCodeList := make([]string, 0, len(eventIndustrySet))
+		for industryCode := range eventIndustrySet {
+			industryCodeList = append(industryCodeList, industryCode)
+		}
+		sort.Strings(industryCodeList)
+		for _, industryCode := range industryCodeList {
+			item := &model.EventImpactItem{
+				Code:    industryCode,
+				Name:    industryNameMap[industryCode],
+				Returns: make([]*model.EventWindowReturn, 0, len(windows)),
+			}
+			for _, days := range windows {
+				endDate, ok := windowEndMap[days]
+				if !ok {
+					break
+				}
+				r, ok := calEventTrendReturn(industryTrendMap[industryCode], impact.BaseDate, endDate)
+				if !ok {
+					continue
+				}
+				item.Returns = append(item.Returns, &model.EventWindowReturn{
+					Days:   days,
+					Return: utils.Float64KeepDecimal(r, 2),
+					Excess: utils.Float64KeepDecimal(r-benchmarkReturnMap[days], 2),
+				})
+			}
+			impact.Industries = append(impact.Industries, item)
+		}
+		ret.Events = append(ret.Events, impact)
+	}
+
+	ret.Categories = toEventImpactGroupStatList(categoryMap, windows)
+	ret.Tags = toEventImpactGroupStatList(tagMap, windows)
+	// 事件按日期倒序返回, 与时间轴保持一致
+	sort.SliceStable(ret.Events, func(i, j int) bool {
+		return ret.Events[i].Date > ret.Events[j].Date
+	})
+	return ret, nil
+}
+
+// eventImpactGroup 汇总同一分类或标签下各个事件在各个窗口的平均收益和超额收益, 每个事件一个样本
+type eventImpactGroup struct {
+	name      string
+	events    int
+	returnMap map[int][][2]float64
+}
+
+func getEventImpactGroup(groupMap map[string]*eventImpactGroup, name string) *eventImpactGroup {
+	group, ok := groupMap[name]
+	if !ok {
+		group = &eventImpactGroup{
+			name:      name,
+			returnMap: make(map[int][][2]float64),
+		}
+		groupMap[name] = group
+	}
+	return group
+}
+
+// toEventImpactGroupStatList 按事件数量降序输出各组的统计
+func toEventImpactGroupStatList(groupMap map[string]*eventImpactGroup, windows []int) []*model.EventImpactGroupStat {
+	ret := make([]*model.EventImpactGroupStat, 0, len(groupMap))
+	for _, group := range groupMap {
+		stat := &model.EventImpactGroupStat{
+			Name:    group.name,
+			Events:  group.events,
+			Windows: make([]*model.EventImpactWindowStat, 0, len(windows)),
+		}
+		for _, days := range windows {
+			returnList := group.returnMap[days]
+			windowStat := &model.EventImpactWindowStat{
+				Days:  days,
+				Count: len(returnList),
+			}
+			if len(returnList) > 0 {
+				sumReturn, sumExcess, win := 0.0, 0.0, 0
+				for _, r := range returnList {
+					sumReturn += r[0]
+					sumExcess += r[1]
+					if r[1] > 0 {
+						win++
+					}
+				}
+				n := float64(len(returnList))
+				windowStat.AvgReturn = utils.Float64KeepDecimal(sumReturn/n, 2)
+				windowStat.AvgExcess = utils.Float64KeepDecimal(sumExcess/n, 2)
+				windowStat.WinRate = utils.Float64KeepDecimal(float64(win)/n*100, 2)
+			}
+			stat.Windows = append(stat.Windows, windowStat)
+		}
+		ret = append(ret, stat)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Events != ret[j].Events {
+			return ret[i].Events > ret[j].Events
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+func parseEventImpactWindows(windows string) ([]int, error) {
+	if strings.TrimSpace(windows) == "" {
+		return DefaultEventImpactWindows, nil
+	}
+	ret := make([]int, 0)
+	for _, item := range strings.Split(windows, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		days, err := strconv.Atoi(item)
+		if err != nil || days <= 0 || days > MaxEventImpactWindow {
+			return nil, fmt.Errorf("invalid window %s", item)
+		}
+		if !utils.In(days, ret) {
+			ret = append(ret, days)
+		}
+	}
+	if len(ret) == 0 {
+		return DefaultEventImpactWindows, nil
+	}
+	sort.Ints(ret)
+	return ret, nil
+}
+
+func getEventStockCodeList(event *dal.Event) []string {
+	return splitEventField(event.Stocks)
+}
+
+// calEventWindowReturn 计算基准日到结束日的累计收益(%), 停牌时使用之前最近一个交易日的收盘价, priceList为正序
+func calEventWindowReturn(priceList []*dal.StockPrice, baseDate string, endDate string) (float64, bool) {
+	base := findPriceOnOrBefore(priceList, baseDate)
+	end := findPriceOnOrBefore(priceList, endDate)
+	if base == nil || end == nil || base.PriceClose <= 0 {
+		return 0, false
+	}
+	return (end.PriceClose/base.PriceClose - 1) * 100, true
+}
+
+// getEventIndustryTrendMap 获取关联行业在日期区间内的加权走势
+func getEventIndustryTrendMap(ctx context.Context, weighting string, industrySet map[string]bool, industryNameMap map[string]string,
+	industryStockMap map[string][]string, startDate time.Time, endDate time.Time) (map[string][]*model.PriceTrend, error) {
+	ret := make(map[string][]*model.PriceTrend)
+	if len(industrySet) == 0 {
+		return ret, nil
+	}
+	groupList := make([]*trendGroup, 0, len(industrySet))
+	for industryCode := range industrySet {
+		groupList = append(groupList, &trendGroup{
+			Code:          industryCode,
+			Name:          industryNameMap[industryCode],
+			StockCodeList: industryStockMap[industryCode],
+		})
+	}
+	dateList, err := dal.GetTradeDateList(ctx, utils.FormatDate(startDate), utils.FormatDate(endDate))
+	if err != nil {
+		return nil, err
+	}
+	if len(dateList) == 0 {
+		return ret, nil
+	}
+	trendList, err := wrapGetGroupTrendDetail(ctx, &model.GetIndustryTrendDataReq{
+		Days:        len(dateList),
+		EndDate:     utils.FormatDate(dateList[len(dateList)-1]),
+		Weighting:   weighting,
+		IncludeRisk: true,
+	}, groupList)
+	if err != nil {
+		return nil, err
+	}
+	for _, trend := range trendList {
+		ret[trend.IndustryCode] = trend.PriceTrendList
+	}
+	return ret, nil
+}
+
+// calEventTrendReturn 根据行业的累计走势计算基准日到结束日的收益(%), trendList为正序
+func calEventTrendReturn(trendList []*model.PriceTrend, baseDate string, endDate string) (float64, bool) {
+	base := findTrendOnOrBefore(trendList, baseDate)
+	end := findTrendOnOrBefore(trendList, endDate)
+	if base == nil || end == nil || base.Price <= 0 {
+		return 0, false
+	}
+	return (end.Price/base.Price - 1) * 100, true
+}
+
+func findTrendOnOrBefore(trendList []*model.PriceTrend, date string) *model.PriceTrend {
+	idx := sort.Search(len(trendList), func(i int) bool {
+		return trendList[i].DateString > date
+	})
+	if idx == 0 {
+		return nil
+	}
+	return trendList[idx-1]
+}
+
+func findPriceOnOrBefore(priceList []*dal.StockPrice, date string) *dal.StockPrice {
+	idx := sort.Search(len(priceList), func(i int) bool {
+		return utils.FormatDate(priceList[i].Date) > date
+	})
+	if idx == 0 {
+		return nil
+	}
+	return priceList[idx-1]
+}
+
+// getEventBenchmarkPriceList 获取基准指数在日期区间内的收盘价, 远程接口每次最多返回300条, 需要向前翻页
+func getEventBenchmarkPriceList(ctx context.Context, code string, start time.Time, end time.Time) ([]*model.DatePrice, error) {
+	client := NewEastMoneyClient()
+	priceMap := make(map[string]float64)
+	cursor := end
+	for {
+		dailyData, err := client.GetRemoteStockDaily(ctx, code, cursor)
+		if err != nil {
+			return nil, err
+		}
+		if dailyData == nil {
+			break
+		}
+		dataList := dailyData.ToDatePriceList()
+		if len(dataList) == 0 {
+			break
+		}
+		for _, item := range dataList {
+			priceMap[item.Date] = item.Price
+		}
+		first := utils.ParseDate(dataList[0].Date)
+		if !first.After(start) || !first.Before(cursor) {
+			break
+		}
+		cursor = first.AddDate(0, 0, -1)
+	}
+	if len(priceMap) == 0 {
+		return nil, fmt.Errorf("benchmark %s data not found", code)
+	}
+	ret := make([]*model.DatePrice, 0, len(priceMap))
+	for date, price := range priceMap {
+		ret = append(ret, &model.DatePrice{
+			Date:  date,
+			Price: price,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Date < ret[j].Date
+	})
+	return ret, nil
+}
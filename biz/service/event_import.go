@@ -0,0 +1,362 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+var (
+	// CSV 表头与字段的对应关系, 支持中英文表头
+	eventImportColumnMap = map[string]string{
+		"date":           "date",
+		"日期":             "date",
+		"event":          "event",
+		"title":          "event",
+		"事件":             "event",
+		"标题":             "event",
+		"comment":        "comment",
+		"备注":             "comment",
+		"stocks":         "stocks",
+		"股票":             "stocks",
+		"category":       "category",
+		"分类":             "category",
+		"tags":           "tags",
+		"标签":             "tags",
+		"industry_codes": "industry_codes",
+		"行业":             "industry_codes",
+		"concept_ids":    "concept_ids",
+		"概念":             "concept_ids",
+	}
+	// 导入时支持的日期格式
+	eventImportDateLayouts = []string{utils.DateFormat, utils.Date2Format, "2006/01/02", "2006/1/2", "2006-1-2"}
+	// 列表字段中除逗号外允许的分隔符, CSV 中可以避免给字段加引号
+	eventImportSeparatorReplacer = strings.NewReplacer(";", ",", "；", ",", "，", ",", "、", ",", "|", ",")
+)
+
+// EventImportFormatError 导入的文件无法解析, 如格式不支持、内容为空或行数超出限制
+type EventImportFormatError struct {
+	Message string
+}
+
+func (e *EventImportFormatError) Error() string {
+	return e.Message
+}
+
+func newEventImportFormatError(format string, args ...interface{}) error {
+	return &EventImportFormatError{Message: fmt.Sprintf(format, args...)}
+}
+
+// eventImportRefs 导入时校验行业和概念用到的数据, 一次性加载避免逐行查询
+type eventImportRefs struct {
+	stockNameMap map[string]string
+	industrySet  map[string]bool
+	conceptSet   map[uint]bool
+}
+
+// ImportEvent 批量导入事件, 逐行校验, 校验通过且不重复的事件才会写入
+func ImportEvent(ctx context.Context, req *model.ImportEventReq, data []byte) (*model.ImportEventResp, error) {
+	rows, err := parseEventImportRows(req.Format, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, newEventImportFormatError("no event found")
+	}
+	if len(rows) > model.MaxEventImportRows {
+		return nil, newEventImportFormatError("too many rows, max is %d", model.MaxEventImportRows)
+	}
+
+	refs, err := getEventImportRefs(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &model.ImportEventResp{
+		DryRun: req.DryRun,
+		Total:  len(rows),
+		Rows:   make([]*model.ImportEventRowResult, 0, len(rows)),
+	}
+	eventList := make([]*dal.Event, 0)
+	resultList := make([]*model.ImportEventRowResult, 0)
+	for _, row := range rows {
+		result := &model.ImportEventRowResult{
+			Row:   row.Line,
+			Date:  strings.TrimSpace(row.Date),
+			Event: strings.TrimSpace(row.Event),
+		}
+		if result.Event == "" {
+			result.Event = strings.TrimSpace(row.Title)
+		}
+		ret.Rows = append(ret.Rows, result)
+
+		event, err := toEventImportEvent(ctx, row, result, refs)
+		if err != nil {
+			result.Status = model.EventImportStatusInvalid
+			result.Message = err.Error()
+			continue
+		}
+		result.Status = model.EventImportStatusValid
+		eventList = append(eventList, event)
+		resultList = append(resultList, result)
+	}
+
+	// 与库中已有事件以及文件中前面的行去重, 日期和内容都相同视为重复
+	eventList, resultList, err = filterDuplicateImportEvent(ctx, eventList, resultList)
+	if err != nil {
+		return nil, err
+	}
+	if !req.DryRun && len(eventList) > 0 {
+		if err := dal.CreateEventList(ctx, eventList); err != nil {
+			return nil, err
+		}
+		for _, result := range resultList {
+			result.Status = model.EventImportStatusCreated
+		}
+	}
+
+	for _, result := range ret.Rows {
+		switch result.Status {
+		case model.EventImportStatusCreated:
+			ret.Created++
+		case model.EventImportStatusValid:
+			ret.Valid++
+		case model.EventImportStatusDuplicate:
+			ret.Duplicate++
+		case model.EventImportStatusInvalid:
+			ret.Invalid++
+		}
+	}
+	return ret, nil
+}
+
+func parseEventImportRows(format string, data []byte) ([]*model.ImportEventRow, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		// 未指定格式时根据内容判断
+		trimmed := bytes.TrimSpace(data)
+		if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+			format = model.EventImportFormatJSON
+		} else {
+			format = model.EventImportFormatCSV
+		}
+	}
+	switch format {
+	case model.EventImportFormatCSV:
+		return parseEventImportCSV(data)
+	case model.EventImportFormatJSON:
+		var rows []*model.ImportEventRow
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, newEventImportFormatError("invalid json: %v", err)
+		}
+		for i, row := range rows {
+			if row == nil {
+				return nil, newEventImportFormatError("invalid json: row %d is null", i+1)
+			}
+			row.Line = i + 1
+		}
+		return rows, nil
+	default:
+		return nil, newEventImportFormatError("format %s is not supported", format)
+	}
+}
+
+func parseEventImportCSV(data []byte) ([]*model.ImportEventRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, newEventImportFormatError("invalid csv: %v", err)
+	}
+	columnMap := make(map[int]string)
+	fieldSet := make(map[string]bool)
+	for i, name := range header {
+		field, ok := eventImportColumnMap[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			continue
+		}
+		columnMap[i] = field
+		fieldSet[field] = true
+	}
+	if !fieldSet["date"] || !fieldSet["event"] {
+		return nil, newEventImportFormatError("csv header must contain date and event columns")
+	}
+
+	rows := make([]*model.ImportEventRow, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, newEventImportFormatError("invalid csv: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		row := &model.ImportEventRow{Line: line}
+		for i, value := range record {
+			switch columnMap[i] {
+			case "date":
+				row.Date = value
+			case "event":
+				row.Event = value
+			case "comment":
+				row.Comment = value
+			case "stocks":
+				row.Stocks = model.EventImportList(value)
+			case "category":
+				row.Category = value
+			case "tags":
+				row.Tags = model.EventImportList(value)
+			case "industry_codes":
+				row.IndustryCodes = model.EventImportList(value)
+			case "concept_ids":
+				row.ConceptIDs = model.EventImportList(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// getEventImportRefs 按需一次性加载股票名称、行业和概念, 文件中没有对应字段时不加载
+func getEventImportRefs(ctx context.Context, rows []*model.ImportEventRow) (*eventImportRefs, error) {
+	hasStock, hasIndustry, hasConcept := false, false, false
+	for _, row := range rows {
+		hasStock = hasStock || strings.TrimSpace(string(row.Stocks)) != ""
+		hasIndustry = hasIndustry || strings.TrimSpace(string(row.IndustryCodes)) != ""
+		hasConcept = hasConcept || strings.TrimSpace(string(row.ConceptIDs)) != ""
+	}
+	refs := &eventImportRefs{}
+	if hasStock {
+		stockCodeList, err := dal.GetAllStockCode(ctx)
+		if err != nil {
+			return nil, err
+		}
+		refs.stockNameMap = make(map[string]string)
+		for _, stockCode := range stockCodeList {
+			refs.stockNameMap[stockCode.CompanyName] = stockCode.CompanyCode
+		}
+	}
+	if hasIndustry {
+		industrySet, err := getEventIndustrySet(ctx)
+		if err != nil {
+			return nil, err
+		}
+		refs.industrySet = industrySet
+	}
+	if hasConcept {
+		conceptList, err := dal.GetConcepts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		refs.conceptSet = make(map[uint]bool)
+		for _, concept := range conceptList {
+			refs.conceptSet[concept.ID] = true
+		}
+	}
+	return refs, nil
+}
+
+func toEventImportEvent(ctx context.Context, row *model.ImportEventRow, result *model.ImportEventRowResult, refs *eventImportRefs) (*dal.Event, error) {
+	if result.Date == "" || result.Event == "" {
+		return nil, fmt.Errorf("date and event are required")
+	}
+	date, err := parseEventImportDate(result.Date)
+	if err != nil {
+		return nil, err
+	}
+	result.Date = date
+
+	stocks, err := normalizeEventStockList(ctx, eventImportSeparatorReplacer.Replace(string(row.Stocks)), refs.stockNameMap)
+	if err != nil {
+		return nil, err
+	}
+	result.Stocks = stocks
+	industries, err := checkEventIndustryList(eventImportSeparatorReplacer.Replace(string(row.IndustryCodes)), refs.industrySet)
+	if err != nil {
+		return nil, err
+	}
+	concepts, err := checkEventConceptList(eventImportSeparatorReplacer.Replace(string(row.ConceptIDs)), refs.conceptSet)
+	if err != nil {
+		return nil, err
+	}
+	tags := normalizeEventTagList(eventImportSeparatorReplacer.Replace(string(row.Tags)))
+
+	return &dal.Event{
+		Date:       date,
+		Event:      result.Event,
+		Comment:    strings.TrimSpace(row.Comment),
+		Stocks:     strings.Join(stocks, ","),
+		Category:   strings.TrimSpace(row.Category),
+		Tags:       strings.Join(tags, ","),
+		Industries: strings.Join(industries, ","),
+		Concepts:   strings.Join(concepts, ","),
+	}, nil
+}
+
+func parseEventImportDate(date string) (string, error) {
+	for _, layout := range eventImportDateLayouts {
+		t, err := time.Parse(layout, date)
+		if err == nil {
+			return utils.FormatDate(t), nil
+		}
+	}
+	return "", fmt.Errorf("date %s is invalid", date)
+}
+
+func filterDuplicateImportEvent(ctx context.Context, eventList []*dal.Event, resultList []*model.ImportEventRowResult) ([]*dal.Event, []*model.ImportEventRowResult, error) {
+	if len(eventList) == 0 {
+		return eventList, resultList, nil
+	}
+	startDate, endDate := eventList[0].Date, eventList[0].Date
+	for _, event := range eventList {
+		if event.Date < startDate {
+			startDate = event.Date
+		}
+		if event.Date > endDate {
+			endDate = event.Date
+		}
+	}
+	existList, err := dal.GetEventsByDate(ctx, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	existSet := make(map[string]bool)
+	for _, event := range existList {
+		existSet[getEventImportKey(utils.FormatDate(utils.ParseDateWithRegion(event.Date)), event.Event)] = true
+	}
+
+	retEventList := make([]*dal.Event, 0, len(eventList))
+	retResultList := make([]*model.ImportEventRowResult, 0, len(resultList))
+	for i, event := range eventList {
+		key := getEventImportKey(event.Date, event.Event)
+		if existSet[key] {
+			resultList[i].Status = model.EventImportStatusDuplicate
+			resultList[i].Message = "event already exists"
+			continue
+		}
+		existSet[key] = true
+		retEventList = append(retEventList, event)
+		retResultList = append(retResultList, resultList[i])
+	}
+	return retEventList, retResultList, nil
+}
+
+func getEventImportKey(date string, event string) string {
+	return fmt.Sprintf("%s|%s", date, strings.TrimSpace(event))
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseEventImportRows(t *testing.T) {
+	csvData := "\xef\xbb\xbf日期,标题,股票,标签\n2024-09-24,央行降准,\"600000,平安银行\",降准；货币政策\n20241008,节后开盘,,\n"
+	rows, err := parseEventImportRows("", []byte(csvData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("csv rows = %d, want 2", len(rows))
+	}
+	// 行号与文件中的行一致, 表头为第1行
+	if rows[0].Line != 2 || rows[1].Line != 3 {
+		t.Errorf("csv lines = %d, %d, want 2, 3", rows[0].Line, rows[1].Line)
+	}
+	if rows[0].Event != "央行降准" || string(rows[0].Stocks) != "600000,平安银行" {
+		t.Errorf("csv row 1 = %+v", rows[0])
+	}
+	if splitEventField(eventImportSeparatorReplacer.Replace(string(rows[0].Tags)))[1] != "货币政策" {
+		t.Errorf("csv row 1 tags = %s", rows[0].Tags)
+	}
+	date, err := parseEventImportDate(rows[1].Date)
+	if err != nil || date != "2024-10-08" {
+		t.Errorf("parseEventImportDate(%s) = %s, %v", rows[1].Date, date, err)
+	}
+
+	jsonData := `[{"date":"2024-09-24","title":"央行降准","stocks":["600000","平安银行"],"tags":"降准","concept_ids":[12]}]`
+	rows, err = parseEventImportRows("", []byte(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || string(rows[0].Stocks) != "600000,平安银行" || string(rows[0].ConceptIDs) != "12" {
+		t.Errorf("json rows = %+v", rows[0])
+	}
+
+	var formatErr *EventImportFormatError
+	if _, err := parseEventImportRows("csv", []byte("stocks,tags\n600000,a\n")); !errors.As(err, &formatErr) {
+		t.Errorf("csv without date and event columns should fail with format error, got %v", err)
+	}
+	if _, err := parseEventImportRows("txt", []byte("a")); !errors.As(err, &formatErr) {
+		t.Errorf("unsupported format should fail with format error, got %v", err)
+	}
+}
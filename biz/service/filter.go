@@ -2,58 +2,77 @@ package service
 
 import (
 	"context"
+	"runtime"
+	"time"
 
 	"github.com/zhikongming/stock/biz/dal"
 	"github.com/zhikongming/stock/biz/model"
 	"github.com/zhikongming/stock/utils"
 )
 
-func FilterStockCode(ctx context.Context, req model.FilterStockCodeReq) ([]*model.FilterStockCodeItem, error) {
-	// 检查是否存在股票基础数据, 如果不存在就同步数据
+const (
+	// 各分析器需要的数据条数, 取最大值一次性加载
+	FilterMaWindow      = 20
+	FilterBollingWindow = 20
+	FilterMacdWindow    = 50
+	FilterKdjWindow     = 10
+	FilterPriceWindow   = FilterMacdWindow
+)
+
+func FilterStockCode(ctx context.Context, req model.FilterStockCodeReq) (*model.FilterStockCodeResp, error) {
+	startTime := time.Now()
 	stockCodeList, err := dal.GetAllStockCode(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	resultList := make([]*model.FilterStockCodeItem, 0)
-	for _, stockCode := range stockCodeList {
-		lastStockPrice, err := dal.GetLastStockPrice(ctx, stockCode.CompanyCode)
+	if !req.IncludeRisk {
+		stockCodeList, err = filterRiskStockCode(ctx, stockCodeList, req.Date)
 		if err != nil {
 			return nil, err
 		}
+	}
+	codeList := make([]string, 0, len(stockCodeList))
+	for _, stockCode := range stockCodeList {
+		codeList = append(codeList, stockCode.CompanyCode)
+	}
 
-		result := make(map[model.StockStrategy]*model.AnalyzeStockCodeResp)
-		req := model.AnalyzeStockCodeReq{
-			Code: stockCode.CompanyCode,
-			Date: req.Date,
-		}
-		result[model.StockStrategyMa], err = AnalyzeMa(ctx, req)
-		if err != nil {
-			return nil, err
-		}
-		result[model.StockStrategyBolling], err = AnalyzeBolling(ctx, req)
-		if err != nil {
-			return nil, err
-		}
-		result[model.StockStrategyMacd], err = AnalyzeMacd(ctx, req)
-		if err != nil {
-			return nil, err
-		}
-		result[model.StockStrategyKdj], err = AnalyzeKdj(ctx, req)
-		if err != nil {
-			return nil, err
+	// 批量加载所有股票的数据窗口
+	priceMap, err := BatchGetLastNStockPrice(ctx, codeList, req.Date, FilterPriceWindow)
+	if err != nil {
+		return nil, err
+	}
+	loadCost := time.Since(startTime)
+
+	// 在内存中并发执行分析
+	analyzeStartTime := time.Now()
+	jobs := make([]func() (interface{}, error), 0, len(stockCodeList))
+	for _, stockCode := range stockCodeList {
+		jobs = append(jobs, func(stockCode *dal.StockCode, priceList []*dal.StockPrice) func() (interface{}, error) {
+			return func() (interface{}, error) {
+				return analyzeFilterStockCode(ctx, stockCode, priceList)
+			}
+		}(stockCode, priceMap[stockCode.CompanyCode]))
+	}
+	results, err := utils.ConcurrentActuator(jobs, runtime.NumCPU())
+	if err != nil {
+		return nil, err
+	}
+	resultMap := make(map[string]*model.FilterStockCodeItem)
+	for _, result := range results {
+		item := result.(*model.FilterStockCodeItem)
+		if item != nil {
+			resultMap[item.Code] = item
 		}
-		resultList = append(resultList, &model.FilterStockCodeItem{
-			Code:        stockCode.CompanyCode,
-			CompanyName: stockCode.CompanyName,
-			Result:      result,
-			LastDate:    utils.FormatDate(lastStockPrice.Date),
-		})
 	}
+	analyzeCost := time.Since(analyzeStartTime)
 
-	// 过滤数据
+	// 过滤数据, 保持原有的代码顺序
 	filterResultList := make([]*model.FilterStockCodeItem, 0)
-	for _, item := range resultList {
+	for _, stockCode := range stockCodeList {
+		item, ok := resultMap[stockCode.CompanyCode]
+		if !ok {
+			continue
+		}
 		if req.MaFilter.Filter(item) &&
 			req.BollingFilter.Filter(item) &&
 			req.MacdFilter.Filter(item) &&
@@ -62,5 +81,57 @@ func FilterStockCode(ctx context.Context, req model.FilterStockCodeReq) ([]*mode
 		}
 	}
 
-	return filterResultList, nil
+	return &model.FilterStockCodeResp{
+		Items: filterResultList,
+		Stats: &model.FilterStockCodeStats{
+			Total:       len(stockCodeList),
+			Analyzed:    len(resultMap),
+			Skipped:     len(stockCodeList) - len(resultMap),
+			Matched:     len(filterResultList),
+			LoadCost:    loadCost.String(),
+			AnalyzeCost: analyzeCost.String(),
+			TotalCost:   time.Since(startTime).String(),
+		},
+	}, nil
+}
+
+// analyzeFilterStockCode 基于倒序的价格数据执行四个分析器, 数据不足时返回nil
+func analyzeFilterStockCode(ctx context.Context, stockCode *dal.StockCode, priceList []*dal.StockPrice) (*model.FilterStockCodeItem, error) {
+	if len(priceList) <= 2 {
+		return nil, nil
+	}
+	ascPriceList := utils.ListSwap(append([]*dal.StockPrice{}, priceList...))
+
+	var err error
+	result := make(map[model.StockStrategy]*model.AnalyzeStockCodeResp)
+	result[model.StockStrategyMa], err = analyzeMaPoint(ctx, lastNStockPrice(ascPriceList, FilterMaWindow))
+	if err != nil {
+		return nil, err
+	}
+	result[model.StockStrategyBolling], err = analyzeBollingPoint(ctx, lastNStockPrice(ascPriceList, FilterBollingWindow))
+	if err != nil {
+		return nil, err
+	}
+	result[model.StockStrategyMacd], err = analyzeMacdPoint(ctx, lastNStockPrice(ascPriceList, FilterMacdWindow))
+	if err != nil {
+		return nil, err
+	}
+	result[model.StockStrategyKdj], err = analyzeKdjPoint(ctx, lastNStockPrice(ascPriceList, FilterKdjWindow))
+	if err != nil {
+		return nil, err
+	}
+	return &model.FilterStockCodeItem{
+		Code:        stockCode.CompanyCode,
+		CompanyName: stockCode.CompanyName,
+		Result:      result,
+		LastDate:    utils.FormatDate(priceList[0].Date),
+	}, nil
+}
+
+// lastNStockPrice 获取正序数据的最后n条
+func lastNStockPrice(ascPriceList []*dal.StockPrice, n int) []*dal.StockPrice {
+	if len(ascPriceList) <= n {
+		return ascPriceList
+	}
+	return ascPriceList[len(ascPriceList)-n:]
 }
@@ -89,6 +89,11 @@ func GetIndustryBasicData(ctx context.Context, req *model.GetIndustryBasicDataRe
 func GetIndustryTrendData(ctx context.Context, req *model.GetIndustryTrendDataReq) (*model.GetIndustryTrendDataResp, error) {
 	resp := &model.GetIndustryTrendDataResp{}
 	if req.IndustryCode == "" {
+		weighting, err := model.ParseTrendWeighting(req.Weighting)
+		if err != nil {
+			return nil, err
+		}
+		resp.Weighting = weighting
 		trend, err := GetIndustryTrendDetail(ctx, req)
 		if err != nil {
 			return nil, err
@@ -228,24 +233,63 @@ func GetIndustryCodeDetail(ctx context.Context, req *model.GetIndustryTrendDataR
 	return ret, nil
 }
 
-func WrapGetIndustryTrendDetail(ctx context.Context, req *model.GetIndustryTrendDataReq, industryList []*dal.StockIndustry) ([]*model.IndustryPriceTrend, error) {
-	industryDalMap := make(map[string]*dal.StockIndustry)
-	for _, industry := range industryList {
-		industryDalMap[industry.Code] = industry
-	}
+// trendGroup 计算走势的股票分组, 如行业或概念
+type trendGroup struct {
+	Code          string
+	Name          string
+	StockCodeList []string
+}
 
-	stockCodeList := make([]string, 0)
-	industryMap := make(map[string]string)
+func WrapGetIndustryTrendDetail(ctx context.Context, req *model.GetIndustryTrendDataReq, industryList []*dal.StockIndustry) ([]*model.IndustryPriceTrend, error) {
+	groupList := make([]*trendGroup, 0, len(industryList))
 	for _, industry := range industryList {
 		industryRelationList, err := dal.GetStockIndustryRelation(ctx, industry.Code)
 		if err != nil {
 			return nil, err
 		}
+		group := &trendGroup{
+			Code:          industry.Code,
+			Name:          industry.Name,
+			StockCodeList: make([]string, 0, len(industryRelationList)),
+		}
 		for _, item := range industryRelationList {
-			stockCodeList = append(stockCodeList, item.CompanyCode)
-			industryMap[item.CompanyCode] = industry.Code
+			group.StockCodeList = append(group.StockCodeList, item.CompanyCode)
+		}
+		groupList = append(groupList, group)
+	}
+	return wrapGetGroupTrendDetail(ctx, req, groupList)
+}
+
+// wrapGetGroupTrendDetail 根据分组内股票的走势按加权方式合成分组的走势
+func wrapGetGroupTrendDetail(ctx context.Context, req *model.GetIndustryTrendDataReq, groupList []*trendGroup) ([]*model.IndustryPriceTrend, error) {
+	weighting, err := model.ParseTrendWeighting(req.Weighting)
+	if err != nil {
+		return nil, err
+	}
+	var riskTypeMap map[string][]model.StockRiskType
+	if !req.IncludeRisk {
+		riskTypeMap, err = getStockRiskTypeMap(ctx, req.EndDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	stockCodeList := make([]string, 0)
+	stockCodeSet := make(map[string]bool)
+	for _, group := range groupList {
+		for _, stockCode := range group.StockCodeList {
+			// 风险股票不参与板块走势的计算
+			if _, ok := riskTypeMap[stockCode]; ok {
+				continue
+			}
+			if !stockCodeSet[stockCode] {
+				stockCodeSet[stockCode] = true
+				stockCodeList = append(stockCodeList, stockCode)
+			}
 		}
 	}
+	if len(stockCodeList) == 0 {
+		return make([]*model.IndustryPriceTrend, 0), nil
+	}
 	// 为了避免同步股价的数据导致接口响应过慢，先检查最新的股价数据是否存在，如果不存在就同步。
 	stockPriceMap, err := getStockPrice(ctx, stockCodeList, req)
 	if err != nil {
@@ -255,30 +299,62 @@ func WrapGetIndustryTrendDetail(ctx context.Context, req *model.GetIndustryTrend
 	// 过滤掉时间不符合的股票价格
 	stockPriceMap = filterStockPrice(stockPriceMap)
 
-	industryDateMap := make(map[string][]*model.CodeDiffPrice)
+	// 市值加权需要股本数据
+	stockShareMap := make(map[string]*dal.StockCode)
+	if weighting == model.TrendWeightingTotalCap || weighting == model.TrendWeightingFloatCap {
+		stockList, err := dal.GetStockCodeByCodeList(ctx, stockCodeList)
+		if err != nil {
+			return nil, err
+		}
+		for _, stock := range stockList {
+			stockShareMap[stock.CompanyCode] = stock
+		}
+	}
+
+	stockDiffMap := make(map[string][]*model.CodeDiffPrice)
 	for stockCode, stockPriceList := range stockPriceMap {
 		if len(stockPriceList) == 0 {
 			continue
 		}
-		industryCode := industryMap[stockCode]
-		if _, ok := industryDateMap[industryCode]; !ok {
-			industryDateMap[industryCode] = make([]*model.CodeDiffPrice, 0)
-		}
 		// 这里是倒序的,第一个是最新的价格
 		lastStockPrice := stockPriceList[len(stockPriceList)-1]
+		var totalAmount int64 = 0
+		for i := 0; i < len(stockPriceList)-1; i++ {
+			totalAmount += stockPriceList[i].Amount
+		}
+		diffList := make([]*model.CodeDiffPrice, 0, len(stockPriceList))
 		for i := 0; i < len(stockPriceList)-1; i++ {
 			stockPrice := stockPriceList[i]
 			nextStockPrice := stockPriceList[i+1]
 			date := utils.FormatDate(stockPrice.Date)
 			diff := utils.Float64KeepDecimal(100*(stockPrice.PriceClose-nextStockPrice.PriceClose)/nextStockPrice.PriceClose, 4)
 			price := utils.Float64KeepDecimal(100*(stockPrice.PriceClose-lastStockPrice.PriceClose)/lastStockPrice.PriceClose, 4)
-			industryDateMap[industryCode] = append(industryDateMap[industryCode], &model.CodeDiffPrice{
-				Date:   date,
-				Diff:   diff,
-				Price:  price,
-				Code:   stockCode,
-				Amount: stockPrice.Amount,
-			})
+			codeDiff := &model.CodeDiffPrice{
+				Date:        date,
+				Diff:        diff,
+				Price:       price,
+				Code:        stockCode,
+				Amount:      stockPrice.Amount,
+				DiffWeight:  1,
+				PriceWeight: 1,
+			}
+			// 累计走势使用基准日的权重, 相当于基准日买入并持有; 当日涨跌幅使用前一日的权重
+			switch weighting {
+			case model.TrendWeightingTotalCap, model.TrendWeightingFloatCap:
+				var shares int64 = 0
+				if stock, ok := stockShareMap[stockCode]; ok {
+					shares = stock.TotalShares
+					if weighting == model.TrendWeightingFloatCap {
+						shares = stock.FloatShares
+					}
+				}
+				codeDiff.DiffWeight = float64(shares) * nextStockPrice.PriceClose
+				codeDiff.PriceWeight = float64(shares) * lastStockPrice.PriceClose
+			case model.TrendWeightingAmount:
+				codeDiff.DiffWeight = float64(stockPrice.Amount)
+				codeDiff.PriceWeight = float64(totalAmount)
+			}
+			diffList = append(diffList, codeDiff)
 		}
 		// 计算资金流入数据, 这里采用折中的方案, 如果几乎所有的股票都没有这个数据的话, 则不予计算
 		inflowMap := make(map[string]*model.FundInflowItem)
@@ -295,8 +371,7 @@ func WrapGetIndustryTrendDetail(ctx context.Context, req *model.GetIndustryTrend
 			mediumInflowAmount += stockPrice.MediumInflowAmount
 			smallInflowAmount += stockPrice.SmallInflowAmount
 			date := utils.FormatDate(stockPrice.Date)
-			idx := fmt.Sprintf("%s_%s", stockCode, date)
-			inflowMap[idx] = &model.FundInflowItem{
+			inflowMap[date] = &model.FundInflowItem{
 				MainInflowAmount:         mainInflowAmount,
 				ExtremeLargeInflowAmount: extremeLargeInflowAmount,
 				LargeInflowAmount:        largeInflowAmount,
@@ -304,28 +379,33 @@ func WrapGetIndustryTrendDetail(ctx context.Context, req *model.GetIndustryTrend
 				SmallInflowAmount:        smallInflowAmount,
 			}
 		}
-		for _, codeDiff := range industryDateMap[industryCode] {
-			idx := fmt.Sprintf("%s_%s", codeDiff.Code, codeDiff.Date)
-			if item, ok := inflowMap[idx]; ok {
-				codeDiff.MainInflowAmount = item.MainInflowAmount
-				codeDiff.ExtremeLargeInflowAmount = item.ExtremeLargeInflowAmount
-				codeDiff.LargeInflowAmount = item.LargeInflowAmount
-				codeDiff.MediumInflowAmount = item.MediumInflowAmount
-				codeDiff.SmallInflowAmount = item.SmallInflowAmount
+		for _, codeDiff := range diffList {
+			if item, ok := inflowMap[codeDiff.Date]; ok {
+				codeDiff.FundInflowItem = *item
 			}
 		}
+		stockDiffMap[stockCode] = diffList
 	}
 
 	ret := make([]*model.IndustryPriceTrend, 0)
-	for industryCode, diffList := range industryDateMap {
-		industry := industryDalMap[industryCode]
+	for _, group := range groupList {
+		diffList := make([]*model.CodeDiffPrice, 0)
+		for _, stockCode := range group.StockCodeList {
+			diffList = append(diffList, stockDiffMap[stockCode]...)
+		}
+		if len(diffList) == 0 {
+			continue
+		}
 		d := &model.IndustryPriceTrend{
-			IndustryCode:   industry.Code,
-			IndustryName:   industry.Name,
+			IndustryCode:   group.Code,
+			IndustryName:   group.Name,
+			Weighting:      weighting,
 			PriceTrendList: make([]*model.PriceTrend, 0),
 		}
 		diffMap := make(map[string][]float64)
+		diffWeightMap := make(map[string][]float64)
 		priceMap := make(map[string][]float64)
+		priceWeightMap := make(map[string][]float64)
 		amountMap := make(map[string][]int64)
 		mainInflowMap := make(map[string][]int64)
 		extremeLargeInflowMap := make(map[string][]int64)
@@ -334,7 +414,9 @@ func WrapGetIndustryTrendDetail(ctx context.Context, req *model.GetIndustryTrend
 		smallInflowMap := make(map[string][]int64)
 		for _, p := range diffList {
 			diffMap[p.Date] = append(diffMap[p.Date], p.Diff)
+			diffWeightMap[p.Date] = append(diffWeightMap[p.Date], p.DiffWeight)
 			priceMap[p.Date] = append(priceMap[p.Date], (100+p.Price)/100)
+			priceWeightMap[p.Date] = append(priceWeightMap[p.Date], p.PriceWeight)
 			mainInflowMap[p.Date] = append(mainInflowMap[p.Date], p.MainInflowAmount)
 			extremeLargeInflowMap[p.Date] = append(extremeLargeInflowMap[p.Date], p.ExtremeLargeInflowAmount)
 			largeInflowMap[p.Date] = append(largeInflowMap[p.Date], p.LargeInflowAmount)
@@ -345,8 +427,8 @@ func WrapGetIndustryTrendDetail(ctx context.Context, req *model.GetIndustryTrend
 		for date, dl := range diffMap {
 			d.PriceTrendList = append(d.PriceTrendList, &model.PriceTrend{
 				DateString: date,
-				Diff:       utils.Float64KeepDecimal(utils.ListFloat64Average(dl), 4),
-				Price:      utils.Float64KeepDecimal(utils.ListFloat64Average(priceMap[date]), 4),
+				Diff:       utils.Float64KeepDecimal(weightedAverage(dl, diffWeightMap[date]), 4),
+				Price:      utils.Float64KeepDecimal(weightedAverage(priceMap[date], priceWeightMap[date]), 4),
 				Date:       utils.ParseDate(date),
 				FundInflowItem: model.FundInflowItem{
 					MainInflowAmount:         utils.ListSum(mainInflowMap[date]),
@@ -365,6 +447,22 @@ func WrapGetIndustryTrendDetail(ctx context.Context, req *model.GetIndustryTrend
 	return ret, nil
 }
 
+// weightedAverage 加权平均, 权重之和不大于0时(如缺少股本数据)退化为等权平均
+func weightedAverage(values []float64, weights []float64) float64 {
+	var sum, totalWeight float64
+	for i, value := range values {
+		if weights[i] <= 0 {
+			continue
+		}
+		sum += value * weights[i]
+		totalWeight += weights[i]
+	}
+	if totalWeight <= 0 {
+		return utils.ListFloat64Average(values)
+	}
+	return sum / totalWeight
+}
+
 // 获取板块的走势图
 func GetIndustryTrendDetail(ctx context.Context, req *model.GetIndustryTrendDataReq) ([]*model.IndustryPriceTrend, error) {
 	// 根据板块内的股票的波动率，计算当天板块的波动率
@@ -454,7 +552,7 @@ func filterStockPrice(stockPriceMap map[string][]*dal.StockPrice) map[string][]*
 }
 
 func syncStockIndustryCode(ctx context.Context, stockCodeList []string) error {
-	jobCh := make(chan struct{}, MaxJobNum)
+	jobCh := make(chan struct{}, getSyncSourceConfig(SyncSourceBaidu).Concurrency)
 	wg := sync.WaitGroup{}
 	canceled := false
 	for _, stockCode := range stockCodeList {
@@ -468,7 +566,7 @@ func syncStockIndustryCode(ctx context.Context, stockCodeList []string) error {
 			if canceled {
 				return
 			}
-			err := syncOneStockCode(ctx, &model.SyncStockCodeReq{
+			_, err := syncOneStockCode(ctx, &model.SyncStockCodeReq{
 				Code: stockCode,
 			})
 			if err != nil {
@@ -580,6 +678,7 @@ func GetIndustryRelationByBasicCode(ctx context.Context, req *model.GetIndustryR
 		Days:         req.Days,
 		SyncPrice:    false,
 		IndustryCode: req.IndustryCode,
+		IncludeRisk:  true,
 	}
 	trendList, err := GetIndustryTrendDetail(ctx, trendReq)
 	if err != nil {
@@ -618,6 +717,7 @@ func GetIndustryRelationBySplitIndustry(ctx context.Context, req *model.GetIndus
 		Days:         req.Days,
 		SyncPrice:    false,
 		IndustryCode: req.IndustryCode,
+		IncludeRisk:  true,
 	}
 	trendList, err := GetIndustryTrendDetail(ctx, trendReq)
 	if err != nil {
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	DefaultIndustryScoreHistoryDays = 30
+	DefaultIndustryScoreMoverDays   = 5
+	DefaultIndustryScoreMoverLimit  = 10
+)
+
+// saveIndustryScoreHistory 保存全部行业当天的打分和排名, scoreList需已按总分排序
+func saveIndustryScoreHistory(ctx context.Context, modelName string, date string, scoreList []*model.ScoreResult) error {
+	d := utils.ParseDate(date)
+	data := make([]*dal.IndustryScore, 0, len(scoreList))
+	for idx, score := range scoreList {
+		data = append(data, &dal.IndustryScore{
+			Date:         d,
+			ScoreModel:   modelName,
+			IndustryCode: score.Code,
+			IndustryName: score.Name,
+			Rank:         idx + 1,
+			Score:        score.Score,
+			ChangeScore:  score.ChangeScore,
+			Slope5Score:  score.Slope5Score,
+			Slope10Score: score.Slope10Score,
+			Slope20Score: score.Slope20Score,
+			Score5gt10:   score.Score5gt10,
+			Score10gt20:  score.Score10gt20,
+			Score20gt30:  score.Score20gt30,
+			NewHighScore: score.NewHighScore,
+			VolumeScore:  score.VolumeScore,
+			RPS20Score:   score.RPS20Score,
+			RPS5Score:    score.RPS5Score,
+		})
+	}
+	return dal.SaveIndustryScoreList(ctx, modelName, d, data)
+}
+
+// GetIndustryScoreHistory 获取行业最近若干个交易日的得分和排名
+func GetIndustryScoreHistory(ctx context.Context, req *model.GetIndustryScoreHistoryReq) ([]*model.IndustryScoreSeries, error) {
+	modelName, _, err := getScoreModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	days := req.Days
+	if days <= 0 {
+		days = DefaultIndustryScoreHistoryDays
+	}
+	dateList, err := dal.GetLastNIndustryScoreDate(ctx, modelName, "", days)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*model.IndustryScoreSeries, 0)
+	if len(dateList) == 0 {
+		return ret, nil
+	}
+	scoreList, err := dal.GetIndustryScoreList(ctx, modelName, req.IndustryCode, utils.FormatDate(dateList[len(dateList)-1]), "")
+	if err != nil {
+		return nil, err
+	}
+	seriesMap := make(map[string]*model.IndustryScoreSeries)
+	for _, score := range scoreList {
+		series, ok := seriesMap[score.IndustryCode]
+		if !ok {
+			series = &model.IndustryScoreSeries{
+				Code:  score.IndustryCode,
+				Name:  score.IndustryName,
+				Items: make([]*model.IndustryScorePoint, 0, len(dateList)),
+			}
+			seriesMap[score.IndustryCode] = series
+			ret = append(ret, series)
+		}
+		series.Items = append(series.Items, &model.IndustryScorePoint{
+			Date:         utils.FormatDate(score.Date),
+			Rank:         score.Rank,
+			Score:        score.Score,
+			ChangeScore:  score.ChangeScore,
+			Slope5Score:  score.Slope5Score,
+			Slope10Score: score.Slope10Score,
+			Slope20Score: score.Slope20Score,
+			Score5gt10:   score.Score5gt10,
+			Score10gt20:  score.Score10gt20,
+			Score20gt30:  score.Score20gt30,
+			NewHighScore: score.NewHighScore,
+			VolumeScore:  score.VolumeScore,
+			RPS20Score:   score.RPS20Score,
+			RPS5Score:    score.RPS5Score,
+		})
+	}
+	// 按最新一天的排名输出
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Items[len(ret[i].Items)-1].Rank < ret[j].Items[len(ret[j].Items)-1].Rank
+	})
+	return ret, nil
+}
+
+// GetIndustryScoreMovers 对比最新一天和days个交易日前的排名, 返回排名上升最多和下降最多的行业
+func GetIndustryScoreMovers(ctx context.Context, req *model.GetIndustryScoreMoversReq) (*model.IndustryScoreMoversResp, error) {
+	modelName, _, err := getScoreModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	days := req.Days
+	if days <= 0 {
+		days = DefaultIndustryScoreMoverDays
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultIndustryScoreMoverLimit
+	}
+	ret := &model.IndustryScoreMoversResp{
+		RisingStars: make([]*model.IndustryScoreMover, 0),
+		Fading:      make([]*model.IndustryScoreMover, 0),
+	}
+	dateList, err := dal.GetLastNIndustryScoreDate(ctx, modelName, "", days+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(dateList) < 2 {
+		return ret, nil
+	}
+	ret.Date = utils.FormatDate(dateList[0])
+	ret.BaseDate = utils.FormatDate(dateList[len(dateList)-1])
+	scoreList, err := dal.GetIndustryScoreByDate(ctx, modelName, ret.Date)
+	if err != nil {
+		return nil, err
+	}
+	baseScoreList, err := dal.GetIndustryScoreByDate(ctx, modelName, ret.BaseDate)
+	if err != nil {
+		return nil, err
+	}
+	baseScoreMap := make(map[string]*dal.IndustryScore)
+	for _, score := range baseScoreList {
+		baseScoreMap[score.IndustryCode] = score
+	}
+	moverList := make([]*model.IndustryScoreMover, 0, len(scoreList))
+	for _, score := range scoreList {
+		baseScore, ok := baseScoreMap[score.IndustryCode]
+		if !ok {
+			continue
+		}
+		moverList = append(moverList, &model.IndustryScoreMover{
+			Code:      score.IndustryCode,
+			Name:      score.IndustryName,
+			Rank:      score.Rank,
+			PrevRank:  baseScore.Rank,
+			RankDiff:  baseScore.Rank - score.Rank,
+			Score:     score.Score,
+			PrevScore: baseScore.Score,
+		})
+	}
+	sort.SliceStable(moverList, func(i, j int) bool {
+		return moverList[i].RankDiff > moverList[j].RankDiff
+	})
+	for _, mover := range moverList {
+		if len(ret.RisingStars) >= limit || mover.RankDiff <= 0 {
+			break
+		}
+		ret.RisingStars = append(ret.RisingStars, mover)
+	}
+	for i := len(moverList) - 1; i >= 0; i-- {
+		if len(ret.Fading) >= limit || moverList[i].RankDiff >= 0 {
+			break
+		}
+		ret.Fading = append(ret.Fading, moverList[i])
+	}
+	return ret, nil
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	DefaultLeadLagDays           = 120
+	DefaultLeadLagWindow         = 40
+	DefaultLeadLagStep           = 5
+	DefaultLeadLagMaxLag         = 5
+	DefaultLeadLagMinCorrelation = 0.3
+	DefaultLeadLagMinStability   = 60
+	DefaultLeadLagLimit          = 50
+)
+
+// AnalyzeLeadLag 计算行业日收益率之间1~MaxLag天的滞后相关系数, 找出稳定领先于其他行业的板块
+func AnalyzeLeadLag(ctx context.Context, req *model.AnalyzeLeadLagReq) (*model.AnalyzeLeadLagResp, error) {
+	weighting, err := model.ParseTrendWeighting(req.Weighting)
+	if err != nil {
+		return nil, err
+	}
+	days := req.Days
+	if days <= 0 {
+		days = DefaultLeadLagDays
+	}
+	maxLag := req.MaxLag
+	if maxLag <= 0 || maxLag > DefaultLeadLagMaxLag {
+		maxLag = DefaultLeadLagMaxLag
+	}
+	window := req.Window
+	if window <= 0 {
+		window = DefaultLeadLagWindow
+	}
+	window = min(window, days)
+	step := req.Step
+	if step <= 0 {
+		step = DefaultLeadLagStep
+	}
+	minCorrelation := req.MinCorrelation
+	if minCorrelation == 0 {
+		minCorrelation = DefaultLeadLagMinCorrelation
+	}
+	minStability := req.MinStability
+	if minStability == 0 {
+		minStability = DefaultLeadLagMinStability
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultLeadLagLimit
+	}
+	trendList, err := GetIndustryTrendDetail(ctx, &model.GetIndustryTrendDataReq{
+		Days:        days + 1,
+		EndDate:     req.EndDate,
+		Weighting:   weighting,
+		IncludeRisk: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret := &model.AnalyzeLeadLagResp{
+		Weighting: weighting,
+		Window:    window,
+		Pairs:     make([]*model.LeadLagPair, 0),
+	}
+
+	// 按交易日对齐各行业的日收益率, 缺失的日期记为NaN
+	dateSet := make(map[string]bool)
+	for _, trend := range trendList {
+		for _, item := range trend.PriceTrendList {
+			dateSet[item.DateString] = true
+		}
+	}
+	dateList := make([]string, 0, len(dateSet))
+	for date := range dateSet {
+		dateList = append(dateList, date)
+	}
+	sort.Strings(dateList)
+	if len(dateList) <= maxLag+CorrelationMinSamples {
+		return ret, nil
+	}
+	ret.StartDate = dateList[0]
+	ret.EndDate = dateList[len(dateList)-1]
+	dateIndex := make(map[string]int)
+	for idx, date := range dateList {
+		dateIndex[date] = idx
+	}
+	returnList := make([][]float64, 0, len(trendList))
+	for _, trend := range trendList {
+		returns := make([]float64, len(dateList))
+		for i := range returns {
+			returns[i] = math.NaN()
+		}
+		for _, item := range trend.PriceTrendList {
+			returns[dateIndex[item.DateString]] = item.Diff
+		}
+		returnList = append(returnList, returns)
+	}
+	// 滚动窗口的起点, 最后一个窗口与区间末尾对齐
+	windowStartList := make([]int, 0)
+	for end := len(dateList); end-window >= 0; end -= step {
+		windowStartList = append(windowStartList, end-window)
+	}
+	ret.Windows = len(windowStartList)
+
+	for i, leader := range trendList {
+		for j, follower := range trendList {
+			if i == j {
+				continue
+			}
+			if req.IndustryCode != "" && leader.IndustryCode != req.IndustryCode && follower.IndustryCode != req.IndustryCode {
+				continue
+			}
+			bestLag := 0
+			best := math.Inf(-1)
+			for lag := 1; lag <= maxLag; lag++ {
+				correlation := calLaggedCorrelation(returnList[i], returnList[j], lag, 0, len(dateList))
+				if !math.IsNaN(correlation) && correlation > best {
+					best = correlation
+					bestLag = lag
+				}
+			}
+			if bestLag == 0 || best < minCorrelation {
+				continue
+			}
+			// 反方向同样显著时无法判断谁领先
+			reverse := calLaggedCorrelation(returnList[j], returnList[i], bestLag, 0, len(dateList))
+			if !math.IsNaN(reverse) && reverse >= best {
+				continue
+			}
+			hit := 0
+			windowCorrelationList := make([]float64, 0, len(windowStartList))
+			for _, start := range windowStartList {
+				correlation := calLaggedCorrelation(returnList[i], returnList[j], bestLag, start, start+window)
+				if math.IsNaN(correlation) {
+					continue
+				}
+				windowCorrelationList = append(windowCorrelationList, correlation)
+				if correlation >= minCorrelation {
+					hit++
+				}
+			}
+			if len(windowStartList) == 0 {
+				continue
+			}
+			stability := float64(hit) / float64(len(windowStartList)) * 100
+			if stability < minStability {
+				continue
+			}
+			pair := &model.LeadLagPair{
+				LeaderCode:   leader.IndustryCode,
+				LeaderName:   leader.IndustryName,
+				FollowerCode: follower.IndustryCode,
+				FollowerName: follower.IndustryName,
+				Lag:          bestLag,
+				Correlation:  utils.Float64KeepDecimal(best, 4),
+				Stability:    utils.Float64KeepDecimal(stability, 2),
+			}
+			if !math.IsNaN(reverse) {
+				pair.ReverseCorrelation = utils.Float64KeepDecimal(reverse, 4)
+			}
+			contemporaneous := calLaggedCorrelation(returnList[i], returnList[j], 0, 0, len(dateList))
+			if !math.IsNaN(contemporaneous) {
+				pair.Contemporaneous = utils.Float64KeepDecimal(contemporaneous, 4)
+			}
+			if len(windowCorrelationList) > 0 {
+				pair.AvgWindowCorrelation = utils.Float64KeepDecimal(utils.ListFloat64Average(windowCorrelationList), 4)
+			}
+			ret.Pairs = append(ret.Pairs, pair)
+		}
+	}
+	sort.SliceStable(ret.Pairs, func(i, j int) bool {
+		if ret.Pairs[i].Stability != ret.Pairs[j].Stability {
+			return ret.Pairs[i].Stability > ret.Pairs[j].Stability
+		}
+		return ret.Pairs[i].Correlation > ret.Pairs[j].Correlation
+	})
+	if len(ret.Pairs) > limit {
+		ret.Pairs = ret.Pairs[:limit]
+	}
+	return ret, nil
+}
+
+// calLaggedCorrelation 计算x[t-lag]与y[t]的皮尔逊系数, t位于[start, end)区间内, 忽略缺失的数据
+func calLaggedCorrelation(x []float64, y []float64, lag int, start int, end int) float64 {
+	xList := make([]float64, 0, end-start)
+	yList := make([]float64, 0, end-start)
+	for t := max(start, lag); t < end && t < len(y); t++ {
+		if math.IsNaN(x[t-lag]) || math.IsNaN(y[t]) {
+			continue
+		}
+		xList = append(xList, x[t-lag])
+		yList = append(yList, y[t])
+	}
+	if len(xList) < CorrelationMinSamples {
+		return math.NaN()
+	}
+	return calPearsonCorrelation(xList, yList)
+}
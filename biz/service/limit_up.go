@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/zhikongming/stock/biz/dal"
 	"github.com/zhikongming/stock/biz/model"
 	"github.com/zhikongming/stock/utils"
@@ -16,14 +17,22 @@ import (
 const (
 	MaxLimitUpReportJobNum = 50
 	GetLastNStockPriceNum  = 15
+
+	DefaultLimitUpHistoryDays = 30
 )
 
-func GetLimitUpReport(ctx context.Context) ([][]*model.LimitUpReportItem, error) {
+func GetLimitUpReport(ctx context.Context, req *model.GetLimitUpReportReq) (*model.LimitUpReport, error) {
 	// 获取所有的股票信息
 	allStockList, err := dal.GetAllStockCode(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if !req.IncludeRisk {
+		allStockList, err = filterRiskStockCode(ctx, allStockList, "")
+		if err != nil {
+			return nil, err
+		}
+	}
 	// 获取所有的板块信息
 	allIndustryList, err := dal.GetAllStockIndustry(ctx)
 	if err != nil {
@@ -63,18 +72,14 @@ func GetLimitUpReport(ctx context.Context) ([][]*model.LimitUpReportItem, error)
 					return nil, nil
 				}
 				// 倒序开始处理
-				count := CalculateLimitUpCount(stockPriceList)
-				data := &model.LimitUpReportItem{
-					Code:         stockCode.CompanyCode,
-					Name:         stockCode.CompanyName,
-					Count:        count,
-					IndustryName: stockMap[stockCode.CompanyCode],
-					LastDate:     stockPriceList[0].Date,
-				}
+				state := calculateLimitUpState(stockPriceList)
+				state.Item.Code = stockCode.CompanyCode
+				state.Item.Name = stockCode.CompanyName
+				state.Item.IndustryName = stockMap[stockCode.CompanyCode]
 				if conceptName, ok := conceptMap[stockCode.CompanyCode]; ok {
-					data.IndustryName = fmt.Sprintf("%s,%s", data.IndustryName, conceptName)
+					state.Item.IndustryName = fmt.Sprintf("%s,%s", state.Item.IndustryName, conceptName)
 				}
-				return data, nil
+				return state, nil
 			}
 		}(stockCode))
 	}
@@ -83,46 +88,188 @@ func GetLimitUpReport(ctx context.Context) ([][]*model.LimitUpReportItem, error)
 	if err != nil {
 		return nil, err
 	}
-	maxCount := 0
-	var ret []*model.LimitUpReportItem
+	var stateList []*limitUpState
 	for _, item := range dataList {
 		if item != nil {
-			d := item.(*model.LimitUpReportItem)
-			if d.Count > 0 {
-				ret = append(ret, d)
-				if d.Count > maxCount {
-					maxCount = d.Count
-				}
-			}
+			stateList = append(stateList, item.(*limitUpState))
 		}
 	}
 	// 过滤掉日期不正确的股票
 	var lastDate time.Time
-	for _, item := range ret {
-		if item.LastDate.After(lastDate) {
-			lastDate = item.LastDate
+	for _, state := range stateList {
+		if state.Item.LastDate.After(lastDate) {
+			lastDate = state.Item.LastDate
 		}
 	}
-	var retNew []*model.LimitUpReportItem
-	for _, item := range ret {
-		if !item.LastDate.Before(lastDate) {
-			retNew = append(retNew, item)
+	var stateListNew []*limitUpState
+	for _, state := range stateList {
+		if !state.Item.LastDate.Before(lastDate) {
+			stateListNew = append(stateListNew, state)
 		}
 	}
+	stat := calculateLimitUpStat(stateListNew)
+	stat.Date = utils.FormatDate(lastDate)
+
 	// 构建返回数据
-	reportList := make([][]*model.LimitUpReportItem, 0, maxCount+1)
-	for i := 0; i <= maxCount; i++ {
+	reportList := make([][]*model.LimitUpReportItem, 0, stat.MaxCount+1)
+	for i := 0; i <= stat.MaxCount; i++ {
 		reportList = append(reportList, []*model.LimitUpReportItem{})
 	}
-	for _, item := range retNew {
-		count := item.Count
-		reportList[count] = append(reportList[count], item)
+	ret := &model.LimitUpReport{
+		Date:          stat.Date,
+		BrokenList:    make([]*model.LimitUpReportItem, 0),
+		LimitDownList: make([]*model.LimitUpReportItem, 0),
+		Stat:          stat,
+	}
+	for _, state := range stateListNew {
+		if state.Item.Count > 0 {
+			reportList[state.Item.Count] = append(reportList[state.Item.Count], state.Item)
+		}
+		if state.Broken {
+			ret.BrokenList = append(ret.BrokenList, state.Item)
+		}
+		if state.LimitDown {
+			ret.LimitDownList = append(ret.LimitDownList, state.Item)
+		}
 	}
 	// 对每个连板数量的数据排序
 	for _, group := range reportList {
 		sort.Sort(model.LimitUpReportItemSorter(group))
 	}
-	return reportList, nil
+	sort.Sort(model.LimitUpReportItemSorter(ret.BrokenList))
+	sort.Sort(model.LimitUpReportItemSorter(ret.LimitDownList))
+	ret.Ladder = reportList
+
+	if !lastDate.IsZero() && !req.IncludeRisk {
+		err = saveLimitUpStat(ctx, stat)
+		if err != nil {
+			hlog.Errorf("save limit up stat failed, err: %v", err)
+		}
+	}
+	return ret, nil
+}
+
+// limitUpState 股票在最新交易日的涨跌停状态
+type limitUpState struct {
+	Item      *model.LimitUpReportItem
+	Broken    bool
+	LimitDown bool
+}
+
+// calculateLimitUpState 计算最新交易日的连板、炸板、跌停和一字板状态, stockPriceList为倒序
+func calculateLimitUpState(stockPriceList []*dal.StockPrice) *limitUpState {
+	current := stockPriceList[0]
+	state := &limitUpState{
+		Item: &model.LimitUpReportItem{
+			Count:     CalculateLimitUpCount(stockPriceList),
+			PrevCount: CalculateLimitUpCount(stockPriceList[1:]),
+			LastDate:  current.Date,
+		},
+	}
+	if len(stockPriceList) < 2 || current.PriceClose <= 1.0 {
+		return state
+	}
+	previous := stockPriceList[1]
+	rate := GetLimitUpRate(utils.GetStockCodeNumber(current.CompanyCode))
+	if state.Item.Count > 0 {
+		state.Item.OneWord = current.PriceOpen == current.PriceHigh && current.PriceHigh == current.PriceLow
+	} else {
+		state.Broken = IsLimitUpWithRate(previous.PriceClose, current.PriceHigh, rate)
+	}
+	state.LimitDown = IsLimitDownWithRate(previous.PriceClose, current.PriceClose, rate)
+	return state
+}
+
+func calculateLimitUpStat(stateList []*limitUpState) *model.LimitUpStat {
+	stat := &model.LimitUpStat{}
+	for _, state := range stateList {
+		item := state.Item
+		if item.Count > 0 {
+			stat.LimitUpCount++
+			stat.MaxCount = max(stat.MaxCount, item.Count)
+		}
+		if item.OneWord {
+			stat.OneWordCount++
+		}
+		if state.Broken {
+			stat.BrokenCount++
+		}
+		if state.LimitDown {
+			stat.LimitDownCount++
+		}
+		if item.PrevCount == 1 {
+			stat.FirstBoardCount++
+			if item.Count == 2 {
+				stat.SecondBoardCount++
+			}
+		}
+		if item.PrevCount > 0 {
+			stat.ContinuousBoardCount++
+			if item.Count == item.PrevCount+1 {
+				stat.ContinuousPromotionCount++
+			}
+		}
+	}
+	if stat.FirstBoardCount > 0 {
+		stat.PromotionRate = utils.Float64KeepDecimal(float64(stat.SecondBoardCount)/float64(stat.FirstBoardCount)*100, 2)
+	}
+	if stat.ContinuousBoardCount > 0 {
+		stat.ContinuousPromotionRate = utils.Float64KeepDecimal(float64(stat.ContinuousPromotionCount)/float64(stat.ContinuousBoardCount)*100, 2)
+	}
+	if stat.LimitUpCount+stat.BrokenCount > 0 {
+		stat.BrokenRate = utils.Float64KeepDecimal(float64(stat.BrokenCount)/float64(stat.LimitUpCount+stat.BrokenCount)*100, 2)
+	}
+	return stat
+}
+
+func saveLimitUpStat(ctx context.Context, stat *model.LimitUpStat) error {
+	return dal.SaveLimitUpStat(ctx, &dal.LimitUpStat{
+		Date:                     utils.ParseDate(stat.Date),
+		LimitUpCount:             stat.LimitUpCount,
+		OneWordCount:             stat.OneWordCount,
+		BrokenCount:              stat.BrokenCount,
+		LimitDownCount:           stat.LimitDownCount,
+		MaxCount:                 stat.MaxCount,
+		FirstBoardCount:          stat.FirstBoardCount,
+		SecondBoardCount:         stat.SecondBoardCount,
+		PromotionRate:            stat.PromotionRate,
+		ContinuousBoardCount:     stat.ContinuousBoardCount,
+		ContinuousPromotionCount: stat.ContinuousPromotionCount,
+		ContinuousPromotionRate:  stat.ContinuousPromotionRate,
+		BrokenRate:               stat.BrokenRate,
+	})
+}
+
+// GetLimitUpHistory 获取最近若干天的涨停板统计, 按日期正序
+func GetLimitUpHistory(ctx context.Context, req *model.GetLimitUpHistoryReq) ([]*model.LimitUpStat, error) {
+	days := req.Days
+	if days <= 0 {
+		days = DefaultLimitUpHistoryDays
+	}
+	statList, err := dal.GetLastNLimitUpStat(ctx, days)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*model.LimitUpStat, 0, len(statList))
+	for i := len(statList) - 1; i >= 0; i-- {
+		stat := statList[i]
+		ret = append(ret, &model.LimitUpStat{
+			Date:                     utils.FormatDate(stat.Date),
+			LimitUpCount:             stat.LimitUpCount,
+			OneWordCount:             stat.OneWordCount,
+			BrokenCount:              stat.BrokenCount,
+			LimitDownCount:           stat.LimitDownCount,
+			MaxCount:                 stat.MaxCount,
+			FirstBoardCount:          stat.FirstBoardCount,
+			SecondBoardCount:         stat.SecondBoardCount,
+			PromotionRate:            stat.PromotionRate,
+			ContinuousBoardCount:     stat.ContinuousBoardCount,
+			ContinuousPromotionCount: stat.ContinuousPromotionCount,
+			ContinuousPromotionRate:  stat.ContinuousPromotionRate,
+			BrokenRate:               stat.BrokenRate,
+		})
+	}
+	return ret, nil
 }
 
 func CalculateLimitUpCount(stockPriceList []*dal.StockPrice) int {
@@ -160,6 +307,12 @@ func IsLimitUpWithRate(prevClose, current float64, rate float64) bool {
 	return current >= limitUpPrice
 }
 
+// IsLimitDownWithRate 跌停价按交易所规则四舍五入到分
+func IsLimitDownWithRate(prevClose, current float64, rate float64) bool {
+	limitDownPrice := math.Round(prevClose*(2-rate)*100+0.00001) / 100
+	return current <= limitDownPrice
+}
+
 func GetLimitUpRate(stockCode string) float64 {
 	// 创业板（300/301开头）
 	if strings.HasPrefix(stockCode, "30") || strings.HasPrefix(stockCode, "301") {
@@ -3,6 +3,9 @@ package service
 import (
 	"fmt"
 	"testing"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
 )
 
 func TestIsLimitUpWithRate(t *testing.T) {
@@ -63,3 +66,116 @@ func TestIsLimitUpWithRate(t *testing.T) {
 	curClosePrice = 21.66
 	fmt.Printf("%s: IsLimitUpWithRate = %v\n", code, IsLimitUpWithRate(prevClosePrice, curClosePrice, rate))
 }
+
+func TestIsLimitDownWithRate(t *testing.T) {
+	testCases := []struct {
+		prevClose float64
+		current   float64
+		rate      float64
+		want      bool
+	}{
+		// 10.06 * 0.9 = 9.054, 跌停价为9.05
+		{10.06, 9.05, 1.10, true},
+		{10.06, 9.06, 1.10, false},
+		// 10.05 * 0.9 = 9.045, 四舍五入为9.05
+		{10.05, 9.05, 1.10, true},
+		{20.00, 16.00, 1.20, true},
+		{20.00, 16.01, 1.20, false},
+	}
+	for _, tc := range testCases {
+		if got := IsLimitDownWithRate(tc.prevClose, tc.current, tc.rate); got != tc.want {
+			t.Errorf("IsLimitDownWithRate(%v, %v, %v) = %v, want %v", tc.prevClose, tc.current, tc.rate, got, tc.want)
+		}
+	}
+}
+
+// newLimitUpPriceList 按倒序生成股价数据, 每一项为开盘价、最高价、最低价、收盘价
+func newLimitUpPriceList(code string, barList ...[4]float64) []*dal.StockPrice {
+	ret := make([]*dal.StockPrice, 0, len(barList))
+	for _, bar := range barList {
+		ret = append(ret, &dal.StockPrice{
+			CompanyCode: code,
+			PriceOpen:   bar[0],
+			PriceHigh:   bar[1],
+			PriceLow:    bar[2],
+			PriceClose:  bar[3],
+		})
+	}
+	return ret
+}
+
+func TestCalculateLimitUpState(t *testing.T) {
+	testCases := []struct {
+		name      string
+		priceList []*dal.StockPrice
+		count     int
+		prevCount int
+		oneWord   bool
+		broken    bool
+		limitDown bool
+	}{
+		{
+			name:      "one word",
+			priceList: newLimitUpPriceList("SH600000", [4]float64{11, 11, 11, 11}, [4]float64{10, 10, 10, 10}),
+			count:     1,
+			oneWord:   true,
+		},
+		{
+			name:      "second board",
+			priceList: newLimitUpPriceList("SH600000", [4]float64{11.5, 12.1, 11.2, 12.1}, [4]float64{10.2, 11, 10.1, 11}, [4]float64{10, 10, 10, 10}),
+			count:     2,
+			prevCount: 1,
+		},
+		{
+			name:      "broken",
+			priceList: newLimitUpPriceList("SZ300001", [4]float64{10.5, 12, 10.3, 11}, [4]float64{10, 10, 10, 10}),
+			broken:    true,
+		},
+		{
+			name:      "limit down",
+			priceList: newLimitUpPriceList("SH600000", [4]float64{9.5, 9.6, 9.05, 9.05}, [4]float64{10.06, 10.06, 10.06, 10.06}),
+			limitDown: true,
+		},
+		{
+			name:      "one tick above limit down",
+			priceList: newLimitUpPriceList("SH600000", [4]float64{9.5, 9.6, 9.05, 9.06}, [4]float64{10.06, 10.06, 10.06, 10.06}),
+		},
+	}
+	for _, tc := range testCases {
+		state := calculateLimitUpState(tc.priceList)
+		if state.Item.Count != tc.count || state.Item.PrevCount != tc.prevCount || state.Item.OneWord != tc.oneWord ||
+			state.Broken != tc.broken || state.LimitDown != tc.limitDown {
+			t.Errorf("%s: state = %+v, item = %+v", tc.name, state, state.Item)
+		}
+	}
+}
+
+func TestCalculateLimitUpStat(t *testing.T) {
+	newState := func(count int, prevCount int, broken bool) *limitUpState {
+		return &limitUpState{Item: &model.LimitUpReportItem{Count: count, PrevCount: prevCount}, Broken: broken}
+	}
+	stateList := []*limitUpState{
+		// 首板晋级二板
+		newState(2, 1, false),
+		// 首板断板
+		newState(0, 1, true),
+		newState(0, 1, false),
+		// 二板晋级三板
+		newState(3, 2, false),
+		// 新的首板
+		newState(1, 0, false),
+	}
+	stat := calculateLimitUpStat(stateList)
+	if stat.LimitUpCount != 3 || stat.MaxCount != 3 || stat.BrokenCount != 1 {
+		t.Errorf("stat = %+v", stat)
+	}
+	if stat.FirstBoardCount != 3 || stat.SecondBoardCount != 1 || stat.PromotionRate != 33.33 {
+		t.Errorf("promotion = %d/%d, %v", stat.SecondBoardCount, stat.FirstBoardCount, stat.PromotionRate)
+	}
+	if stat.ContinuousBoardCount != 4 || stat.ContinuousPromotionCount != 2 || stat.ContinuousPromotionRate != 50 {
+		t.Errorf("continuous promotion = %d/%d, %v", stat.ContinuousPromotionCount, stat.ContinuousBoardCount, stat.ContinuousPromotionRate)
+	}
+	if stat.BrokenRate != 25 {
+		t.Errorf("broken rate = %v, want 25", stat.BrokenRate)
+	}
+}
@@ -12,6 +12,7 @@ type RemoteClient interface {
 	GetRemoteStockRelation(ctx context.Context, code string) ([]*model.StockRelationItem, error)
 	GetRemoteStockDaily(ctx context.Context, code string, dateTime time.Time) (*model.StockDailyData, error)
 	GetRemoteStockMinute(ctx context.Context, code string) ([]*model.StockMinuteData, error)
+	GetRemoteStockQuote(ctx context.Context, codeList []string) ([]*model.StockQuote, error)
 	GetRemoteStockByKLineType(ctx context.Context, code string, startTime time.Time, endTime time.Time, kLineType model.KLineType) (*model.StockDailyData, error)
 
 	GetRemoteStockIndustry(ctx context.Context) ([]*model.IndustryItem, error)
@@ -21,6 +22,7 @@ type RemoteClient interface {
 	GetRemoteFundFlowByCode(ctx context.Context, code string) ([]*model.FundFlowData, error)
 
 	GetRemoteShareholder(ctx context.Context, code string, date string) (*model.Top10Shareholder, error)
+	GetRemoteStockShares(ctx context.Context) ([]*model.StockShareData, error)
 
 	GetRemoteUnusualStock(ctx context.Context) ([]*model.UnusualStock, error)
 	GetRemoteSpecialUnusualStock(ctx context.Context) ([]*model.UnusualStock, error)
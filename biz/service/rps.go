@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	DefaultStockRpsLimit = 100
+)
+
+// StockRpsPeriods RPS计算的周期, 分别对应50/120/250个交易日的涨幅
+var StockRpsPeriods = []int{50, 120, 250}
+
+// CalculateStockRps 计算全部股票在某个交易日的50/120/250日涨幅及其在全市场的百分位排名并保存
+func CalculateStockRps(ctx context.Context, req *model.CalculateStockRpsReq) (*model.CalculateStockRpsResp, error) {
+	startTime := time.Now()
+	dateList, err := dal.GetLastNTradeDate(ctx, req.Date, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(dateList) == 0 {
+		return nil, errors.New("no trade date found")
+	}
+	date := utils.FormatDate(dateList[0])
+	stockCodeList, err := dal.GetAllStockCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codeList := make([]string, 0, len(stockCodeList))
+	for _, stockCode := range stockCodeList {
+		codeList = append(codeList, stockCode.CompanyCode)
+	}
+	maxPeriod := StockRpsPeriods[len(StockRpsPeriods)-1]
+	priceMap, err := BatchGetLastNStockPrice(ctx, codeList, date, maxPeriod+1)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只计算当天有交易的股票, 停牌的股票不参与排名
+	changeMap := make(map[int]map[string]float64)
+	for _, period := range StockRpsPeriods {
+		changeMap[period] = make(map[string]float64)
+	}
+	rpsList := make([]*dal.StockRps, 0, len(priceMap))
+	for _, code := range codeList {
+		priceList := priceMap[code]
+		if len(priceList) == 0 || utils.FormatDate(priceList[0].Date) != date {
+			continue
+		}
+		rps := &dal.StockRps{
+			Code: code,
+			Date: dateList[0],
+		}
+		for _, period := range StockRpsPeriods {
+			if len(priceList) <= period || priceList[period].PriceClose <= 0 {
+				continue
+			}
+			change := utils.Float64KeepDecimal((priceList[0].PriceClose/priceList[period].PriceClose-1)*100, 2)
+			changeMap[period][code] = change
+			switch period {
+			case 50:
+				rps.Change50 = change
+			case 120:
+				rps.Change120 = change
+			case 250:
+				rps.Change250 = change
+			}
+		}
+		rpsList = append(rpsList, rps)
+	}
+	rankMap := make(map[int]map[string]float64)
+	for _, period := range StockRpsPeriods {
+		rankMap[period] = calPercentileRank(changeMap[period])
+	}
+	for _, rps := range rpsList {
+		rps.Rps50 = rankMap[50][rps.Code]
+		rps.Rps120 = rankMap[120][rps.Code]
+		rps.Rps250 = rankMap[250][rps.Code]
+	}
+	err = dal.SaveStockRpsList(ctx, dateList[0], rpsList)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CalculateStockRpsResp{
+		Date:  date,
+		Total: len(rpsList),
+		Cost:  time.Since(startTime).String(),
+	}, nil
+}
+
+// calPercentileRank 计算每个值在全部数据中的百分位(0~100], 相同的值排名相同
+func calPercentileRank(valueMap map[string]float64) map[string]float64 {
+	ret := make(map[string]float64)
+	if len(valueMap) == 0 {
+		return ret
+	}
+	keyList := make([]string, 0, len(valueMap))
+	for key := range valueMap {
+		keyList = append(keyList, key)
+	}
+	sort.Slice(keyList, func(i, j int) bool {
+		return valueMap[keyList[i]] < valueMap[keyList[j]]
+	})
+	n := float64(len(keyList))
+	for i := len(keyList) - 1; i >= 0; {
+		j := i
+		for j-1 >= 0 && valueMap[keyList[j-1]] == valueMap[keyList[i]] {
+			j--
+		}
+		rank := utils.Float64KeepDecimal(float64(i+1)/n*100, 2)
+		for k := j; k <= i; k++ {
+			ret[keyList[k]] = rank
+		}
+		i = j - 1
+	}
+	return ret
+}
+
+// GetStockRps 按RPS阈值和行业、概念筛选股票
+func GetStockRps(ctx context.Context, req *model.GetStockRpsReq) (*model.GetStockRpsResp, error) {
+	sortBy := req.SortBy
+	if sortBy == "" {
+		sortBy = model.RpsSortBy50
+	}
+	if !utils.In(sortBy, []string{model.RpsSortBy50, model.RpsSortBy120, model.RpsSortBy250}) {
+		return nil, fmt.Errorf("invalid sort_by %s", sortBy)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultStockRpsLimit
+	}
+	date, err := dal.GetLastStockRpsDate(ctx, req.Date)
+	if err != nil {
+		return nil, err
+	}
+	ret := &model.GetStockRpsResp{
+		Date:  date,
+		Items: make([]*model.StockRpsItem, 0),
+	}
+	if date == "" {
+		return ret, nil
+	}
+	rpsList, err := dal.GetStockRpsListByDate(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	stockCodeList, err := getScreenerStockCodeList(ctx, req.IndustryCode, req.ConceptID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.IncludeRisk {
+		stockCodeList, err = filterRiskStockCode(ctx, stockCodeList, date)
+		if err != nil {
+			return nil, err
+		}
+	}
+	scope := make(map[string]bool)
+	for _, stockCode := range stockCodeList {
+		scope[stockCode.CompanyCode] = true
+	}
+	nameMap, industryMap, err := getStockNameAndIndustryMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, rps := range rpsList {
+		if !scope[rps.Code] {
+			continue
+		}
+		ret.Total++
+		if rps.Rps50 < req.MinRps50 || rps.Rps120 < req.MinRps120 || rps.Rps250 < req.MinRps250 {
+			continue
+		}
+		item := toStockRpsItem(rps)
+		item.Name = nameMap[rps.Code]
+		item.IndustryName = industryMap[rps.Code]
+		ret.Items = append(ret.Items, item)
+	}
+	ret.Matched = len(ret.Items)
+	sort.SliceStable(ret.Items, func(i, j int) bool {
+		return getStockRpsValue(ret.Items[i], sortBy) > getStockRpsValue(ret.Items[j], sortBy)
+	})
+	if len(ret.Items) > limit {
+		ret.Items = ret.Items[:limit]
+	}
+	return ret, nil
+}
+
+func getStockRpsValue(item *model.StockRpsItem, sortBy string) float64 {
+	switch sortBy {
+	case model.RpsSortBy120:
+		return item.Rps120
+	case model.RpsSortBy250:
+		return item.Rps250
+	default:
+		return item.Rps50
+	}
+}
+
+func toStockRpsItem(rps *dal.StockRps) *model.StockRpsItem {
+	return &model.StockRpsItem{
+		Code:      rps.Code,
+		Date:      utils.FormatDate(rps.Date),
+		Change50:  rps.Change50,
+		Change120: rps.Change120,
+		Change250: rps.Change250,
+		Rps50:     rps.Rps50,
+		Rps120:    rps.Rps120,
+		Rps250:    rps.Rps250,
+	}
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	DefaultScreenerRunLimit = 10
+)
+
+func AddScreener(ctx context.Context, req *model.AddScreenerReq) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("name is empty")
+	}
+	switch req.ScreenerType {
+	case model.ScreenerTypeFilterStockCode:
+		if req.FilterStockCode == nil {
+			return errors.New("filter_stock_code is required")
+		}
+	case model.ScreenerTypeThirdBuy:
+		if req.FilterThirdBuy == nil {
+			return errors.New("filter_third_buy is required")
+		}
+	case model.ScreenerTypeQuery:
+		if req.Query == nil {
+			return errors.New("query is required")
+		}
+		if _, err := ParseScreenerExpr(req.Query.Expression); err != nil {
+			return fmt.Errorf("parse expression failed: %v", err)
+		}
+	default:
+		return errors.New("invalid screener type")
+	}
+	d, _ := json.Marshal(req)
+	return dal.CreateScreener(ctx, &dal.Screener{
+		Name:         req.Name,
+		ScreenerType: int(req.ScreenerType),
+		Params:       string(d),
+		CreateTime:   time.Now(),
+		Status:       int(dal.StatusEnabled),
+	})
+}
+
+func GetScreeners(ctx context.Context, req *model.GetScreenerReq) ([]*model.ScreenerResp, error) {
+	screenerList, err := getScreenerList(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*model.ScreenerResp, 0, len(screenerList))
+	for _, screener := range screenerList {
+		params, err := parseScreenerParams(screener)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, &model.ScreenerResp{
+			ID:           screener.ID,
+			Name:         screener.Name,
+			ScreenerType: model.ScreenerType(screener.ScreenerType),
+			TypeName:     model.ScreenerType(screener.ScreenerType).String(),
+			Params:       params,
+			CreateTime:   utils.FormatTime(screener.CreateTime),
+		})
+	}
+	return ret, nil
+}
+
+func DeleteScreener(ctx context.Context, req *model.DeleteScreenerReq) error {
+	if req.ID <= 0 {
+		return errors.New("id must be greater than 0")
+	}
+	return dal.DeleteScreenerByID(ctx, uint(req.ID))
+}
+
+// RunScreener 手动运行选股器, ID为0时运行全部
+func RunScreener(ctx context.Context, req *model.RunScreenerReq) ([]*model.ScreenerRunResult, error) {
+	screenerList, err := getScreenerList(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	resultList := make([]*model.ScreenerRunResult, 0, len(screenerList))
+	for _, screener := range screenerList {
+		result, _, err := runScreener(ctx, screener)
+		if err != nil {
+			return nil, err
+		}
+		resultList = append(resultList, result)
+	}
+	if req.Notify && len(resultList) > 0 {
+		_ = SendLarkMessage(ctx, BuildScreenerReportMessage(resultList))
+	}
+	return resultList, nil
+}
+
+// GetScreenerReport 每日同步后运行所有选股器并通知新进和剔除的股票
+func GetScreenerReport(ctx context.Context) error {
+	screenerList, err := dal.GetAllScreenerList(ctx)
+	if err != nil {
+		return err
+	}
+	resultList := make([]*model.ScreenerRunResult, 0, len(screenerList))
+	for _, screener := range screenerList {
+		// 单个选股器失败不影响其他选股器
+		result, changed, err := runScreener(ctx, screener)
+		if err != nil {
+			hlog.Errorf("run screener %d failed, err: %v", screener.ID, err)
+			continue
+		}
+		// 同一交易日重复运行且结果没有变化时不再重复通知
+		if !changed {
+			continue
+		}
+		resultList = append(resultList, result)
+	}
+	if len(resultList) == 0 {
+		return nil
+	}
+	return SendLarkMessage(ctx, BuildScreenerReportMessage(resultList))
+}
+
+// GetScreenerRuns 获取选股器的历史运行结果, 每次结果与前一次对比
+func GetScreenerRuns(ctx context.Context, req *model.GetScreenerRunsReq) ([]*model.ScreenerRunResult, error) {
+	if req.ID <= 0 {
+		return nil, errors.New("id must be greater than 0")
+	}
+	screener, err := dal.GetScreenerByID(ctx, uint(req.ID))
+	if err != nil {
+		return nil, err
+	}
+	if screener == nil {
+		return nil, fmt.Errorf("screener %d not found", req.ID)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultScreenerRunLimit
+	}
+	// 多取一次用于对比最早的一次结果
+	runList, err := dal.GetScreenerRunList(ctx, screener.ID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*model.ScreenerRunResult, 0, len(runList))
+	for i, run := range runList {
+		if i >= limit {
+			break
+		}
+		// 与之前交易日的结果对比, 兼容同一天存在多条结果的历史数据
+		var prevRun *dal.ScreenerRun
+		for j := i + 1; j < len(runList); j++ {
+			if runList[j].Date.Before(run.Date) {
+				prevRun = runList[j]
+				break
+			}
+		}
+		result, err := toScreenerRunResult(screener, run, prevRun)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, result)
+	}
+	return ret, nil
+}
+
+func getScreenerList(ctx context.Context, id int) ([]*dal.Screener, error) {
+	if id <= 0 {
+		return dal.GetAllScreenerList(ctx)
+	}
+	screener, err := dal.GetScreenerByID(ctx, uint(id))
+	if err != nil {
+		return nil, err
+	}
+	if screener == nil {
+		return nil, fmt.Errorf("screener %d not found", id)
+	}
+	return []*dal.Screener{screener}, nil
+}
+
+func parseScreenerParams(screener *dal.Screener) (*model.AddScreenerReq, error) {
+	var params model.AddScreenerReq
+	if err := json.Unmarshal([]byte(screener.Params), &params); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+// runScreener 运行选股器, 按交易日保存结果并与之前交易日的结果对比
+// 同一交易日重复运行时覆盖当天的结果, 返回的 changed 表示当天的结果是否是新的或发生了变化
+func runScreener(ctx context.Context, screener *dal.Screener) (*model.ScreenerRunResult, bool, error) {
+	params, err := parseScreenerParams(screener)
+	if err != nil {
+		return nil, false, err
+	}
+	codeList, err := executeScreener(ctx, params)
+	if err != nil {
+		return nil, false, err
+	}
+	// 周末或节假日运行时归属到最近的交易日
+	dateList, err := dal.GetLastNTradeDate(ctx, utils.GetDateOfToday(), 1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(dateList) == 0 {
+		return nil, false, errors.New("trade date not found")
+	}
+	tradeDate := dateList[0]
+	prevRun, err := dal.GetLastScreenerRunBeforeDate(ctx, screener.ID, tradeDate)
+	if err != nil {
+		return nil, false, err
+	}
+	run, err := dal.GetScreenerRunByDate(ctx, screener.ID, tradeDate)
+	if err != nil {
+		return nil, false, err
+	}
+
+	codes := make([]string, 0, len(codeList))
+	for _, code := range codeList {
+		codes = append(codes, code.Code)
+	}
+	codeStr := strings.Join(codes, ",")
+	changed := run == nil || run.Codes != codeStr
+	if run == nil {
+		run = &dal.ScreenerRun{
+			ScreenerID: screener.ID,
+			Date:       tradeDate,
+		}
+	}
+	run.RunTime = time.Now()
+	run.Codes = codeStr
+	run.Result = utils.ToJsonString(codeList)
+	if err := dal.SaveScreenerRun(ctx, run); err != nil {
+		return nil, false, err
+	}
+	result, err := toScreenerRunResult(screener, run, prevRun)
+	if err != nil {
+		return nil, false, err
+	}
+	return result, changed, nil
+}
+
+// executeScreener 根据选股器类型执行对应的筛选
+func executeScreener(ctx context.Context, params *model.AddScreenerReq) ([]*model.CodeBasic, error) {
+	ret := make([]*model.CodeBasic, 0)
+	switch params.ScreenerType {
+	case model.ScreenerTypeFilterStockCode:
+		resp, err := FilterStockCode(ctx, *params.FilterStockCode)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range resp.Items {
+			ret = append(ret, &model.CodeBasic{Code: item.Code, Name: item.CompanyName})
+		}
+	case model.ScreenerTypeThirdBuy:
+		resp, err := FilterThirdBuyCode(ctx, params.FilterThirdBuy)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range resp.Data {
+			ret = append(ret, &model.CodeBasic{Code: item.Code, Name: item.Name})
+		}
+	case model.ScreenerTypeQuery:
+		resp, err := QueryScreener(ctx, params.Query)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range resp.Items {
+			ret = append(ret, &model.CodeBasic{Code: item.Code, Name: item.Name})
+		}
+	default:
+		return nil, errors.New("invalid screener type")
+	}
+	return ret, nil
+}
+
+func toScreenerRunResult(screener *dal.Screener, run *dal.ScreenerRun, prevRun *dal.ScreenerRun) (*model.ScreenerRunResult, error) {
+	codeList := make([]*model.CodeBasic, 0)
+	if err := json.Unmarshal([]byte(run.Result), &codeList); err != nil {
+		return nil, err
+	}
+	prevCodeList := make([]*model.CodeBasic, 0)
+	if prevRun != nil {
+		if err := json.Unmarshal([]byte(prevRun.Result), &prevCodeList); err != nil {
+			return nil, err
+		}
+	}
+	entries, exits := diffScreenerCodeList(prevCodeList, codeList)
+	return &model.ScreenerRunResult{
+		ScreenerID: screener.ID,
+		Name:       screener.Name,
+		Date:       utils.FormatDate(run.Date),
+		RunTime:    utils.FormatTime(run.RunTime),
+		Codes:      codeList,
+		Entries:    entries,
+		Exits:      exits,
+	}, nil
+}
+
+// diffScreenerCodeList 对比两次结果, 返回新进入和被剔除的股票
+func diffScreenerCodeList(prevCodeList, codeList []*model.CodeBasic) ([]*model.CodeBasic, []*model.CodeBasic) {
+	prevMap := make(map[string]bool)
+	for _, code := range prevCodeList {
+		prevMap[code.Code] = true
+	}
+	curMap := make(map[string]bool)
+	for _, code := range codeList {
+		curMap[code.Code] = true
+	}
+	entries := make([]*model.CodeBasic, 0)
+	for _, code := range codeList {
+		if !prevMap[code.Code] {
+			entries = append(entries, code)
+		}
+	}
+	exits := make([]*model.CodeBasic, 0)
+	for _, code := range prevCodeList {
+		if !curMap[code.Code] {
+			exits = append(exits, code)
+		}
+	}
+	return entries, exits
+}
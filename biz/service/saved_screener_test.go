@@ -0,0 +1,47 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zhikongming/stock/biz/model"
+)
+
+func TestDiffScreenerCodeList(t *testing.T) {
+	toCodeList := func(codes ...string) []*model.CodeBasic {
+		ret := make([]*model.CodeBasic, 0, len(codes))
+		for _, code := range codes {
+			ret = append(ret, &model.CodeBasic{Code: code})
+		}
+		return ret
+	}
+	toCodes := func(list []*model.CodeBasic) []string {
+		ret := make([]string, 0, len(list))
+		for _, item := range list {
+			ret = append(ret, item.Code)
+		}
+		return ret
+	}
+
+	testCases := []struct {
+		name    string
+		prev    []*model.CodeBasic
+		cur     []*model.CodeBasic
+		entries []string
+		exits   []string
+	}{
+		{"first run", nil, toCodeList("SH600000", "SZ000001"), []string{"SH600000", "SZ000001"}, []string{}},
+		{"unchanged", toCodeList("SH600000"), toCodeList("SH600000"), []string{}, []string{}},
+		{"entry and exit", toCodeList("SH600000", "SZ000001"), toCodeList("SZ000001", "SZ000002"), []string{"SZ000002"}, []string{"SH600000"}},
+		{"all exit", toCodeList("SH600000"), toCodeList(), []string{}, []string{"SH600000"}},
+	}
+	for _, tc := range testCases {
+		entries, exits := diffScreenerCodeList(tc.prev, tc.cur)
+		if got := strings.Join(toCodes(entries), ","); got != strings.Join(tc.entries, ",") {
+			t.Errorf("%s: entries = %s, want %v", tc.name, got, tc.entries)
+		}
+		if got := strings.Join(toCodes(exits), ","); got != strings.Join(tc.exits, ",") {
+			t.Errorf("%s: exits = %s, want %v", tc.name, got, tc.exits)
+		}
+	}
+}
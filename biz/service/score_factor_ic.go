@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/zhikongming/stock/biz/config"
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	// 总分作为一个额外的因子参与评估
+	ScoreFactorTotal = "score"
+	// 单次评估最多的交易日数量
+	ScoreFactorICMaxDates = 250
+	// 计算截面相关系数最少需要的板块数量
+	ScoreFactorICMinIndustry = 5
+)
+
+var ScoreFactorICHorizons = []int{1, 5, 10}
+
+// AnalyzeScoreFactorIC 逐日回放板块打分, 计算各因子与未来收益的秩相关系数, 以及前N名板块相对全部板块的收益
+func AnalyzeScoreFactorIC(ctx context.Context, req *model.AnalyzeScoreFactorICReq) (*model.AnalyzeScoreFactorICResp, error) {
+	if req.StartDate == "" {
+		return nil, errors.New("start_date is required")
+	}
+	modelName, scoreModel, err := getScoreModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	endDate := req.EndDate
+	if endDate == "" {
+		endDate = utils.GetDateOfToday()
+	}
+	dateList, err := dal.GetTradeDateList(ctx, req.StartDate, "")
+	if err != nil {
+		return nil, err
+	}
+	rangeCount := sort.Search(len(dateList), func(i int) bool {
+		return utils.FormatDate(dateList[i]) > endDate
+	})
+	if rangeCount == 0 {
+		return nil, fmt.Errorf("no trade date between %s and %s", req.StartDate, endDate)
+	}
+	if rangeCount > ScoreFactorICMaxDates {
+		return nil, fmt.Errorf("too many trade dates, max %d", ScoreFactorICMaxDates)
+	}
+	// 多加载回看窗口以及最长持有期的数据
+	maxHorizon := ScoreFactorICHorizons[len(ScoreFactorICHorizons)-1]
+	loadEndIdx := min(rangeCount-1+maxHorizon, len(dateList)-1)
+	trendList, err := GetIndustryTrendDetail(ctx, &model.GetIndustryTrendDataReq{
+		Days:    loadEndIdx + 1 + scoreModel.LookbackDays,
+		EndDate: utils.FormatDate(dateList[loadEndIdx]),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 评估时启用全部因子, 未配置的因子权重为0, 不影响总分
+	evalModel := &config.ScoreModelConfig{
+		LookbackDays: scoreModel.LookbackDays,
+		TopN:         scoreModel.TopN,
+		Factors:      make(map[string]float64),
+	}
+	for _, factor := range scoreFactorList {
+		evalModel.Factors[factor] = scoreModel.Factors[factor]
+	}
+	factorList := append([]string{ScoreFactorTotal}, scoreFactorList...)
+
+	indexMap := make(map[string]map[string]int)
+	for _, trend := range trendList {
+		indexMap[trend.IndustryCode] = make(map[string]int)
+		for idx, item := range trend.PriceTrendList {
+			indexMap[trend.IndustryCode][item.DateString] = idx
+		}
+	}
+	icMap := make(map[string]map[int][]float64)
+	for _, factor := range factorList {
+		icMap[factor] = make(map[int][]float64)
+	}
+	topReturnMap := make(map[int][]float64)
+	avgReturnMap := make(map[int][]float64)
+	dates := 0
+	for _, date := range dateList[:rangeCount] {
+		d := utils.FormatDate(date)
+		windowList := make([]*model.IndustryPriceTrend, 0, len(trendList))
+		forwardMap := make(map[string]map[int]float64)
+		for _, trend := range trendList {
+			idx, ok := indexMap[trend.IndustryCode][d]
+			if !ok || idx < scoreModel.LookbackDays {
+				continue
+			}
+			priceTrendList := trend.PriceTrendList
+			windowList = append(windowList, &model.IndustryPriceTrend{
+				IndustryCode:   trend.IndustryCode,
+				IndustryName:   trend.IndustryName,
+				PriceTrendList: rebasePriceTrendList(priceTrendList[idx-scoreModel.LookbackDays+1:idx+1], priceTrendList[idx-scoreModel.LookbackDays].Price),
+			})
+			forward := make(map[int]float64)
+			for _, days := range ScoreFactorICHorizons {
+				if idx+days < len(priceTrendList) && priceTrendList[idx].Price > 0 {
+					forward[days] = (priceTrendList[idx+days].Price/priceTrendList[idx].Price - 1) * 100
+				}
+			}
+			forwardMap[trend.IndustryCode] = forward
+		}
+		if len(windowList) < ScoreFactorICMinIndustry {
+			continue
+		}
+		dates++
+		sort.Sort(model.SortIndustryPriceTrend(windowList))
+		scoreList := calculateFactorScore(ctx, windowList, evalModel)
+		for _, days := range ScoreFactorICHorizons {
+			returns := make([]float64, 0, len(scoreList))
+			validList := make([]*model.ScoreResult, 0, len(scoreList))
+			for _, s := range scoreList {
+				if r, ok := forwardMap[s.Code][days]; ok {
+					returns = append(returns, r)
+					validList = append(validList, s)
+				}
+			}
+			if len(validList) < ScoreFactorICMinIndustry {
+				continue
+			}
+			for _, factor := range factorList {
+				values := make([]float64, 0, len(validList))
+				for _, s := range validList {
+					if factor == ScoreFactorTotal {
+						values = append(values, s.Score)
+					} else {
+						values = append(values, getScoreFactorValue(s, factor))
+					}
+				}
+				ic := calRankCorrelation(values, returns)
+				if !math.IsNaN(ic) {
+					icMap[factor][days] = append(icMap[factor][days], ic)
+				}
+			}
+			// validList已按总分倒序
+			topN := min(scoreModel.TopN, len(returns))
+			topReturnMap[days] = append(topReturnMap[days], utils.ListFloat64Average(returns[:topN]))
+			avgReturnMap[days] = append(avgReturnMap[days], utils.ListFloat64Average(returns))
+		}
+	}
+
+	ret := &model.AnalyzeScoreFactorICResp{
+		Model:     modelName,
+		StartDate: utils.FormatDate(dateList[0]),
+		EndDate:   utils.FormatDate(dateList[rangeCount-1]),
+		Dates:     dates,
+		TopN:      scoreModel.TopN,
+		Factors:   make([]*model.ScoreFactorICStat, 0, len(factorList)),
+		Basket:    make([]*model.ScoreBasketReturnStat, 0, len(ScoreFactorICHorizons)),
+	}
+	for _, factor := range factorList {
+		stat := &model.ScoreFactorICStat{
+			Factor:   factor,
+			Weight:   scoreModel.Factors[factor],
+			Horizons: make([]*model.ScoreFactorICItem, 0, len(ScoreFactorICHorizons)),
+		}
+		for _, days := range ScoreFactorICHorizons {
+			stat.Horizons = append(stat.Horizons, toScoreFactorICItem(days, icMap[factor][days]))
+		}
+		ret.Factors = append(ret.Factors, stat)
+	}
+	for _, days := range ScoreFactorICHorizons {
+		ret.Basket = append(ret.Basket, toScoreBasketReturnStat(days, topReturnMap[days], avgReturnMap[days]))
+	}
+	return ret, nil
+}
+
+// rebasePriceTrendList 以base为基准重新归一化板块走势, 使窗口内的数据与直接按窗口计算时一致
+func rebasePriceTrendList(priceTrendList []*model.PriceTrend, base float64) []*model.PriceTrend {
+	ret := make([]*model.PriceTrend, 0, len(priceTrendList))
+	for _, item := range priceTrendList {
+		d := *item
+		if base > 0 {
+			d.Price = item.Price / base
+		}
+		ret = append(ret, &d)
+	}
+	return ret
+}
+
+func toScoreFactorICItem(days int, icList []float64) *model.ScoreFactorICItem {
+	item := &model.ScoreFactorICItem{
+		Days:  days,
+		Count: len(icList),
+	}
+	if len(icList) == 0 {
+		return item
+	}
+	mean := utils.ListFloat64Average(icList)
+	variance := 0.0
+	positive := 0
+	for _, ic := range icList {
+		variance += (ic - mean) * (ic - mean)
+		if ic > 0 {
+			positive++
+		}
+	}
+	std := math.Sqrt(variance / float64(len(icList)))
+	item.MeanIC = utils.Float64KeepDecimal(mean, 4)
+	item.ICStd = utils.Float64KeepDecimal(std, 4)
+	if std > 0 {
+		item.ICIR = utils.Float64KeepDecimal(mean/std, 4)
+	}
+	item.PositiveRate = utils.Float64KeepDecimal(float64(positive)/float64(len(icList))*100, 2)
+	return item
+}
+
+func toScoreBasketReturnStat(days int, topReturnList []float64, avgReturnList []float64) *model.ScoreBasketReturnStat {
+	item := &model.ScoreBasketReturnStat{
+		Days:  days,
+		Count: len(topReturnList),
+	}
+	if len(topReturnList) == 0 {
+		return item
+	}
+	win := 0
+	for i := range topReturnList {
+		if topReturnList[i] > avgReturnList[i] {
+			win++
+		}
+	}
+	item.TopReturn = utils.Float64KeepDecimal(utils.ListFloat64Average(topReturnList), 4)
+	item.AvgReturn = utils.Float64KeepDecimal(utils.ListFloat64Average(avgReturnList), 4)
+	item.Excess = utils.Float64KeepDecimal(item.TopReturn-item.AvgReturn, 4)
+	item.WinRate = utils.Float64KeepDecimal(float64(win)/float64(len(topReturnList))*100, 2)
+	return item
+}
+
+// calRankCorrelation 计算两组数据的秩相关系数(Spearman), 相同值取平均秩
+func calRankCorrelation(x []float64, y []float64) float64 {
+	if len(x) != len(y) || len(x) < 2 {
+		return math.NaN()
+	}
+	return calPearsonCorrelation(calAverageRank(x), calAverageRank(y))
+}
+
+func calAverageRank(values []float64) []float64 {
+	idxList := make([]int, len(values))
+	for i := range idxList {
+		idxList[i] = i
+	}
+	sort.Slice(idxList, func(i, j int) bool {
+		return values[idxList[i]] < values[idxList[j]]
+	})
+	ranks := make([]float64, len(values))
+	for i := 0; i < len(idxList); {
+		j := i
+		for j+1 < len(idxList) && values[idxList[j+1]] == values[idxList[i]] {
+			j++
+		}
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranks[idxList[k]] = rank
+		}
+		i = j + 1
+	}
+	return ranks
+}
+
+// calPearsonCorrelation 计算两组等长数据的皮尔逊系数, 任一组数据没有波动时返回NaN
+func calPearsonCorrelation(x []float64, y []float64) float64 {
+	if len(x) != len(y) || len(x) < 2 {
+		return math.NaN()
+	}
+	meanX := utils.ListFloat64Average(x)
+	meanY := utils.ListFloat64Average(y)
+	var cov, stdX, stdY float64
+	for i := range x {
+		devX := x[i] - meanX
+		devY := y[i] - meanY
+		cov += devX * devY
+		stdX += devX * devX
+		stdY += devY * devY
+	}
+	if stdX == 0 || stdY == 0 {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(stdX*stdY)
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalRankCorrelation(t *testing.T) {
+	cases := []struct {
+		x    []float64
+		y    []float64
+		want float64
+	}{
+		{[]float64{1, 2, 3, 4}, []float64{10, 20, 30, 40}, 1},
+		{[]float64{1, 2, 3, 4}, []float64{4, 3, 2, 1}, -1},
+		// 单调但非线性, 秩相关仍为1
+		{[]float64{1, 2, 3, 4}, []float64{1, 4, 9, 100}, 1},
+		// 相同值取平均秩
+		{[]float64{1, 1, 2, 3}, []float64{1, 2, 3, 4}, 0.9487},
+	}
+	for _, c := range cases {
+		got := calRankCorrelation(c.x, c.y)
+		if math.Abs(got-c.want) > 1e-4 {
+			t.Errorf("calRankCorrelation(%v, %v) = %v, want %v", c.x, c.y, got, c.want)
+		}
+	}
+	if !math.IsNaN(calRankCorrelation([]float64{1, 1, 1}, []float64{1, 2, 3})) {
+		t.Errorf("constant values should return NaN")
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/zhikongming/stock/biz/config"
+	"github.com/zhikongming/stock/biz/model"
+)
+
+const (
+	ScoreFactorChange   = "change"
+	ScoreFactorSlope5   = "slope5"
+	ScoreFactorSlope10  = "slope10"
+	ScoreFactorSlope20  = "slope20"
+	ScoreFactorMA5gt10  = "ma5gt10"
+	ScoreFactorMA10gt20 = "ma10gt20"
+	ScoreFactorMA20gt30 = "ma20gt30"
+	ScoreFactorNewHigh  = "new_high"
+	ScoreFactorVolume   = "volume"
+	ScoreFactorRPS20    = "rps20"
+	ScoreFactorRPS5     = "rps5"
+
+	DefaultScoreModelName    = "default"
+	DefaultScoreLookbackDays = 30
+	DefaultScoreTopN         = 15
+)
+
+var scoreFactorList = []string{
+	ScoreFactorChange, ScoreFactorSlope5, ScoreFactorSlope10, ScoreFactorSlope20,
+	ScoreFactorMA5gt10, ScoreFactorMA10gt20, ScoreFactorMA20gt30,
+	ScoreFactorNewHigh, ScoreFactorVolume, ScoreFactorRPS20, ScoreFactorRPS5,
+}
+
+// getScoreModel 根据名称获取打分模型, 名称为空时使用默认模型, 未配置默认模型时所有因子等权
+func getScoreModel(name string) (string, *config.ScoreModelConfig, error) {
+	if name == "" {
+		name = getDefaultScoreModelName()
+	}
+	var scoreModel *config.ScoreModelConfig
+	scoreConfig := config.GetScoreConfig()
+	if scoreConfig != nil && scoreConfig.Models != nil {
+		scoreModel = scoreConfig.Models[name]
+	}
+	if scoreModel == nil {
+		if name != DefaultScoreModelName {
+			return "", nil, fmt.Errorf("score model %s not found", name)
+		}
+		scoreModel = &config.ScoreModelConfig{
+			Factors: make(map[string]float64),
+		}
+		for _, factor := range scoreFactorList {
+			scoreModel.Factors[factor] = 1
+		}
+	}
+	for factor := range scoreModel.Factors {
+		if !isScoreFactor(factor) {
+			return "", nil, fmt.Errorf("invalid factor %s in score model %s", factor, name)
+		}
+	}
+	ret := *scoreModel
+	if ret.LookbackDays <= 0 {
+		ret.LookbackDays = DefaultScoreLookbackDays
+	}
+	if ret.TopN <= 0 {
+		ret.TopN = DefaultScoreTopN
+	}
+	return name, &ret, nil
+}
+
+func getDefaultScoreModelName() string {
+	scoreConfig := config.GetScoreConfig()
+	if scoreConfig == nil || scoreConfig.Default == "" {
+		return DefaultScoreModelName
+	}
+	return scoreConfig.Default
+}
+
+func isScoreFactor(factor string) bool {
+	for _, item := range scoreFactorList {
+		if item == factor {
+			return true
+		}
+	}
+	return false
+}
+
+// getScoreFactorValue 获取板块在某个因子上的得分
+func getScoreFactorValue(s *model.ScoreResult, factor string) float64 {
+	switch factor {
+	case ScoreFactorChange:
+		return s.ChangeScore
+	case ScoreFactorSlope5:
+		return s.Slope5Score
+	case ScoreFactorSlope10:
+		return s.Slope10Score
+	case ScoreFactorSlope20:
+		return s.Slope20Score
+	case ScoreFactorMA5gt10:
+		return s.Score5gt10
+	case ScoreFactorMA10gt20:
+		return s.Score10gt20
+	case ScoreFactorMA20gt30:
+		return s.Score20gt30
+	case ScoreFactorNewHigh:
+		return s.NewHighScore
+	case ScoreFactorVolume:
+		return s.VolumeScore
+	case ScoreFactorRPS20:
+		return s.RPS20Score
+	case ScoreFactorRPS5:
+		return s.RPS5Score
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+// QueryScreener 按表达式筛选股票
+func QueryScreener(ctx context.Context, req *model.ScreenerQueryReq) (*model.ScreenerQueryResp, error) {
+	if strings.TrimSpace(req.Expression) == "" {
+		return nil, newScreenerExprError("expression is empty")
+	}
+	expr, err := ParseScreenerExpr(req.Expression)
+	if err != nil {
+		return nil, newScreenerExprError("parse expression failed: %v", err)
+	}
+	var sortExpr *ScreenerExpr
+	if strings.TrimSpace(req.SortBy) != "" {
+		sortExpr, err = ParseScreenerExpr(req.SortBy)
+		if err != nil {
+			return nil, newScreenerExprError("parse sort_by failed: %v", err)
+		}
+	}
+	// 输出表达式引用的字段以及额外指定的字段
+	fieldList := make([]string, 0)
+	fieldList = append(fieldList, expr.Fields...)
+	window := expr.Window
+	if sortExpr != nil {
+		fieldList = append(fieldList, sortExpr.Fields...)
+		window = max(window, sortExpr.Window)
+	}
+	for _, field := range req.Fields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		fieldWindow, err := getScreenerFieldWindow(field)
+		if err != nil {
+			return nil, newScreenerExprError("%v", err)
+		}
+		fieldList = append(fieldList, field)
+		window = max(window, fieldWindow)
+	}
+	outputFields := make([]string, 0, len(fieldList))
+	for _, field := range fieldList {
+		if !utils.In(field, outputFields) {
+			outputFields = append(outputFields, field)
+		}
+	}
+
+	stockCodeList, err := getScreenerStockCodeList(ctx, req.IndustryCode, req.ConceptID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.IncludeRisk {
+		stockCodeList, err = filterRiskStockCode(ctx, stockCodeList, req.Date)
+		if err != nil {
+			return nil, err
+		}
+	}
+	priceMap, err := getScreenerPriceMap(ctx, stockCodeList, req.Date, window)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*model.ScreenerItem, 0)
+	for _, stockCode := range stockCodeList {
+		priceList := priceMap[stockCode.CompanyCode]
+		matched, err := expr.Match(priceList)
+		if err != nil {
+			// 数据不足的代码直接跳过
+			if errors.Is(err, errScreenerMissingData) {
+				continue
+			}
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+		item := &model.ScreenerItem{
+			Code:   stockCode.CompanyCode,
+			Name:   stockCode.CompanyName,
+			Date:   utils.FormatDate(priceList[0].Date),
+			Values: make(map[string]float64),
+		}
+		for _, field := range outputFields {
+			value, err := GetScreenerFieldValue(field, priceList)
+			if err != nil {
+				continue
+			}
+			item.Values[field] = utils.Float64KeepDecimal(value, 2)
+		}
+		if sortExpr != nil {
+			item.SortValue, _ = sortExpr.Eval(priceList)
+		}
+		items = append(items, item)
+	}
+
+	if sortExpr != nil {
+		sort.Sort(model.ScreenerItemSorter{Items: items, Asc: req.Order == model.ScreenerOrderAsc})
+	} else {
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].Code < items[j].Code
+		})
+	}
+	matched := len(items)
+	limit := req.Limit
+	if limit <= 0 {
+		limit = model.ScreenerDefaultLimit
+	}
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return &model.ScreenerQueryResp{
+		Expression: expr.Source,
+		Total:      len(stockCodeList),
+		Matched:    matched,
+		Items:      items,
+	}, nil
+}
+
+// getScreenerStockCodeList 根据行业和概念限定股票范围
+func getScreenerStockCodeList(ctx context.Context, industryCode string, conceptID int64) ([]*dal.StockCode, error) {
+	stockCodeList, err := dal.GetAllStockCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var scope map[string]bool
+	if industryCode != "" {
+		relationList, err := dal.GetStockIndustryRelation(ctx, industryCode)
+		if err != nil {
+			return nil, err
+		}
+		scope = make(map[string]bool)
+		for _, relation := range relationList {
+			scope[relation.CompanyCode] = true
+		}
+	}
+	if conceptID > 0 {
+		concept, err := dal.GetConcept(ctx, uint(conceptID))
+		if err != nil {
+			return nil, err
+		}
+		if concept == nil {
+			return nil, fmt.Errorf("concept %d not found", conceptID)
+		}
+		conceptScope := make(map[string]bool)
+		for _, code := range strings.Split(concept.Stocks, ",") {
+			code = strings.TrimSpace(code)
+			if code == "" {
+				continue
+			}
+			if scope == nil || scope[code] {
+				conceptScope[code] = true
+			}
+		}
+		scope = conceptScope
+	}
+	if scope == nil {
+		return stockCodeList, nil
+	}
+	ret := make([]*dal.StockCode, 0, len(scope))
+	for _, stockCode := range stockCodeList {
+		if scope[stockCode.CompanyCode] {
+			ret = append(ret, stockCode)
+		}
+	}
+	return ret, nil
+}
+
+// getScreenerPriceMap 获取每只股票最近window条倒序价格数据
+func getScreenerPriceMap(ctx context.Context, stockCodeList []*dal.StockCode, date string, window int) (map[string][]*dal.StockPrice, error) {
+	codeList := make([]string, 0, len(stockCodeList))
+	for _, stockCode := range stockCodeList {
+		codeList = append(codeList, stockCode.CompanyCode)
+	}
+	return BatchGetLastNStockPrice(ctx, codeList, date, window)
+}
@@ -0,0 +1,561 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/zhikongming/stock/biz/dal"
+)
+
+// 选股表达式, 语法示例:
+//   close > ma20 and ma5 > ma10 and main_inflow_5d > 0 and pct_chg_20d between 10 and 40
+// 支持 and/or/not, 比较运算 > >= < <= = == !=, between ... and ..., 四则运算和括号
+
+const (
+	ScreenerMaxWindowDays = 250
+)
+
+var (
+	errScreenerMissingData = errors.New("screener missing data")
+
+	screenerWindowFieldRegex = regexp.MustCompile(`^(pct_chg|main_inflow|amount|high|low)_(\d+)d$`)
+)
+
+// 单日字段, 取最新一条数据
+var screenerBasicFields = map[string]func(p *dal.StockPrice) float64{
+	"open":        func(p *dal.StockPrice) float64 { return p.PriceOpen },
+	"close":       func(p *dal.StockPrice) float64 { return p.PriceClose },
+	"high":        func(p *dal.StockPrice) float64 { return p.PriceHigh },
+	"low":         func(p *dal.StockPrice) float64 { return p.PriceLow },
+	"amount":      func(p *dal.StockPrice) float64 { return float64(p.Amount) },
+	"ma5":         func(p *dal.StockPrice) float64 { return p.Ma5 },
+	"ma10":        func(p *dal.StockPrice) float64 { return p.Ma10 },
+	"ma20":        func(p *dal.StockPrice) float64 { return p.Ma20 },
+	"ma30":        func(p *dal.StockPrice) float64 { return p.Ma30 },
+	"ma60":        func(p *dal.StockPrice) float64 { return p.Ma60 },
+	"boll_up":     func(p *dal.StockPrice) float64 { return p.BollingUp },
+	"boll_mid":    func(p *dal.StockPrice) float64 { return p.BollingMid },
+	"boll_down":   func(p *dal.StockPrice) float64 { return p.BollingDown },
+	"macd_dif":    func(p *dal.StockPrice) float64 { return p.MacdDif },
+	"macd_dea":    func(p *dal.StockPrice) float64 { return p.MacdDea },
+	"macd":        func(p *dal.StockPrice) float64 { return p.GetMacdValue() },
+	"kdj_k":       func(p *dal.StockPrice) float64 { return p.KdjK },
+	"kdj_d":       func(p *dal.StockPrice) float64 { return p.KdjD },
+	"kdj_j":       func(p *dal.StockPrice) float64 { return p.KdjJ },
+	"main_inflow": func(p *dal.StockPrice) float64 { return float64(p.MainInflowAmount) },
+}
+
+// ScreenerExprError 选股表达式或字段无法解析
+type ScreenerExprError struct {
+	Message string
+}
+
+func (e *ScreenerExprError) Error() string {
+	return e.Message
+}
+
+func newScreenerExprError(format string, args ...interface{}) error {
+	return &ScreenerExprError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ScreenerExpr 解析后的选股表达式
+type ScreenerExpr struct {
+	Source string
+	root   screenerNode
+	// 表达式引用到的字段
+	Fields []string
+	// 计算需要的最少数据条数
+	Window int
+}
+
+// Eval 基于倒序(最新在前)的价格数据计算表达式的值
+func (e *ScreenerExpr) Eval(priceList []*dal.StockPrice) (float64, error) {
+	if len(priceList) == 0 {
+		return 0, errScreenerMissingData
+	}
+	return e.root.eval(priceList)
+}
+
+// Match 判断表达式是否成立
+func (e *ScreenerExpr) Match(priceList []*dal.StockPrice) (bool, error) {
+	v, err := e.Eval(priceList)
+	if err != nil {
+		return false, err
+	}
+	return v != 0, nil
+}
+
+// ParseScreenerExpr 解析选股表达式, 解析失败时返回 ScreenerExprError
+func ParseScreenerExpr(source string) (*ScreenerExpr, error) {
+	tokens, err := tokenizeScreenerExpr(source)
+	if err != nil {
+		return nil, newScreenerExprError("%v", err)
+	}
+	p := &screenerParser{tokens: tokens, fields: make(map[string]bool)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, newScreenerExprError("%v", err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, newScreenerExprError("unexpected token %q", p.tokens[p.pos].text)
+	}
+	expr := &ScreenerExpr{
+		Source: source,
+		root:   root,
+		Fields: make([]string, 0, len(p.fields)),
+		Window: 1,
+	}
+	for _, name := range p.order {
+		expr.Fields = append(expr.Fields, name)
+		window, _ := getScreenerFieldWindow(name)
+		if window > expr.Window {
+			expr.Window = window
+		}
+	}
+	return expr, nil
+}
+
+// GetScreenerFieldValue 计算单个字段的值, priceList为倒序数据
+func GetScreenerFieldValue(name string, priceList []*dal.StockPrice) (float64, error) {
+	if len(priceList) == 0 {
+		return 0, errScreenerMissingData
+	}
+	if f, ok := screenerBasicFields[name]; ok {
+		return f(priceList[0]), nil
+	}
+	if name == "pct_chg" {
+		name = "pct_chg_1d"
+	}
+	matches := screenerWindowFieldRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return 0, fmt.Errorf("unknown field %s", name)
+	}
+	days, _ := strconv.Atoi(matches[2])
+	switch matches[1] {
+	case "pct_chg":
+		if len(priceList) <= days || priceList[days].PriceClose == 0 {
+			return 0, errScreenerMissingData
+		}
+		return (priceList[0].PriceClose/priceList[days].PriceClose - 1) * 100, nil
+	}
+	if len(priceList) < days {
+		return 0, errScreenerMissingData
+	}
+	ret := 0.0
+	for i := 0; i < days; i++ {
+		item := priceList[i]
+		switch matches[1] {
+		case "main_inflow":
+			ret += float64(item.MainInflowAmount)
+		case "amount":
+			// 区间平均成交额
+			ret += float64(item.Amount) / float64(days)
+		case "high":
+			if i == 0 || item.PriceHigh > ret {
+				ret = item.PriceHigh
+			}
+		case "low":
+			if i == 0 || item.PriceLow < ret {
+				ret = item.PriceLow
+			}
+		}
+	}
+	return ret, nil
+}
+
+func getScreenerFieldWindow(name string) (int, error) {
+	if _, ok := screenerBasicFields[name]; ok {
+		return 1, nil
+	}
+	if name == "pct_chg" {
+		return 2, nil
+	}
+	matches := screenerWindowFieldRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return 0, fmt.Errorf("unknown field %s", name)
+	}
+	days, _ := strconv.Atoi(matches[2])
+	if days <= 0 || days > ScreenerMaxWindowDays {
+		return 0, fmt.Errorf("invalid days of field %s", name)
+	}
+	if matches[1] == "pct_chg" {
+		return days + 1, nil
+	}
+	return days, nil
+}
+
+type screenerTokenType int
+
+const (
+	screenerTokenNumber screenerTokenType = iota
+	screenerTokenIdent
+	screenerTokenOp
+	screenerTokenLParen
+	screenerTokenRParen
+)
+
+type screenerToken struct {
+	typ   screenerTokenType
+	text  string
+	value float64
+}
+
+func tokenizeScreenerExpr(source string) ([]*screenerToken, error) {
+	tokens := make([]*screenerToken, 0)
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, &screenerToken{typ: screenerTokenLParen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, &screenerToken{typ: screenerTokenRParen, text: ")"})
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			text := string(runes[i:j])
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", text)
+			}
+			tokens = append(tokens, &screenerToken{typ: screenerTokenNumber, text: text, value: value})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, &screenerToken{typ: screenerTokenIdent, text: strings.ToLower(string(runes[i:j]))})
+			i = j
+		case strings.ContainsRune("<>=!", r):
+			text := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				text += "="
+			}
+			if text == "!" {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+			tokens = append(tokens, &screenerToken{typ: screenerTokenOp, text: text})
+			i += len(text)
+		case strings.ContainsRune("+-*/", r):
+			tokens = append(tokens, &screenerToken{typ: screenerTokenOp, text: string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+type screenerParser struct {
+	tokens []*screenerToken
+	pos    int
+	fields map[string]bool
+	order  []string
+}
+
+func (p *screenerParser) peek() *screenerToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *screenerParser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t != nil && t.typ == screenerTokenIdent && t.text == keyword
+}
+
+func (p *screenerParser) isOp(ops ...string) bool {
+	t := p.peek()
+	if t == nil || t.typ != screenerTokenOp {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *screenerParser) parseOr() (screenerNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &screenerBinaryNode{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *screenerParser) parseAnd() (screenerNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &screenerBinaryNode{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *screenerParser) parseNot() (screenerNode, error) {
+	if p.isKeyword("not") {
+		p.pos++
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &screenerNotNode{node: node}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *screenerParser) parseCompare() (screenerNode, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.isKeyword("between") {
+		p.pos++
+		low, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isKeyword("and") {
+			return nil, errors.New("between must be followed by 'and'")
+		}
+		p.pos++
+		high, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return &screenerBetweenNode{node: left, low: low, high: high}, nil
+	}
+	if p.isOp(">", ">=", "<", "<=", "=", "==", "!=") {
+		op := p.peek().text
+		p.pos++
+		right, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return &screenerBinaryNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *screenerParser) parseSum() (screenerNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+", "-") {
+		op := p.peek().text
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &screenerBinaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *screenerParser) parseTerm() (screenerNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*", "/") {
+		op := p.peek().text
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &screenerBinaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *screenerParser) parseUnary() (screenerNode, error) {
+	if p.isOp("-") {
+		p.pos++
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &screenerBinaryNode{op: "-", left: &screenerNumberNode{value: 0}, right: node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *screenerParser) parsePrimary() (screenerNode, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, errors.New("unexpected end of expression")
+	}
+	switch t.typ {
+	case screenerTokenNumber:
+		p.pos++
+		return &screenerNumberNode{value: t.value}, nil
+	case screenerTokenIdent:
+		if _, err := getScreenerFieldWindow(t.text); err != nil {
+			return nil, err
+		}
+		p.pos++
+		if !p.fields[t.text] {
+			p.fields[t.text] = true
+			p.order = append(p.order, t.text)
+		}
+		return &screenerFieldNode{name: t.text}, nil
+	case screenerTokenLParen:
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.peek(); t == nil || t.typ != screenerTokenRParen {
+			return nil, errors.New("missing ')'")
+		}
+		p.pos++
+		return node, nil
+	}
+	return nil, fmt.Errorf("unexpected token %q", t.text)
+}
+
+type screenerNode interface {
+	eval(priceList []*dal.StockPrice) (float64, error)
+}
+
+type screenerNumberNode struct {
+	value float64
+}
+
+func (n *screenerNumberNode) eval(priceList []*dal.StockPrice) (float64, error) {
+	return n.value, nil
+}
+
+type screenerFieldNode struct {
+	name string
+}
+
+func (n *screenerFieldNode) eval(priceList []*dal.StockPrice) (float64, error) {
+	return GetScreenerFieldValue(n.name, priceList)
+}
+
+type screenerNotNode struct {
+	node screenerNode
+}
+
+func (n *screenerNotNode) eval(priceList []*dal.StockPrice) (float64, error) {
+	v, err := n.node.eval(priceList)
+	if err != nil {
+		return 0, err
+	}
+	return boolToFloat(v == 0), nil
+}
+
+type screenerBetweenNode struct {
+	node screenerNode
+	low  screenerNode
+	high screenerNode
+}
+
+func (n *screenerBetweenNode) eval(priceList []*dal.StockPrice) (float64, error) {
+	v, err := n.node.eval(priceList)
+	if err != nil {
+		return 0, err
+	}
+	low, err := n.low.eval(priceList)
+	if err != nil {
+		return 0, err
+	}
+	high, err := n.high.eval(priceList)
+	if err != nil {
+		return 0, err
+	}
+	return boolToFloat(v >= low && v <= high), nil
+}
+
+type screenerBinaryNode struct {
+	op    string
+	left  screenerNode
+	right screenerNode
+}
+
+func (n *screenerBinaryNode) eval(priceList []*dal.StockPrice) (float64, error) {
+	l, err := n.left.eval(priceList)
+	if err != nil {
+		return 0, err
+	}
+	// 短路求值
+	if n.op == "and" && l == 0 {
+		return 0, nil
+	}
+	if n.op == "or" && l != 0 {
+		return 1, nil
+	}
+	r, err := n.right.eval(priceList)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "and", "or":
+		return boolToFloat(r != 0), nil
+	case ">":
+		return boolToFloat(l > r), nil
+	case ">=":
+		return boolToFloat(l >= r), nil
+	case "<":
+		return boolToFloat(l < r), nil
+	case "<=":
+		return boolToFloat(l <= r), nil
+	case "=", "==":
+		return boolToFloat(math.Abs(l-r) < 1e-9), nil
+	case "!=":
+		return boolToFloat(math.Abs(l-r) >= 1e-9), nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return 0, errScreenerMissingData
+		}
+		return l / r, nil
+	}
+	return 0, fmt.Errorf("unknown operator %s", n.op)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
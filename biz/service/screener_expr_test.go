@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zhikongming/stock/biz/dal"
+)
+
+func TestScreenerExpr(t *testing.T) {
+	// 倒序数据, 最新在前
+	priceList := make([]*dal.StockPrice, 0)
+	for i := 0; i < 21; i++ {
+		priceList = append(priceList, &dal.StockPrice{
+			PriceClose:       12 - float64(i)*0.1,
+			Ma5:              11.8,
+			Ma10:             11.5,
+			Ma20:             11,
+			MainInflowAmount: 100,
+		})
+	}
+
+	cases := []struct {
+		expr   string
+		want   bool
+		window int
+	}{
+		{"close > ma20 and ma5 > ma10 and main_inflow_5d > 0 and pct_chg_20d between 10 and 40", true, 21},
+		{"close > ma20 and not (ma5 > ma10)", false, 1},
+		{"close < ma20 or main_inflow_5d = 500", true, 5},
+		{"close > ma20 * 1.1", false, 1},
+		{"-pct_chg < 0", true, 2},
+	}
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			expr, err := ParseScreenerExpr(c.expr)
+			if err != nil {
+				t.Fatalf("ParseScreenerExpr() error = %v", err)
+			}
+			if expr.Window != c.window {
+				t.Errorf("Window = %v, want %v", expr.Window, c.window)
+			}
+			got, err := expr.Match(priceList)
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if got != c.want {
+				t.Errorf("Match() = %v, want %v", got, c.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{"close >", "unknown_field > 1", "close between 1", "(close > 1"} {
+		_, err := ParseScreenerExpr(bad)
+		var exprErr *ScreenerExprError
+		if !errors.As(err, &exprErr) {
+			t.Errorf("ParseScreenerExpr(%q) error = %v, want ScreenerExprError", bad, err)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	DefaultSignalEvaluateStep         = 5
+	DefaultSignalEvaluateWindow       = TrendSignalScanDays
+	DefaultSignalEvaluateThirdBuyDays = 60
+	// 第三类买点阈值全部为空时使用的默认值, 与页面默认值一致
+	DefaultSignalEvaluateThresholdUp        = 20
+	DefaultSignalEvaluateThresholdPullback  = 15
+	DefaultSignalEvaluateThresholdDeviation = 5
+	DefaultSignalEvaluateThresholdProfit    = 15
+	// 缠论分析最少需要的日线数量
+	SignalEvaluateMinBars = 30
+	// 统计最大不利偏移的交易日数量
+	SignalEvaluateMaeDays = 20
+	// 区间结束后额外加载的自然日, 保证末尾的信号有足够的后续数据
+	SignalEvaluateForwardCalendarDays = 45
+)
+
+var (
+	SignalEvaluateHorizons = []int{1, 5, 10, 20}
+	signalEvaluateTypeList = []string{"B1", "B2", "B3", "S1", "S2", "S3", model.SignalTypeThirdBuy}
+)
+
+type signalEvaluateOption struct {
+	StartDate          string
+	EndDate            string
+	Step               int
+	Window             int
+	ThirdBuyDays       int
+	ThresholdUp        float64
+	ThresholdPullback  float64
+	ThresholdDeviation float64
+	ThresholdProfit    float64
+	SignalTypes        map[string]bool
+}
+
+// signalOutcome 单个历史信号的后续表现, 入场价为信号被识别当天的收盘价
+type signalOutcome struct {
+	Code       string
+	SignalType string
+	Returns    map[int]float64
+	Mae        float64
+	HasMae     bool
+}
+
+// EvaluateSignals 回放历史数据, 统计缠论买卖点和第三类买点出现后的收益表现
+func EvaluateSignals(ctx context.Context, req *model.EvaluateSignalReq) (*model.EvaluateSignalResp, error) {
+	startTime := time.Now()
+	opt, err := toSignalEvaluateOption(req)
+	if err != nil {
+		return nil, err
+	}
+	codeList, err := getSignalEvaluateCodeList(ctx, req.IndustryName)
+	if err != nil {
+		return nil, err
+	}
+	_, industryMap, err := getStockNameAndIndustryMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 向前多加载分析窗口的数据, 向后多加载计算收益的数据
+	dateList, err := dal.GetLastNTradeDate(ctx, opt.StartDate, max(opt.Window, opt.ThirdBuyDays))
+	if err != nil {
+		return nil, err
+	}
+	if len(dateList) == 0 {
+		return nil, fmt.Errorf("no stock price data before %s", opt.StartDate)
+	}
+	loadStart := utils.FormatDate(dateList[len(dateList)-1])
+	loadEnd := utils.FormatDate(utils.ParseDate(opt.EndDate).AddDate(0, 0, SignalEvaluateForwardCalendarDays))
+	priceMap, err := BatchGetStockPriceByDate(ctx, codeList, loadStart, loadEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]func() (interface{}, error), 0, len(codeList))
+	for _, code := range codeList {
+		priceList := priceMap[code]
+		if len(priceList) == 0 {
+			continue
+		}
+		jobs = append(jobs, func(code string, priceList []*dal.StockPrice) func() (interface{}, error) {
+			return func() (interface{}, error) {
+				return evaluateCodeSignals(code, priceList, opt), nil
+			}
+		}(code, priceList))
+	}
+	results, err := utils.ConcurrentActuator(jobs, runtime.NumCPU())
+	if err != nil {
+		return nil, err
+	}
+	outcomeList := make([]*signalOutcome, 0)
+	for _, result := range results {
+		outcomeList = append(outcomeList, result.([]*signalOutcome)...)
+	}
+
+	return &model.EvaluateSignalResp{
+		StartDate:  opt.StartDate,
+		EndDate:    opt.EndDate,
+		Total:      len(outcomeList),
+		ByType:     aggregateSignalOutcome(outcomeList, nil),
+		ByIndustry: aggregateSignalOutcome(outcomeList, industryMap),
+		Cost:       time.Since(startTime).String(),
+	}, nil
+}
+
+func toSignalEvaluateOption(req *model.EvaluateSignalReq) (*signalEvaluateOption, error) {
+	if req.StartDate == "" {
+		return nil, errors.New("start_date is required")
+	}
+	opt := &signalEvaluateOption{
+		StartDate:          req.StartDate,
+		EndDate:            req.EndDate,
+		Step:               req.Step,
+		Window:             req.Window,
+		ThirdBuyDays:       req.ThirdBuyDays,
+		ThresholdUp:        req.ThresholdUp,
+		ThresholdPullback:  req.ThresholdPullback,
+		ThresholdDeviation: req.ThresholdDeviation,
+		ThresholdProfit:    req.ThresholdProfit,
+		SignalTypes:        make(map[string]bool),
+	}
+	if opt.EndDate == "" {
+		opt.EndDate = utils.GetDateOfToday()
+	}
+	if opt.EndDate < opt.StartDate {
+		return nil, errors.New("end_date must not be earlier than start_date")
+	}
+	if opt.Step <= 0 {
+		opt.Step = DefaultSignalEvaluateStep
+	}
+	if opt.Window <= 0 {
+		opt.Window = DefaultSignalEvaluateWindow
+	}
+	if opt.ThirdBuyDays <= 0 {
+		opt.ThirdBuyDays = DefaultSignalEvaluateThirdBuyDays
+	}
+	if opt.ThresholdUp == 0 && opt.ThresholdPullback == 0 && opt.ThresholdDeviation == 0 && opt.ThresholdProfit == 0 {
+		opt.ThresholdUp = DefaultSignalEvaluateThresholdUp
+		opt.ThresholdPullback = DefaultSignalEvaluateThresholdPullback
+		opt.ThresholdDeviation = DefaultSignalEvaluateThresholdDeviation
+		opt.ThresholdProfit = DefaultSignalEvaluateThresholdProfit
+	}
+	signalTypes := req.SignalTypes
+	if len(signalTypes) == 0 {
+		signalTypes = signalEvaluateTypeList
+	}
+	for _, signalType := range signalTypes {
+		if !utils.In(signalType, signalEvaluateTypeList) {
+			return nil, fmt.Errorf("invalid signal type %s", signalType)
+		}
+		opt.SignalTypes[signalType] = true
+	}
+	return opt, nil
+}
+
+// getSignalEvaluateCodeList 获取参与评估的股票代码, 行业为空时返回全市场
+func getSignalEvaluateCodeList(ctx context.Context, industryName string) ([]string, error) {
+	codeList := make([]string, 0)
+	if industryName == "" {
+		stockCodeList, err := dal.GetAllStockCode(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, stockCode := range stockCodeList {
+			codeList = append(codeList, stockCode.CompanyCode)
+		}
+		return codeList, nil
+	}
+	industry, err := dal.GetStockIndustryByName(ctx, industryName)
+	if err != nil {
+		return nil, err
+	}
+	if industry == nil {
+		return nil, fmt.Errorf("industry not found")
+	}
+	relationList, err := dal.GetStockIndustryRelation(ctx, industry.Code)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range relationList {
+		codeList = append(codeList, relation.CompanyCode)
+	}
+	return codeList, nil
+}
+
+// evaluateCodeSignals 按步长回放单只股票的正序数据, 每次只使用当天及之前的数据识别信号, 避免使用未来数据
+func evaluateCodeSignals(code string, priceList []*dal.StockPrice, opt *signalEvaluateOption) []*signalOutcome {
+	ret := make([]*signalOutcome, 0)
+	seen := make(map[string]bool)
+	evalDivergence := false
+	for signalType := range opt.SignalTypes {
+		if signalType != model.SignalTypeThirdBuy {
+			evalDivergence = true
+		}
+	}
+	startIdx := sort.Search(len(priceList), func(i int) bool {
+		return utils.FormatDate(priceList[i].Date) >= opt.StartDate
+	})
+	for t := startIdx; t < len(priceList); t += opt.Step {
+		if utils.FormatDate(priceList[t].Date) > opt.EndDate {
+			break
+		}
+		offset := max(t-opt.Window+1, 0)
+		if evalDivergence && t-offset+1 >= SignalEvaluateMinBars {
+			structure := analyzeTrendStructure(priceList[offset : t+1])
+			for _, point := range structure.DivergencePointList {
+				signalType := point.PointType.ToString()
+				date := utils.FormatDate(priceList[offset+point.Index].Date)
+				if !opt.SignalTypes[signalType] || date < opt.StartDate {
+					continue
+				}
+				key := signalType + date
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				ret = append(ret, calSignalOutcome(code, priceList, t, signalType, point.PointType > 0))
+			}
+		}
+		if opt.SignalTypes[model.SignalTypeThirdBuy] {
+			codePriceList := formatStockPriceList(priceList[max(t-opt.ThirdBuyDays+1, 0) : t+1])
+			period := AnalyzeThirdBuyPeriod(codePriceList)
+			if !period.ValidFilter(opt.ThresholdUp, opt.ThresholdPullback, opt.ThresholdDeviation, opt.ThresholdProfit) {
+				continue
+			}
+			// 同一段上涨只记录第一次满足条件的时间
+			key := model.SignalTypeThirdBuy + period.UpPeriod.StartDate + period.UpPeriod.EndDate
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			ret = append(ret, calSignalOutcome(code, priceList, t, model.SignalTypeThirdBuy, true))
+		}
+	}
+	return ret
+}
+
+// calSignalOutcome 计算以entryIdx当天收盘价入场后的收益和最大不利偏移
+func calSignalOutcome(code string, priceList []*dal.StockPrice, entryIdx int, signalType string, isBuy bool) *signalOutcome {
+	ret := &signalOutcome{
+		Code:       code,
+		SignalType: signalType,
+		Returns:    make(map[int]float64),
+	}
+	entryPrice := priceList[entryIdx].PriceClose
+	if entryPrice <= 0 {
+		return ret
+	}
+	for _, days := range SignalEvaluateHorizons {
+		if entryIdx+days >= len(priceList) {
+			continue
+		}
+		ret.Returns[days] = (priceList[entryIdx+days].PriceClose - entryPrice) / entryPrice * 100
+	}
+	for i := entryIdx + 1; i < len(priceList) && i <= entryIdx+SignalEvaluateMaeDays; i++ {
+		var excursion float64
+		if isBuy {
+			excursion = (priceList[i].PriceLow - entryPrice) / entryPrice * 100
+		} else {
+			excursion = (entryPrice - priceList[i].PriceHigh) / entryPrice * 100
+		}
+		if !ret.HasMae || excursion < ret.Mae {
+			ret.Mae = excursion
+			ret.HasMae = true
+		}
+	}
+	ret.Mae = min(ret.Mae, 0)
+	return ret
+}
+
+// aggregateSignalOutcome 按信号类型汇总, industryMap不为空时按行业和信号类型汇总
+func aggregateSignalOutcome(outcomeList []*signalOutcome, industryMap map[string]string) []*model.SignalEvaluateStat {
+	groupMap := make(map[string][]*signalOutcome)
+	statMap := make(map[string]*model.SignalEvaluateStat)
+	for _, outcome := range outcomeList {
+		industryName := ""
+		if industryMap != nil {
+			industryName = industryMap[outcome.Code]
+		}
+		key := industryName + "|" + outcome.SignalType
+		if _, ok := statMap[key]; !ok {
+			statMap[key] = &model.SignalEvaluateStat{
+				SignalType:   outcome.SignalType,
+				IndustryName: industryName,
+			}
+		}
+		groupMap[key] = append(groupMap[key], outcome)
+	}
+
+	ret := make([]*model.SignalEvaluateStat, 0, len(statMap))
+	for key, stat := range statMap {
+		fillSignalEvaluateStat(stat, groupMap[key])
+		ret = append(ret, stat)
+	}
+	typeOrder := make(map[string]int)
+	for i, signalType := range signalEvaluateTypeList {
+		typeOrder[signalType] = i
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].IndustryName != ret[j].IndustryName {
+			return ret[i].IndustryName < ret[j].IndustryName
+		}
+		return typeOrder[ret[i].SignalType] < typeOrder[ret[j].SignalType]
+	})
+	return ret
+}
+
+func fillSignalEvaluateStat(stat *model.SignalEvaluateStat, outcomeList []*signalOutcome) {
+	stat.Count = len(outcomeList)
+	isBuy := stat.SignalType == model.SignalTypeThirdBuy || stat.SignalType[0] == 'B'
+	maeCount := 0
+	maeSum := 0.0
+	for _, outcome := range outcomeList {
+		if !outcome.HasMae {
+			continue
+		}
+		maeCount++
+		maeSum += outcome.Mae
+		stat.WorstMae = min(stat.WorstMae, outcome.Mae)
+	}
+	if maeCount > 0 {
+		stat.AvgMae = utils.Float64KeepDecimal(maeSum/float64(maeCount), 2)
+	}
+	stat.WorstMae = utils.Float64KeepDecimal(stat.WorstMae, 2)
+
+	stat.Horizons = make([]*model.SignalForwardReturnStat, 0, len(SignalEvaluateHorizons))
+	for _, days := range SignalEvaluateHorizons {
+		count, hit := 0, 0
+		sum := 0.0
+		for _, outcome := range outcomeList {
+			value, ok := outcome.Returns[days]
+			if !ok {
+				continue
+			}
+			count++
+			sum += value
+			if (isBuy && value > 0) || (!isBuy && value < 0) {
+				hit++
+			}
+		}
+		item := &model.SignalForwardReturnStat{Days: days, Count: count}
+		if count > 0 {
+			item.AvgReturn = utils.Float64KeepDecimal(sum/float64(count), 2)
+			item.HitRate = utils.Float64KeepDecimal(float64(hit)/float64(count)*100, 2)
+		}
+		stat.Horizons = append(stat.Horizons, item)
+	}
+}
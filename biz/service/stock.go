@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/zhikongming/stock/biz/config"
 	"github.com/zhikongming/stock/biz/dal"
 	"github.com/zhikongming/stock/biz/model"
 	"github.com/zhikongming/stock/utils"
@@ -25,6 +28,19 @@ const (
 
 	MaxJobNum   = 1
 	MaxDBJobNum = 100
+
+	SyncSourceBaidu     = "baidu"
+	SyncSourceEastMoney = "eastmoney"
+
+	DefaultSyncRetryTimes     = 2
+	DefaultSyncRetryInterval  = 3000
+	DefaultSyncSourceInterval = 500
+
+	// 批量查询价格时每批的代码数量
+	BatchStockPriceCodeNum = 500
+	// 批量查询时额外多取的交易日, 兼容停牌导致的数据缺失
+	BatchStockPriceDateBuffer = 10
+	MaxBatchStockPriceJobNum  = 4
 )
 
 func GetAllCode(ctx context.Context) ([]*dal.StockCode, error) {
@@ -35,7 +51,7 @@ func GetAllCode(ctx context.Context) ([]*dal.StockCode, error) {
 	return codeList, nil
 }
 
-func SyncStockCode(ctx context.Context, req *model.SyncStockCodeReq) error {
+func SyncStockCode(ctx context.Context, req *model.SyncStockCodeReq) (*model.SyncStockCodeResp, error) {
 	if len(req.Code) != 0 {
 		return syncOneStockCode(ctx, req)
 	} else {
@@ -43,66 +59,229 @@ func SyncStockCode(ctx context.Context, req *model.SyncStockCodeReq) error {
 	}
 }
 
-func syncOneStockCode(ctx context.Context, req *model.SyncStockCodeReq) error {
+func syncOneStockCode(ctx context.Context, req *model.SyncStockCodeReq) (*model.SyncStockCodeResp, error) {
+	startTime := time.Now()
 	// 判断代码是否存在
 	exist, err := dal.IsStockCodeExist(ctx, req.Code)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if !exist {
 		err = SyncStockBasic(ctx, req)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	err = SyncStockDailyPrice(ctx, req)
+	updated, err := SyncStockDailyPrice(ctx, req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	resp := model.NewSyncStockCodeResp(1)
+	resp.AddResult(req.Code, updated)
+	resp.SetCost(time.Since(startTime))
+	return resp, nil
 }
 
-func syncAllStockCode(ctx context.Context, req *model.SyncStockCodeReq) error {
-	// 获取所有股票代码
-	stockCodeList, err := dal.GetAllStockCode(ctx)
+func syncAllStockCode(ctx context.Context, req *model.SyncStockCodeReq) (*model.SyncStockCodeResp, error) {
+	startTime := time.Now()
+	// 获取所有股票代码, 已经退市的代码不再同步
+	stockCodeList, err := dal.GetListedStockCode(ctx, int(model.StockStatusDelisted))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	var wg sync.WaitGroup
-	jobs := make(chan struct{}, MaxJobNum)
-	failTaskNum := 0
-	mutex := &sync.Mutex{}
-
+	codeList := make([]string, 0, len(stockCodeList))
 	for _, stockCode := range stockCodeList {
-		// 检查任务是否大量出现了问题
-		mutex.Lock()
-		if failTaskNum > MaxJobNum {
-			mutex.Unlock()
-			break
+		codeList = append(codeList, stockCode.CompanyCode)
+	}
+
+	resp := model.NewSyncStockCodeResp(len(codeList))
+	retryTimes, retryInterval := getSyncRetryConfig()
+	sourceConf := getSyncSourceConfig(SyncSourceBaidu)
+	runSyncStockPriceWithRetry(ctx, codeList, sourceConf.Concurrency, retryTimes, retryInterval, resp, syncStockDailyPriceByCode)
+	resp.SetCost(time.Since(startTime))
+	hlog.Infof("sync stock price finished, total: %d, succeeded: %d, skipped: %d, failed: %d, cost: %s",
+		resp.Total, len(resp.Succeeded), len(resp.Skipped), len(resp.Failed), resp.Cost)
+	return resp, nil
+}
+
+// syncStockPriceFunc 同步单个代码的股价, 返回是否拉取到了新数据
+type syncStockPriceFunc func(ctx context.Context, code string) (bool, error)
+
+func syncStockDailyPriceByCode(ctx context.Context, code string) (bool, error) {
+	return SyncStockDailyPrice(ctx, &model.SyncStockCodeReq{Code: code})
+}
+
+// runSyncStockPriceWithRetry 分轮同步, 每轮失败的代码进入下一轮重试, 重试后仍然失败的代码记录到结果中
+func runSyncStockPriceWithRetry(ctx context.Context, codeList []string, concurrency int, retryTimes int, retryInterval time.Duration,
+	resp *model.SyncStockCodeResp, syncFunc syncStockPriceFunc) {
+	failedMap := make(map[string]error)
+	for round := 0; round <= retryTimes && len(codeList) > 0; round++ {
+		if round > 0 {
+			hlog.Infof("retry sync stock price, round: %d, code num: %d", round, len(codeList))
+			time.Sleep(retryInterval)
+		}
+		failedMap = runSyncStockPriceRound(ctx, codeList, concurrency, resp, syncFunc)
+		// 失败的代码进入下一轮重试
+		codeList = make([]string, 0, len(failedMap))
+		for code := range failedMap {
+			codeList = append(codeList, code)
 		}
-		mutex.Unlock()
+		sort.Strings(codeList)
+	}
+	for _, code := range codeList {
+		resp.AddFailed(code, failedMap[code], retryTimes)
+	}
+}
 
+// runSyncStockPriceRound 使用固定数量的worker同步一批代码, 单个代码的失败不影响其他代码, 返回失败的代码及原因
+func runSyncStockPriceRound(ctx context.Context, codeList []string, concurrency int, resp *model.SyncStockCodeResp, syncFunc syncStockPriceFunc) map[string]error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	taskCh := make(chan string, len(codeList))
+	for _, code := range codeList {
+		taskCh <- code
+	}
+	close(taskCh)
+
+	failedMap := make(map[string]error)
+	mutex := &sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
-		jobs <- struct{}{}
-		tmpReq := model.SyncStockCodeReq{
-			Code: stockCode.CompanyCode,
-		}
 		go func() {
-			err := SyncStockDailyPriceWrap(ctx, &tmpReq, &wg, jobs)
-			if err != nil {
+			defer wg.Done()
+			for code := range taskCh {
+				updated, err := syncStockDailyPriceSafely(ctx, code, syncFunc)
 				mutex.Lock()
-				failTaskNum++
+				if err != nil {
+					failedMap[code] = err
+				} else {
+					resp.AddResult(code, updated)
+				}
 				mutex.Unlock()
 			}
 		}()
 	}
 	wg.Wait()
-	if failTaskNum > 0 {
-		return fmt.Errorf("sync stock code failed, fail task num: %d", failTaskNum)
+	return failedMap
+}
+
+func syncStockDailyPriceSafely(ctx context.Context, code string, syncFunc syncStockPriceFunc) (updated bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sync stock price panic: %v", r)
+		}
+	}()
+	return syncFunc(ctx, code)
+}
+
+func getSyncRetryConfig() (int, time.Duration) {
+	return toSyncRetryConfig(config.GetSyncConfig())
+}
+
+func toSyncRetryConfig(syncConf *config.SyncConfig) (int, time.Duration) {
+	retryTimes := DefaultSyncRetryTimes
+	retryInterval := DefaultSyncRetryInterval
+	if syncConf != nil {
+		if syncConf.RetryTimes != nil && *syncConf.RetryTimes >= 0 {
+			retryTimes = *syncConf.RetryTimes
+		}
+		if syncConf.RetryInterval > 0 {
+			retryInterval = syncConf.RetryInterval
+		}
 	}
-	return nil
+	return retryTimes, time.Duration(retryInterval) * time.Millisecond
+}
+
+// syncSourceSetting 数据源的并发数和每次请求后的等待时间
+type syncSourceSetting struct {
+	Concurrency int
+	Interval    time.Duration
+}
+
+// getSyncSourceConfig 获取数据源的并发配置, 未配置时使用默认值
+func getSyncSourceConfig(source string) *syncSourceSetting {
+	return toSyncSourceSetting(config.GetSyncConfig(), source)
+}
+
+func toSyncSourceSetting(syncConf *config.SyncConfig, source string) *syncSourceSetting {
+	ret := &syncSourceSetting{
+		Concurrency: MaxJobNum,
+		Interval:    DefaultSyncSourceInterval * time.Millisecond,
+	}
+	if syncConf == nil || syncConf.Sources == nil {
+		return ret
+	}
+	sourceConf, ok := syncConf.Sources[source]
+	if !ok || sourceConf == nil {
+		return ret
+	}
+	if sourceConf.Concurrency > 0 {
+		ret.Concurrency = sourceConf.Concurrency
+	}
+	if sourceConf.Interval != nil && *sourceConf.Interval >= 0 {
+		ret.Interval = time.Duration(*sourceConf.Interval) * time.Millisecond
+	}
+	return ret
+}
+
+// BatchGetLastNStockPrice 批量获取每只股票截止到date的最近limit条数据, 每只股票的数据为倒序, 与GetLastNStockPrice一致
+func BatchGetLastNStockPrice(ctx context.Context, codeList []string, date string, limit int) (map[string][]*dal.StockPrice, error) {
+	ret := make(map[string][]*dal.StockPrice)
+	if len(codeList) == 0 || limit <= 0 {
+		return ret, nil
+	}
+	dateList, err := dal.GetLastNTradeDate(ctx, date, limit+BatchStockPriceDateBuffer)
+	if err != nil {
+		return nil, err
+	}
+	if len(dateList) == 0 {
+		return ret, nil
+	}
+	dateStart := utils.FormatDate(dateList[len(dateList)-1])
+	dateEnd := utils.FormatDate(dateList[0])
+	ret, err = BatchGetStockPriceByDate(ctx, codeList, dateStart, dateEnd)
+	if err != nil {
+		return nil, err
+	}
+	for code, priceList := range ret {
+		priceList = utils.ListSwap(priceList)
+		if len(priceList) > limit {
+			priceList = priceList[:limit]
+		}
+		ret[code] = priceList
+	}
+	return ret, nil
+}
+
+// BatchGetStockPriceByDate 批量获取每只股票在日期区间内的数据, 每只股票的数据为正序
+func BatchGetStockPriceByDate(ctx context.Context, codeList []string, dateStart string, dateEnd string) (map[string][]*dal.StockPrice, error) {
+	ret := make(map[string][]*dal.StockPrice)
+	if len(codeList) == 0 {
+		return ret, nil
+	}
+	jobs := make([]func() (interface{}, error), 0)
+	for i := 0; i < len(codeList); i += BatchStockPriceCodeNum {
+		end := min(i+BatchStockPriceCodeNum, len(codeList))
+		jobs = append(jobs, func(codeList []string) func() (interface{}, error) {
+			return func() (interface{}, error) {
+				return dal.GetStockPriceByCodeListAndDate(ctx, codeList, dateStart, dateEnd)
+			}
+		}(codeList[i:end]))
+	}
+	results, err := utils.ConcurrentActuator(jobs, MaxBatchStockPriceJobNum)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		for _, item := range result.([]*dal.StockPrice) {
+			ret[item.CompanyCode] = append(ret[item.CompanyCode], item)
+		}
+	}
+	return ret, nil
 }
 
 func SyncStockBasic(ctx context.Context, req *model.SyncStockCodeReq) error {
@@ -136,14 +315,6 @@ func SyncStockBasic(ctx context.Context, req *model.SyncStockCodeReq) error {
 	return err
 }
 
-func SyncStockDailyPriceWrap(ctx context.Context, req *model.SyncStockCodeReq, wg *sync.WaitGroup, jobs chan struct{}) error {
-	defer wg.Done()
-	defer func() {
-		<-jobs
-	}()
-	return SyncStockDailyPrice(ctx, req)
-}
-
 func GetStockPrice(ctx context.Context, code string, startTime time.Time, endTime time.Time, kLineType model.KLineType) ([]*dal.StockPrice, error) {
 	// 只获取数据，不需同步数据
 	client := NewEastMoneyClient()
@@ -196,19 +367,20 @@ func GetStockPrice(ctx context.Context, code string, startTime time.Time, endTim
 	return ret, nil
 }
 
-func SyncStockDailyPrice(ctx context.Context, req *model.SyncStockCodeReq) error {
+// SyncStockDailyPrice 同步日线数据, 返回值表示是否从远端拉取了数据, 已是最新的代码返回false
+func SyncStockDailyPrice(ctx context.Context, req *model.SyncStockCodeReq) (bool, error) {
 	// 检查是否存在股票基础数据, 如果不存在就同步数据
 	client := NewBaiduClient()
 	localStockDailyData, err := dal.GetLastStockPrice(ctx, req.Code)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	dateTime := time.Now()
 	if localStockDailyData != nil {
 		// 如果今天更新过了, 就直接pass
 		if utils.FormatDate(localStockDailyData.UpdateTime) == utils.FormatDate(time.Now()) {
-			return nil
+			return false, nil
 		}
 		// 如果更新时间在昨天下午之后, 但是当前时间在今天下午之前, 则dateTime设置为昨天下午
 		preDay := utils.FormatDate(time.Now().AddDate(0, 0, -1))
@@ -218,13 +390,13 @@ func SyncStockDailyPrice(ctx context.Context, req *model.SyncStockCodeReq) error
 		if localStockDailyData.UpdateTime.After(utils.ParseTime(preDayStartTime)) &&
 			localStockDailyData.UpdateTime.Before(utils.ParseTime(preDayEndTime)) &&
 			time.Now().Before(utils.ParseTime(todayStartTime)) {
-			return nil
+			return false, nil
 		}
 	}
 
 	stockDailyData, err := client.GetRemoteStockDaily(ctx, req.Code, dateTime)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if len(stockDailyData.Item) >= 100 {
 		stockDailyData.Item = stockDailyData.Item[len(stockDailyData.Item)-100:]
@@ -269,7 +441,7 @@ func SyncStockDailyPrice(ctx context.Context, req *model.SyncStockCodeReq) error
 			// 检查是否需要更新
 			stockPrice, err := dal.GetStockPriceByCodeAndDate(ctx, item.CompanyCode, utils.FormatDate(item.Date))
 			if err != nil {
-				return err
+				return false, err
 			}
 			if stockPrice == nil {
 				continue
@@ -292,7 +464,7 @@ func SyncStockDailyPrice(ctx context.Context, req *model.SyncStockCodeReq) error
 				stockPrice.KdjJ = item.KdjJ
 				err = dal.UpdateStockPrice(ctx, stockPrice)
 				if err != nil {
-					return err
+					return false, err
 				}
 			}
 			continue
@@ -302,12 +474,12 @@ func SyncStockDailyPrice(ctx context.Context, req *model.SyncStockCodeReq) error
 		if currentTime.After(closeTimeStamp) {
 			err = dal.CreateStockPrice(ctx, item)
 			if err != nil {
-				return err
+				return false, err
 			}
 		}
 	}
-	time.Sleep(500 * time.Millisecond)
-	return nil
+	time.Sleep(getSyncSourceConfig(SyncSourceBaidu).Interval)
+	return true, nil
 }
 
 func CalculateMa(dailyData []*dal.StockPrice) {
@@ -745,6 +917,40 @@ func SyncFundFlow(ctx context.Context, req *model.SyncFundFlowReq) error {
 	return nil
 }
 
+// SyncStockShares 根据最新的市值和价格计算并更新每只股票的总股本和流通股本, 用于计算板块的市值加权走势
+func SyncStockShares(ctx context.Context) error {
+	stockList, err := dal.GetAllStockCode(ctx)
+	if err != nil {
+		return err
+	}
+	client := NewEastMoneyClient()
+	shareList, err := client.GetRemoteStockShares(ctx)
+	if err != nil {
+		return err
+	}
+	shareMap := make(map[string]*model.StockShareData)
+	for _, share := range shareList {
+		shareMap[share.Code] = share
+	}
+	for _, stock := range stockList {
+		share, ok := shareMap[stock.CompanyCode]
+		// 停牌的股票没有价格, 保留原来的股本数据
+		if !ok || share.Price <= 0 {
+			continue
+		}
+		totalShares := int64(share.TotalMarketCap / share.Price)
+		floatShares := int64(share.FloatMarketCap / share.Price)
+		if totalShares == stock.TotalShares && floatShares == stock.FloatShares {
+			continue
+		}
+		err = dal.UpdateStockCodeShares(ctx, stock.CompanyCode, totalShares, floatShares)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func syncLatestFundFlow(ctx context.Context, stockList []*dal.StockCode) error {
 	if len(stockList) == 0 {
 		return nil
@@ -792,7 +998,8 @@ func syncMultiFundFlow(ctx context.Context, stockList []*dal.StockCode) error {
 
 	dataCh := make(chan *model.WrapFundFlowData, len(stockList))
 	wg := sync.WaitGroup{}
-	jobs := make(chan struct{}, MaxJobNum)
+	sourceConf := getSyncSourceConfig(SyncSourceEastMoney)
+	jobs := make(chan struct{}, sourceConf.Concurrency)
 	for _, stock := range stockList {
 		wg.Add(1)
 		go func(stock *dal.StockCode) {
@@ -801,6 +1008,8 @@ func syncMultiFundFlow(ctx context.Context, stockList []*dal.StockCode) error {
 			defer func() { <-jobs }()
 			client := NewEastMoneyClient()
 			remoteIndustryStockList, err := client.GetRemoteFundFlowByCode(ctx, stock.CompanyCode)
+			// 占用并发名额等待, 控制对数据源的请求频率
+			time.Sleep(sourceConf.Interval)
 			if err != nil {
 				d := &model.WrapFundFlowData{
 					StockCode: stock.CompanyCode,
@@ -877,6 +1086,15 @@ func GetStockInfo(ctx context.Context, req *model.GetStockInfoReq) (*model.Stock
 		Name: stockCode.CompanyName,
 	}
 
+	// 获取最新的RPS
+	rps, err := dal.GetLastStockRps(ctx, stockCode.CompanyCode)
+	if err != nil {
+		return nil, err
+	}
+	if rps != nil {
+		stockInfo.RpsInfo = toStockRpsItem(rps)
+	}
+
 	// 获取行业信息
 	industryRelation, err := dal.GetStockIndustryRelationByCompanyCode(ctx, stockCode.CompanyCode)
 	if err != nil {
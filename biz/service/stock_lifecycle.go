@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	// 远程的股票列表数量低于本地的比例时认为数据不完整, 不做退市判断
+	LifecycleRemoteMinRatio = 0.8
+	// 连续多次不在远程的股票列表中才认为退市, 避免接口偶尔缺数据
+	LifecycleDelistMissTimes = 3
+)
+
+// stockLifecycleState 判断股票状态需要的数据
+type stockLifecycleState struct {
+	Status model.StockStatus
+	// 截止检查日期最后一个有行情的交易日, 没有行情时为空
+	LastTradeDate string
+	// 远程的股票列表是否覆盖该股票所在的板块, 不覆盖时不做退市判断
+	RemoteCovered bool
+	Listed        bool
+	// 之前连续不在远程列表中的次数
+	MissTimes int
+	// 本次同步行情失败, 没有行情不代表停牌
+	SyncFailed bool
+}
+
+// calStockStatus 根据行情和远程的股票列表计算股票的新状态, 以及连续不在远程列表中的次数
+func calStockStatus(state *stockLifecycleState, date string) (model.StockStatus, int) {
+	newStatus := state.Status
+	if state.LastTradeDate == date {
+		newStatus = model.StockStatusNormal
+	} else if state.LastTradeDate != "" && !state.SyncFailed {
+		// 交易日没有行情视为停牌
+		newStatus = model.StockStatusSuspended
+	}
+	missTimes := state.MissTimes
+	if state.RemoteCovered {
+		if state.Listed {
+			missTimes = 0
+		} else {
+			missTimes++
+		}
+	}
+	// 当天有行情说明仍在交易, 不会是退市
+	trading := state.LastTradeDate == date
+	switch {
+	case state.RemoteCovered && missTimes >= LifecycleDelistMissTimes && !trading:
+		return model.StockStatusDelisted, missTimes
+	case state.Status != model.StockStatusDelisted || trading:
+		return newStatus, missTimes
+	case state.RemoteCovered && state.Listed:
+		// 重新上市但还没有同步行情
+		if newStatus == model.StockStatusDelisted {
+			newStatus = model.StockStatusSuspended
+		}
+		return newStatus, missTimes
+	default:
+		return model.StockStatusDelisted, missTimes
+	}
+}
+
+// isLifecycleRemoteBoard 远程的股票列表只包含沪深两市, 不包含北交所
+func isLifecycleRemoteBoard(code string) bool {
+	return strings.HasPrefix(code, "SH") || strings.HasPrefix(code, "SZ")
+}
+
+// SyncStockLifecycle 对比远程的股票列表和本地的行情, 记录更名、ST状态变化、停复牌和退市, 需要在同步股价之后执行
+func SyncStockLifecycle(ctx context.Context, req *model.SyncStockLifecycleReq) (*model.SyncStockLifecycleResp, error) {
+	dateList, err := dal.GetLastNTradeDate(ctx, req.Date, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(dateList) == 0 {
+		return nil, errors.New("no trade date found")
+	}
+	date := dateList[0]
+	stockCodeList, err := dal.GetAllStockCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client := NewEastMoneyClient()
+	remoteList, err := client.GetRemoteStockShares(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remoteMap := make(map[string]*model.StockShareData)
+	for _, item := range remoteList {
+		remoteMap[item.Code] = item
+	}
+	localCount := 0
+	for _, stockCode := range stockCodeList {
+		if isLifecycleRemoteBoard(stockCode.CompanyCode) {
+			localCount++
+		}
+	}
+	remoteComplete := float64(len(remoteMap)) >= float64(localCount)*LifecycleRemoteMinRatio
+	if !remoteComplete {
+		hlog.Warnf("remote stock list is incomplete, remote: %d, local: %d, skip delisting check", len(remoteMap), localCount)
+	}
+	skipCodeMap := make(map[string]bool)
+	for _, code := range req.SkipCodes {
+		skipCodeMap[code] = true
+	}
+	codeList := make([]string, 0, len(stockCodeList))
+	for _, stockCode := range stockCodeList {
+		codeList = append(codeList, stockCode.CompanyCode)
+	}
+	// 长期停牌的股票最后一根K线可能在很久之前, 不能只看最近几个交易日
+	lastDateMap, err := dal.GetLastTradeDateMap(ctx, codeList, utils.FormatDate(date))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &model.SyncStockLifecycleResp{
+		Date: utils.FormatDate(date),
+	}
+	eventList := make([]*dal.StockLifecycle, 0)
+	for _, stockCode := range stockCodeList {
+		code := stockCode.CompanyCode
+		fields := make(map[string]interface{})
+		newEvent := func(eventType model.StockLifecycleEventType, oldValue string, newValue string) {
+			eventList = append(eventList, &dal.StockLifecycle{
+				Code:      code,
+				Date:      date,
+				EventType: int(eventType),
+				OldValue:  oldValue,
+				NewValue:  newValue,
+			})
+		}
+		// 名称变化, 同时检查ST状态是否变化
+		remote, listed := remoteMap[code]
+		if listed && remote.Name != "" && remote.Name != stockCode.CompanyName {
+			newEvent(model.StockLifecycleEventRename, stockCode.CompanyName, remote.Name)
+			oldRisk := model.GetStockRiskTypeByName(stockCode.CompanyName)
+			newRisk := model.GetStockRiskTypeByName(remote.Name)
+			if oldRisk != newRisk {
+				newEvent(model.StockLifecycleEventRiskChange, oldRisk.String(), newRisk.String())
+			}
+			fields["company_name"] = remote.Name
+			ret.Renamed++
+		}
+
+		// 交易日没有行情视为停牌, 连续多次不在远程列表中视为退市
+		oldStatus := model.StockStatus(stockCode.Status)
+		var lastTradeDate string
+		if lastDate, ok := lastDateMap[code]; ok {
+			lastTradeDate = utils.FormatDate(lastDate)
+			if stockCode.LastTradeDate == nil || utils.FormatDate(*stockCode.LastTradeDate) != lastTradeDate {
+				fields["last_trade_date"] = lastDate
+			}
+		}
+		newStatus, missTimes := calStockStatus(&stockLifecycleState{
+			Status:        oldStatus,
+			LastTradeDate: lastTradeDate,
+			RemoteCovered: remoteComplete && isLifecycleRemoteBoard(code),
+			Listed:        listed,
+			MissTimes:     stockCode.RemoteMissTimes,
+			SyncFailed:    skipCodeMap[code],
+		}, ret.Date)
+		if missTimes != stockCode.RemoteMissTimes {
+			fields["remote_miss_times"] = missTimes
+		}
+		if newStatus != oldStatus {
+			switch {
+			case newStatus == model.StockStatusDelisted:
+				newEvent(model.StockLifecycleEventDelist, lastTradeDate, "")
+				ret.Delisted++
+			case oldStatus == model.StockStatusDelisted:
+				newEvent(model.StockLifecycleEventRelist, lastTradeDate, "")
+				ret.Relisted++
+			case newStatus == model.StockStatusSuspended:
+				newEvent(model.StockLifecycleEventSuspend, lastTradeDate, "")
+				ret.Suspended++
+			default:
+				newEvent(model.StockLifecycleEventResume, "", lastTradeDate)
+				ret.Resumed++
+			}
+			fields["status"] = int(newStatus)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		err = dal.UpdateStockCodeFields(ctx, code, fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = dal.CreateStockLifecycleList(ctx, eventList)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetStockLifecycle 查询股票的生命周期事件
+func GetStockLifecycle(ctx context.Context, req *model.GetStockLifecycleReq) ([]*model.StockLifecycleEvent, error) {
+	eventList, err := dal.GetStockLifecycleList(ctx, req.Code, req.StartDate, req.EventType)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*model.StockLifecycleEvent, 0, len(eventList))
+	for _, event := range eventList {
+		eventType := model.StockLifecycleEventType(event.EventType)
+		ret = append(ret, &model.StockLifecycleEvent{
+			Code:      event.Code,
+			Date:      utils.FormatDate(event.Date),
+			EventType: eventType,
+			EventName: eventType.String(),
+			OldValue:  event.OldValue,
+			NewValue:  event.NewValue,
+		})
+	}
+	return ret, nil
+}
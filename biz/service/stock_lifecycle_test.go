@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/zhikongming/stock/biz/model"
+)
+
+func TestCalStockStatus(t *testing.T) {
+	date := "2024-10-08"
+	testCases := []struct {
+		name          string
+		state         *stockLifecycleState
+		wantStatus    model.StockStatus
+		wantMissTimes int
+	}{
+		{
+			name:       "normal",
+			state:      &stockLifecycleState{LastTradeDate: date, RemoteCovered: true, Listed: true, MissTimes: 1},
+			wantStatus: model.StockStatusNormal,
+		},
+		{
+			name:       "suspended",
+			state:      &stockLifecycleState{LastTradeDate: "2024-09-30", RemoteCovered: true, Listed: true},
+			wantStatus: model.StockStatusSuspended,
+		},
+		{
+			name:       "resume",
+			state:      &stockLifecycleState{Status: model.StockStatusSuspended, LastTradeDate: date, RemoteCovered: true, Listed: true},
+			wantStatus: model.StockStatusNormal,
+		},
+		{
+			// 同步行情失败时保持原来的状态
+			name:       "sync failed",
+			state:      &stockLifecycleState{LastTradeDate: "2024-09-30", RemoteCovered: true, Listed: true, SyncFailed: true},
+			wantStatus: model.StockStatusNormal,
+		},
+		{
+			name:          "first miss",
+			state:         &stockLifecycleState{LastTradeDate: "2024-09-30", RemoteCovered: true},
+			wantStatus:    model.StockStatusSuspended,
+			wantMissTimes: 1,
+		},
+		{
+			name:          "delisted after consecutive misses",
+			state:         &stockLifecycleState{Status: model.StockStatusSuspended, LastTradeDate: "2024-09-30", RemoteCovered: true, MissTimes: LifecycleDelistMissTimes - 1},
+			wantStatus:    model.StockStatusDelisted,
+			wantMissTimes: LifecycleDelistMissTimes,
+		},
+		{
+			// 北交所不在远程列表中, 不做退市判断
+			name:       "board not covered",
+			state:      &stockLifecycleState{LastTradeDate: date, MissTimes: 0},
+			wantStatus: model.StockStatusNormal,
+		},
+		{
+			// 之前被误判为退市的股票, 有行情后恢复
+			name:       "delisted but trading",
+			state:      &stockLifecycleState{Status: model.StockStatusDelisted, LastTradeDate: date},
+			wantStatus: model.StockStatusNormal,
+		},
+		{
+			name:          "still delisted",
+			state:         &stockLifecycleState{Status: model.StockStatusDelisted, LastTradeDate: "2024-01-02", RemoteCovered: true, MissTimes: 10},
+			wantStatus:    model.StockStatusDelisted,
+			wantMissTimes: 11,
+		},
+		{
+			name:       "relisted without price",
+			state:      &stockLifecycleState{Status: model.StockStatusDelisted, RemoteCovered: true, Listed: true, MissTimes: 10},
+			wantStatus: model.StockStatusSuspended,
+		},
+	}
+	for _, tc := range testCases {
+		status, missTimes := calStockStatus(tc.state, date)
+		if status != tc.wantStatus || missTimes != tc.wantMissTimes {
+			t.Errorf("%s: status = %s, miss times = %d, want %s, %d", tc.name, status, missTimes, tc.wantStatus, tc.wantMissTimes)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+// SyncStockRisk 根据股票名称、生命周期状态和交易所风险提示维护风险股票名单, 需要在同步生命周期之后执行
+func SyncStockRisk(ctx context.Context, req *model.SyncStockRiskReq) (*model.SyncStockRiskResp, error) {
+	dateList, err := dal.GetLastNTradeDate(ctx, req.Date, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(dateList) == 0 {
+		return nil, errors.New("no trade date found")
+	}
+	date := dateList[0]
+	// 风险解除时以前一个交易日作为截止日期
+	closeDate := date.AddDate(0, 0, -1)
+	if len(dateList) > 1 {
+		closeDate = dateList[1]
+	}
+	stockCodeList, err := dal.GetAllStockCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// ST、退市和停牌的状态没有明确的截止日期, 状态消失时再关闭; 停牌和退市使用生命周期同步的状态
+	detectedList := make([]*dal.StockRisk, 0)
+	for _, stockCode := range stockCodeList {
+		riskType := model.GetStockRiskTypeByName(stockCode.CompanyName)
+		if model.StockStatus(stockCode.Status) == model.StockStatusDelisted {
+			riskType = model.StockRiskTypeDelisting
+		}
+		if riskType != model.StockRiskTypeNone {
+			detectedList = append(detectedList, &dal.StockRisk{
+				Code:      stockCode.CompanyCode,
+				Name:      stockCode.CompanyName,
+				RiskType:  int(riskType),
+				StartDate: date,
+				Reason:    stockCode.CompanyName,
+			})
+		}
+		if model.StockStatus(stockCode.Status) == model.StockStatusSuspended {
+			reason := ""
+			if stockCode.LastTradeDate != nil {
+				reason = fmt.Sprintf("最后交易日: %s", utils.FormatDate(*stockCode.LastTradeDate))
+			}
+			detectedList = append(detectedList, &dal.StockRisk{
+				Code:      stockCode.CompanyCode,
+				Name:      stockCode.CompanyName,
+				RiskType:  int(model.StockRiskTypeSuspended),
+				StartDate: date,
+				Reason:    reason,
+			})
+		}
+	}
+	detectedMap := make(map[string]bool)
+	for _, risk := range detectedList {
+		detectedMap[getStockRiskKey(risk.Code, risk.RiskType)] = true
+	}
+	openList, err := dal.GetOpenStockRiskList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret := &model.SyncStockRiskResp{
+		Date: utils.FormatDate(date),
+	}
+	openMap := make(map[string]bool)
+	for _, risk := range openList {
+		key := getStockRiskKey(risk.Code, risk.RiskType)
+		if detectedMap[key] {
+			openMap[key] = true
+			continue
+		}
+		if err := dal.CloseStockRisk(ctx, risk.ID, closeDate); err != nil {
+			return nil, err
+		}
+		ret.Closed++
+	}
+	for _, risk := range detectedList {
+		if openMap[getStockRiskKey(risk.Code, risk.RiskType)] {
+			continue
+		}
+		if err := dal.CreateStockRisk(ctx, risk); err != nil {
+			return nil, err
+		}
+		ret.Added++
+	}
+
+	// 交易所风险提示自带有效期
+	marketRiskList, err := dal.GetUnusualStockListByType(ctx, int(model.UnusualTypeMarketRisk), utils.FormatDate(date))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range marketRiskList {
+		startDate := utils.ParseDate(utils.FormatDate(utils.ParseDateWithRegion(item.StartDate)))
+		endDate := utils.ParseDate(utils.FormatDate(utils.ParseDateWithRegion(item.EndDate)))
+		exist, err := dal.ExistStockRisk(ctx, item.Code, int(model.StockRiskTypeMarketRisk), startDate)
+		if err != nil {
+			return nil, err
+		}
+		if exist {
+			continue
+		}
+		if err := dal.CreateStockRisk(ctx, &dal.StockRisk{
+			Code:      item.Code,
+			Name:      item.Name,
+			RiskType:  int(model.StockRiskTypeMarketRisk),
+			StartDate: startDate,
+			EndDate:   &endDate,
+			Reason:    item.UnusualReason,
+		}); err != nil {
+			return nil, err
+		}
+		ret.Added++
+	}
+
+	activeList, err := dal.GetActiveStockRiskList(ctx, utils.FormatDate(date))
+	if err != nil {
+		return nil, err
+	}
+	ret.Active = len(activeList)
+	return ret, nil
+}
+
+func getStockRiskKey(code string, riskType int) string {
+	return fmt.Sprintf("%s_%d", code, riskType)
+}
+
+// GetStockRisk 指定股票时返回该股票全部的风险记录, 否则返回某一天有效的风险名单
+func GetStockRisk(ctx context.Context, req *model.GetStockRiskReq) ([]*model.StockRisk, error) {
+	var riskList []*dal.StockRisk
+	var err error
+	if req.Code != "" {
+		riskList, err = dal.GetStockRiskListByCode(ctx, req.Code)
+	} else {
+		date := req.Date
+		if date == "" {
+			date = utils.GetDateOfToday()
+		}
+		riskList, err = dal.GetActiveStockRiskList(ctx, date)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*model.StockRisk, 0, len(riskList))
+	for _, risk := range riskList {
+		if req.RiskType > 0 && risk.RiskType != req.RiskType {
+			continue
+		}
+		item := &model.StockRisk{
+			Code:      risk.Code,
+			Name:      risk.Name,
+			RiskType:  model.StockRiskType(risk.RiskType),
+			RiskName:  model.StockRiskType(risk.RiskType).String(),
+			StartDate: utils.FormatDate(risk.StartDate),
+			Reason:    risk.Reason,
+		}
+		if risk.EndDate != nil {
+			item.EndDate = utils.FormatDate(*risk.EndDate)
+		}
+		ret = append(ret, item)
+	}
+	return ret, nil
+}
+
+// getStockRiskTypeMap 获取某一天有效的风险类型, 日期为空则使用当天
+func getStockRiskTypeMap(ctx context.Context, date string) (map[string][]model.StockRiskType, error) {
+	if date == "" {
+		date = utils.GetDateOfToday()
+	}
+	riskList, err := dal.GetActiveStockRiskList(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string][]model.StockRiskType)
+	for _, risk := range riskList {
+		ret[risk.Code] = append(ret[risk.Code], model.StockRiskType(risk.RiskType))
+	}
+	return ret, nil
+}
+
+// filterRiskStockCode 去除在某一天处于风险名单中的股票
+func filterRiskStockCode(ctx context.Context, stockCodeList []*dal.StockCode, date string) ([]*dal.StockCode, error) {
+	riskTypeMap, err := getStockRiskTypeMap(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*dal.StockCode, 0, len(stockCodeList))
+	for _, stockCode := range stockCodeList {
+		if _, ok := riskTypeMap[stockCode.CompanyCode]; !ok {
+			ret = append(ret, stockCode)
+		}
+	}
+	return ret, nil
+}
+
+// isUnusualExcludedStock ST和退市股票的异动规则不同, 不记录其异动数据
+func isUnusualExcludedStock(riskTypeMap map[string][]model.StockRiskType, code string, name string) bool {
+	// 名单可能还没有同步, 同时检查当前的名称
+	if model.GetStockRiskTypeByName(name) != model.StockRiskTypeNone {
+		return true
+	}
+	for _, riskType := range riskTypeMap[code] {
+		switch riskType {
+		case model.StockRiskTypeST, model.StockRiskTypeStarST, model.StockRiskTypeDelisting:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhikongming/stock/biz/config"
+	"github.com/zhikongming/stock/biz/model"
+)
+
+func TestRunSyncStockPriceWithRetry(t *testing.T) {
+	mutex := sync.Mutex{}
+	callMap := make(map[string]int)
+	syncFunc := func(ctx context.Context, code string) (bool, error) {
+		mutex.Lock()
+		callMap[code]++
+		count := callMap[code]
+		mutex.Unlock()
+		switch code {
+		case "SH600001":
+			// 第一次失败, 重试后成功
+			if count == 1 {
+				return false, errors.New("timeout")
+			}
+			return true, nil
+		case "SH600002":
+			return false, errors.New("not found")
+		case "SH600003":
+			panic("bad data")
+		case "SH600004":
+			return false, nil
+		default:
+			return true, nil
+		}
+	}
+
+	codeList := []string{"SH600000", "SH600001", "SH600002", "SH600003", "SH600004"}
+	resp := model.NewSyncStockCodeResp(len(codeList))
+	runSyncStockPriceWithRetry(context.Background(), codeList, 2, 2, 0, resp, syncFunc)
+
+	sort.Strings(resp.Succeeded)
+	if len(resp.Succeeded) != 2 || resp.Succeeded[0] != "SH600000" || resp.Succeeded[1] != "SH600001" {
+		t.Errorf("succeeded = %v", resp.Succeeded)
+	}
+	if len(resp.Skipped) != 1 || resp.Skipped[0] != "SH600004" {
+		t.Errorf("skipped = %v", resp.Skipped)
+	}
+	if len(resp.Failed) != 2 || resp.Failed[0].Code != "SH600002" || resp.Failed[1].Code != "SH600003" || resp.Failed[0].RetryTimes != 2 {
+		t.Errorf("failed = %+v", resp.Failed)
+	}
+	// 失败的代码共执行 1 + 2 次, 成功的代码不再重试
+	if callMap["SH600002"] != 3 || callMap["SH600000"] != 1 || callMap["SH600001"] != 2 {
+		t.Errorf("call times = %v", callMap)
+	}
+
+	// 配置为不重试时只执行一轮
+	resp = model.NewSyncStockCodeResp(1)
+	runSyncStockPriceWithRetry(context.Background(), []string{"SH600002"}, 1, 0, 0, resp, syncFunc)
+	if len(resp.Failed) != 1 || callMap["SH600002"] != 4 {
+		t.Errorf("no retry: failed = %+v, call times = %d", resp.Failed, callMap["SH600002"])
+	}
+}
+
+func TestSyncConfigDefault(t *testing.T) {
+	zero := 0
+	five := 5
+
+	retryTimes, retryInterval := toSyncRetryConfig(nil)
+	if retryTimes != DefaultSyncRetryTimes || retryInterval != DefaultSyncRetryInterval*time.Millisecond {
+		t.Errorf("default retry = %d, %s", retryTimes, retryInterval)
+	}
+	retryTimes, _ = toSyncRetryConfig(&config.SyncConfig{RetryTimes: &zero})
+	if retryTimes != 0 {
+		t.Errorf("retry_times 0 = %d, want 0", retryTimes)
+	}
+
+	syncConf := &config.SyncConfig{
+		Sources: map[string]*config.SyncSourceConfig{
+			SyncSourceBaidu:     {Concurrency: 3},
+			SyncSourceEastMoney: {Interval: &zero},
+			"xueqiu":            {Interval: &five},
+		},
+	}
+	// 只配置并发数时保留默认的请求间隔
+	setting := toSyncSourceSetting(syncConf, SyncSourceBaidu)
+	if setting.Concurrency != 3 || setting.Interval != DefaultSyncSourceInterval*time.Millisecond {
+		t.Errorf("baidu setting = %+v", setting)
+	}
+	setting = toSyncSourceSetting(syncConf, SyncSourceEastMoney)
+	if setting.Concurrency != MaxJobNum || setting.Interval != 0 {
+		t.Errorf("eastmoney setting = %+v", setting)
+	}
+	setting = toSyncSourceSetting(syncConf, "xueqiu")
+	if setting.Interval != 5*time.Millisecond {
+		t.Errorf("xueqiu setting = %+v", setting)
+	}
+}
@@ -63,6 +63,7 @@ func (p *IndustryRateChangeStrategyParser) Parse() (*StrategyParseResult, error)
 		Days:         p.Days,
 		SyncPrice:    false,
 		IndustryCode: "",
+		IncludeRisk:  true,
 	}
 	industryPriceTrendList, err := GetIndustryTrendDetailByIndustryCode(p.ctx, req, p.IndustryCode)
 	if err != nil {
@@ -8,7 +8,9 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/zhikongming/stock/biz/config"
 	"github.com/zhikongming/stock/biz/dal"
 	"github.com/zhikongming/stock/biz/model"
@@ -20,25 +22,43 @@ const (
 	MaxVolumeReportJobNum     = 50
 )
 
-func GetAnalyzeReport(ctx context.Context) ([]*model.ScoreResult, error) {
-	req := &model.GetIndustryTrendDataReq{
-		Days: 30,
+func GetAnalyzeReport(ctx context.Context, req *model.GetAnalyzeReportReq) ([]*model.ScoreResult, error) {
+	modelName, scoreModel, err := getScoreModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	trendReq := &model.GetIndustryTrendDataReq{
+		Days:        scoreModel.LookbackDays,
+		IncludeRisk: req.IncludeRisk,
 	}
 	// 获取板块数据
-	industryTrendList, err := GetIndustryTrendDetail(ctx, req)
+	industryTrendList, err := GetIndustryTrendDetail(ctx, trendReq)
 	if err != nil {
 		return nil, err
 	}
 	// 计算综合得分
-	res := calculateScore(ctx, industryTrendList)
+	res, allRes := calculateScore(ctx, industryTrendList, scoreModel)
+	// 包含风险股票的结果只返回, 不覆盖打分历史也不发送通知
+	if req.IncludeRisk {
+		return res, nil
+	}
+	// 保存全部行业的打分历史
+	priceTrendList := industryTrendList[0].PriceTrendList
+	date := priceTrendList[len(priceTrendList)-1].DateString
+	if err := saveIndustryScoreHistory(ctx, modelName, date, allRes); err != nil {
+		hlog.Errorf("save industry score history failed, model: %s, err: %v", modelName, err)
+	}
+	// 非默认模型只返回结果, 不发送通知
+	if modelName != getDefaultScoreModelName() {
+		return res, nil
+	}
 	// 获取上一个分数并计算diff
-	scoreDiff := getScoreDiff(ctx, res, industryTrendList[0].PriceTrendList[len(industryTrendList[0].PriceTrendList)-2].DateString)
+	scoreDiff := getScoreDiff(ctx, modelName, res, priceTrendList[len(priceTrendList)-2].DateString, scoreModel.TopN)
 	// 计算这些股票的量价关系
 	calculatePriceAnalyse(ctx, res)
 	// 发送信息通知
-	message := BuildSummaryMessage(res, industryTrendList[0].PriceTrendList[0].DateString, industryTrendList[0].PriceTrendList[len(industryTrendList[0].PriceTrendList)-1].DateString, scoreDiff)
+	message := BuildSummaryMessage(res, priceTrendList[0].DateString, date, scoreDiff)
 	_ = SendLarkMessage(ctx, message)
-	setScoreCache(ctx, res, industryTrendList[0].PriceTrendList[len(industryTrendList[0].PriceTrendList)-1].DateString)
 	return res, nil
 }
 
@@ -73,33 +93,8 @@ func calculatePriceAnalyse(ctx context.Context, scoreList []*model.ScoreResult)
 	return nil
 }
 
-func setScoreCache(ctx context.Context, scoreList []*model.ScoreResult, date string) {
-	// 获取上一个交易日的缓存数据
-	cache, err := dal.GetCacheByTypeDate(ctx, string(dal.CacheKeyScoreResult), dal.CacheTypeScoreResult, date)
-	if err != nil || cache != nil {
-		return
-	}
-	// 缓存数据
-	data := make([]*model.SimpleScoreResult, 0, len(scoreList))
-	for _, score := range scoreList {
-		data = append(data, &model.SimpleScoreResult{
-			Code:  score.Code,
-			Name:  score.Name,
-			Score: score.Score,
-		})
-	}
-	scoreByte, _ := json.Marshal(data)
-	cache = &dal.Cache{
-		DataKey:   string(dal.CacheKeyScoreResult),
-		DataType:  int8(dal.CacheTypeScoreResult),
-		Date:      date,
-		DataValue: string(scoreByte),
-	}
-	dal.CreateCache(ctx, cache)
-	return
-}
-
-func getScoreDiff(ctx context.Context, scoreList []*model.ScoreResult, date string) map[string]*model.ScoreResultDiff {
+// getScoreDiff 与上一个交易日的打分历史对比, 上一日不在前topN的板块视为新进
+func getScoreDiff(ctx context.Context, modelName string, scoreList []*model.ScoreResult, date string, topN int) map[string]*model.ScoreResultDiff {
 	diffMap := make(map[string]*model.ScoreResultDiff)
 	for _, score := range scoreList {
 		diffMap[score.Code] = &model.ScoreResultDiff{
@@ -107,32 +102,24 @@ func getScoreDiff(ctx context.Context, scoreList []*model.ScoreResult, date stri
 			OrderDiff: "-",
 		}
 	}
-	// 获取上一个交易日的缓存数据
-	cache, err := dal.GetCacheByTypeDate(ctx, string(dal.CacheKeyScoreResult), dal.CacheTypeScoreResult, date)
-	if err != nil || cache == nil {
+	// 获取上一个交易日的打分历史
+	prevScoreList, err := dal.GetIndustryScoreByDate(ctx, modelName, date)
+	if err != nil || len(prevScoreList) == 0 {
 		return diffMap
 	}
-	// 解析缓存数据
-	var prevScoreList []*model.SimpleScoreResult
-	err = json.Unmarshal([]byte(cache.DataValue), &prevScoreList)
-	if err != nil {
-		return diffMap
+	prevScoreMap := make(map[string]*dal.IndustryScore)
+	for _, prevScore := range prevScoreList {
+		prevScoreMap[prevScore.IndustryCode] = prevScore
 	}
 	// 计算diff
 	for idx, score := range scoreList {
-		match := false
-		for preIdx, preScore := range prevScoreList {
-			if preScore.Code == score.Code {
-				diffMap[score.Code].ScoreDiff = getScoreDiffMessage(preScore.Score, score.Score)
-				diffMap[score.Code].OrderDiff = getOrderDiffMessage(preIdx, idx)
-				match = true
-				break
-			}
-		}
-		if !match {
-			diffMap[score.Code].ScoreDiff = "-"
+		prevScore, ok := prevScoreMap[score.Code]
+		if !ok || prevScore.Rank > topN {
 			diffMap[score.Code].OrderDiff = "新进"
+			continue
 		}
+		diffMap[score.Code].ScoreDiff = getScoreDiffMessage(prevScore.Score, score.Score)
+		diffMap[score.Code].OrderDiff = getOrderDiffMessage(prevScore.Rank-1, idx)
 	}
 	return diffMap
 }
@@ -167,10 +154,32 @@ func getOrderDiffMessage(originOrder int, newOrder int) string {
 - 判断成交量 > 5日均量，得10分或0分。
 - 计算过去20日涨幅，得到RPS_20值，若>90得15分。
 - 计算过去5日涨幅，得到RPS_5值，若>85得10分。
-2. 汇总总分，得到每个板块的最终分数。
-3. 筛选：输出总分≥70分的板块，或按总分排序取前15名，作为强势板块候选。若需区分异动与持续强势，可再按上述分档条件标记标签。
+2. 按打分模型中配置的权重汇总总分，未启用的因子不参与计算。
+3. 筛选：按总分排序取模型配置的前N名，作为强势板块候选。
 */
-func calculateScore(ctx context.Context, industryTrendList []*model.IndustryPriceTrend) []*model.ScoreResult {
+func calculateScore(ctx context.Context, industryTrendList []*model.IndustryPriceTrend, scoreModel *config.ScoreModelConfig) ([]*model.ScoreResult, []*model.ScoreResult) {
+	result := calculateFactorScore(ctx, industryTrendList, scoreModel)
+	allResult := result
+	// 只取模型配置数量的板块
+	if len(result) > scoreModel.TopN {
+		result = result[:scoreModel.TopN]
+	}
+	// 计算板块内涨幅最大的股票
+	industryMaxChangeStockMap := CalculateIndustryMaxChangeStock(ctx, result)
+	for _, s := range result {
+		if codeChange, ok := industryMaxChangeStockMap[s.Code]; ok {
+			s.MaxStockCode = codeChange.Code
+			s.MaxStockName = codeChange.Name
+			s.MaxStockChange = codeChange.Change
+		}
+	}
+	// 计算板块内的第三类买点的数据
+	CalculateThirdBuyPoint(ctx, result)
+	return result, allResult
+}
+
+// calculateFactorScore 计算全部板块的各项因子得分以及加权总分, 结果按总分倒序
+func calculateFactorScore(ctx context.Context, industryTrendList []*model.IndustryPriceTrend, scoreModel *config.ScoreModelConfig) []*model.ScoreResult {
 	result := make([]*model.ScoreResult, 0, len(industryTrendList))
 	resultMap := make(map[string]*model.ScoreResult)
 	for _, trend := range industryTrendList {
@@ -182,137 +191,113 @@ func calculateScore(ctx context.Context, industryTrendList []*model.IndustryPric
 		resultMap[trend.IndustryCode] = s
 		result = append(result, s)
 	}
+	enabled := func(factor string) bool {
+		_, ok := scoreModel.Factors[factor]
+		return ok
+	}
 	// 根据涨跌幅区间来计算分数
-	changeScoreMap := CalculateChangeScore(ctx, industryTrendList)
-	for code, score := range changeScoreMap {
-		s := resultMap[code]
-		s.ChangeScore = score
+	if enabled(ScoreFactorChange) {
+		changeScoreMap := CalculateChangeScore(ctx, industryTrendList)
+		for code, score := range changeScoreMap {
+			s := resultMap[code]
+			s.ChangeScore = score
+		}
 	}
 	// 5日均线斜率（3日涨幅）并排名打分（10分制）。
-	codeScoreList := make([]*model.CodeScore, 0, len(industryTrendList))
-	for _, trend := range industryTrendList {
-		slope := CalculateSlope(5, 3, trend)
-		if math.IsNaN(slope) {
-			slope = 0.0
+	if enabled(ScoreFactorSlope5) {
+		for code, score := range calculateSlopeScoreMap(industryTrendList, 5, 3) {
+			resultMap[code].Slope5Score = score
 		}
-		codeScore := &model.CodeScore{
-			Code:  trend.IndustryCode,
-			Value: slope,
-		}
-		codeScoreList = append(codeScoreList, codeScore)
-	}
-	slopeScoreMap := CalculateSlopeRankScore(codeScoreList)
-	for code, score := range slopeScoreMap {
-		s := resultMap[code]
-		s.Slope5Score = score
 	}
 	// 10日均线斜率（3日涨幅）排名打分（10分制）。
-	codeScoreList = make([]*model.CodeScore, 0, len(industryTrendList))
-	for _, trend := range industryTrendList {
-		slope := CalculateSlope(10, 3, trend)
-		if math.IsNaN(slope) {
-			slope = 0.0
+	if enabled(ScoreFactorSlope10) {
+		for code, score := range calculateSlopeScoreMap(industryTrendList, 10, 3) {
+			resultMap[code].Slope10Score = score
 		}
-		codeScore := &model.CodeScore{
-			Code:  trend.IndustryCode,
-			Value: slope,
-		}
-		codeScoreList = append(codeScoreList, codeScore)
-	}
-	slopeScoreMap = CalculateSlopeRankScore(codeScoreList)
-	for code, score := range slopeScoreMap {
-		s := resultMap[code]
-		s.Slope10Score = score
 	}
 	// 计算20日均线斜率（5日涨幅）是否>0，得10分或0分。
-	codeScoreList = make([]*model.CodeScore, 0, len(industryTrendList))
-	for _, trend := range industryTrendList {
-		slope := CalculateSlope(20, 5, trend)
-		if math.IsNaN(slope) {
-			slope = 0.0
-		}
-		codeScore := &model.CodeScore{
-			Code:  trend.IndustryCode,
-			Value: slope,
+	if enabled(ScoreFactorSlope20) {
+		for code, score := range calculateSlopeScoreMap(industryTrendList, 20, 5) {
+			resultMap[code].Slope20Score = score
 		}
-		codeScoreList = append(codeScoreList, codeScore)
-	}
-	slopeScoreMap = CalculateSlopeRankScore(codeScoreList)
-	for code, score := range slopeScoreMap {
-		s := resultMap[code]
-		s.Slope20Score = score
 	}
 	// 判断 5>10、10>20、20>30 分别得对应分数。
 	for _, trend := range industryTrendList {
 		score5gt10, score10gt20, score20gt30 := ScoreMAComparisons(trend)
 		s := resultMap[trend.IndustryCode]
-		s.Score5gt10 = score5gt10
-		s.Score10gt20 = score10gt20
-		s.Score20gt30 = score20gt30
+		if enabled(ScoreFactorMA5gt10) {
+			s.Score5gt10 = score5gt10
+		}
+		if enabled(ScoreFactorMA10gt20) {
+			s.Score10gt20 = score10gt20
+		}
+		if enabled(ScoreFactorMA20gt30) {
+			s.Score20gt30 = score20gt30
+		}
 	}
 	// 判断收盘价是否创20日新高，得10分或0分。
-	for _, trend := range industryTrendList {
-		score := ScoreNewHigh(trend)
-		s := resultMap[trend.IndustryCode]
-		s.NewHighScore = score
+	if enabled(ScoreFactorNewHigh) {
+		for _, trend := range industryTrendList {
+			resultMap[trend.IndustryCode].NewHighScore = ScoreNewHigh(trend)
+		}
 	}
 	// 判断成交量 > 5日均量，得10分或0分。
-	for _, trend := range industryTrendList {
-		score := ScoreVolumeAboveMA5(trend)
-		s := resultMap[trend.IndustryCode]
-		s.VolumeScore = score
+	if enabled(ScoreFactorVolume) {
+		for _, trend := range industryTrendList {
+			resultMap[trend.IndustryCode].VolumeScore = ScoreVolumeAboveMA5(trend)
+		}
 	}
 	// 计算过去20日涨幅，得到RPS_20值
-	codeScoreList = make([]*model.CodeScore, 0, len(industryTrendList))
-	for _, trend := range industryTrendList {
-		change := CalculateChange(trend, 20)
-		codeScore := &model.CodeScore{
-			Code:  trend.IndustryCode,
-			Value: change,
+	if enabled(ScoreFactorRPS20) {
+		for code, score := range calculateRpsScoreMap(industryTrendList, 20) {
+			resultMap[code].RPS20Score = score
 		}
-		codeScoreList = append(codeScoreList, codeScore)
-	}
-	rps20ScoreMap := CalculateChangeRankScore(codeScoreList)
-	for code, score := range rps20ScoreMap {
-		s := resultMap[code]
-		s.RPS20Score = score
 	}
 	// 计算过去5日涨幅，得到RPS_5值
-	codeScoreList = make([]*model.CodeScore, 0, len(industryTrendList))
-	for _, trend := range industryTrendList {
-		change := CalculateChange(trend, 5)
-		codeScore := &model.CodeScore{
-			Code:  trend.IndustryCode,
-			Value: change,
+	if enabled(ScoreFactorRPS5) {
+		for code, score := range calculateRpsScoreMap(industryTrendList, 5) {
+			resultMap[code].RPS5Score = score
 		}
-		codeScoreList = append(codeScoreList, codeScore)
-	}
-	rps5ScoreMap := CalculateChangeRankScore(codeScoreList)
-	for code, score := range rps5ScoreMap {
-		s := resultMap[code]
-		s.RPS5Score = score
 	}
-	// 计算总分
+	// 按权重计算总分
 	for _, s := range result {
-		s.Score = utils.Float64KeepDecimal((s.ChangeScore + s.Slope5Score + s.Slope10Score + s.Slope20Score + s.Score5gt10 + s.Score10gt20 + s.Score20gt30 + s.NewHighScore + s.VolumeScore + s.RPS20Score + s.RPS5Score), 2)
+		total := 0.0
+		for factor, weight := range scoreModel.Factors {
+			total += weight * getScoreFactorValue(s, factor)
+		}
+		s.Score = utils.Float64KeepDecimal(total, 2)
 	}
 	// 对结果进行排序
 	sort.Sort(model.ScoreResultSorter(result))
-	// 只取最多15个板块
-	if len(result) > 15 {
-		result = result[:15]
+	return result
+}
+
+// calculateSlopeScoreMap 计算maDays日均线riseDays日的斜率并排名打分
+func calculateSlopeScoreMap(industryTrendList []*model.IndustryPriceTrend, maDays int, riseDays int) map[string]float64 {
+	codeScoreList := make([]*model.CodeScore, 0, len(industryTrendList))
+	for _, trend := range industryTrendList {
+		slope := CalculateSlope(maDays, riseDays, trend)
+		if math.IsNaN(slope) {
+			slope = 0.0
+		}
+		codeScoreList = append(codeScoreList, &model.CodeScore{
+			Code:  trend.IndustryCode,
+			Value: slope,
+		})
 	}
-	// 计算板块内涨幅最大的股票
-	industryMaxChangeStockMap := CalculateIndustryMaxChangeStock(ctx, result)
-	for code, codeChange := range industryMaxChangeStockMap {
-		s := resultMap[code]
-		s.MaxStockCode = codeChange.Code
-		s.MaxStockName = codeChange.Name
-		s.MaxStockChange = codeChange.Change
+	return CalculateSlopeRankScore(codeScoreList)
+}
+
+// calculateRpsScoreMap 计算过去days日涨幅的RPS打分
+func calculateRpsScoreMap(industryTrendList []*model.IndustryPriceTrend, days int) map[string]float64 {
+	codeScoreList := make([]*model.CodeScore, 0, len(industryTrendList))
+	for _, trend := range industryTrendList {
+		codeScoreList = append(codeScoreList, &model.CodeScore{
+			Code:  trend.IndustryCode,
+			Value: CalculateChange(trend, days),
+		})
 	}
-	// 计算板块内的第三类买点的数据
-	CalculateThirdBuyPoint(ctx, result)
-	return result
+	return CalculateChangeRankScore(codeScoreList)
 }
 
 func CalculateChangeScore(ctx context.Context, trendList []*model.IndustryPriceTrend) map[string]float64 {
@@ -352,7 +337,8 @@ func CalculateThirdBuyPoint(ctx context.Context, scoreResultList []*model.ScoreR
 func CalculateIndustryMaxChangeStock(ctx context.Context, scoreResultList []*model.ScoreResult) map[string]*model.CodeChange {
 	result := make(map[string]*model.CodeChange)
 	req := &model.GetIndustryTrendDataReq{
-		Days: 30,
+		Days:        30,
+		IncludeRisk: true,
 	}
 	for _, s := range scoreResultList {
 		req.IndustryCode = s.Code
@@ -836,12 +822,18 @@ func GetPriceAnalyseReport(ctx context.Context) (*model.PriceAnalyseReport, erro
 
 // GetUpTrendReport 获取上升趋势报告
 // 找出均线金叉（MA5上穿MA10）的股票，并计算持续天数
-func GetUpTrendReport(ctx context.Context) ([]*model.UpTrendReportItem, error) {
+func GetUpTrendReport(ctx context.Context, req *model.GetUpTrendReportReq) ([]*model.UpTrendReportItem, error) {
 	// 获取所有股票代码
 	stockList, err := dal.GetAllStockCode(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if !req.IncludeRisk {
+		stockList, err = filterRiskStockCode(ctx, stockList, "")
+		if err != nil {
+			return nil, err
+		}
+	}
 	// 获取所有的板块信息
 	allIndustryList, err := dal.GetAllStockIndustry(ctx)
 	if err != nil {
@@ -1245,3 +1237,171 @@ func BuildSubscribeStrategyReportMessage(data []*model.SubscribeStrategyResult)
 	message.Card.Body.Elements = append(message.Card.Body.Elements, tableElement)
 	return message
 }
+
+func BuildScreenerReportMessage(data []*model.ScreenerRunResult) *model.LarkMessage {
+	tableElement := model.TableElement{
+		Tag:       "table",
+		RowHeight: "middle",
+		HeaderStyle: model.HeaderStyle{
+			BackgroundStyle: "none",
+			Bold:            true,
+			Lines:           1,
+		},
+		Margin:   "0px 0px 0px 0px",
+		PageSize: len(data),
+		Columns: []model.Column{
+			{
+				DataType:        "text",
+				Name:            "name",
+				DisplayName:     "选股器",
+				HorizontalAlign: "left",
+				Width:           "auto",
+			},
+			{
+				DataType:        "number",
+				Name:            "count",
+				DisplayName:     "选中数量",
+				HorizontalAlign: "left",
+				Width:           "auto",
+			},
+			{
+				DataType:        "text",
+				Name:            "entries",
+				DisplayName:     "新进入",
+				HorizontalAlign: "left",
+				Width:           "auto",
+			},
+			{
+				DataType:        "text",
+				Name:            "exits",
+				DisplayName:     "被剔除",
+				HorizontalAlign: "left",
+				Width:           "auto",
+			},
+		},
+		Rows: make([]map[string]interface{}, 0),
+	}
+	joinNames := func(codeList []*model.CodeBasic) string {
+		if len(codeList) == 0 {
+			return "-"
+		}
+		nameList := make([]string, 0, len(codeList))
+		for _, code := range codeList {
+			nameList = append(nameList, code.Name)
+		}
+		return strings.Join(nameList, ", ")
+	}
+	for _, item := range data {
+		tableElement.Rows = append(tableElement.Rows, map[string]interface{}{
+			"name":    item.Name,
+			"count":   len(item.Codes),
+			"entries": joinNames(item.Entries),
+			"exits":   joinNames(item.Exits),
+		})
+	}
+
+	message := &model.LarkMessage{
+		MsgType: "interactive",
+		Card: model.LarkCard{
+			Header: model.LarkHeader{
+				Title: model.LarkTitle{
+					Tag:     "plain_text",
+					Content: "选股器运行通知",
+				},
+				Subtitle: model.LarkTitle{
+					Tag:     "plain_text",
+					Content: fmt.Sprintf("日期: %s", utils.GetDateOfToday()),
+				},
+				Template: "blue",
+				Padding:  "12px 12px 12px 12px",
+			},
+			Schema: "2.0",
+			Config: model.LarkConfig{
+				UpdateMulti: true,
+				Style: model.Style{
+					TextSize: model.TextSize{
+						NormalV2: model.NormalV2{
+							Default: "medium",
+							Pc:      "medium",
+							Mobile:  "heading",
+						},
+					},
+				},
+			},
+			Body: model.LarkBody{
+				Direction:         "vertical",
+				HorizontalSpacing: "8px",
+				VerticalSpacing:   "8px",
+				HorizontalAlign:   "left",
+				VerticalAlign:     "top",
+				Padding:           "12px 12px 12px 12px",
+				Elements: []model.Element{
+					model.MarkdownElement{
+						Tag:       "markdown",
+						Content:   "根据保存的选股器, 基于最新的股价重新筛选, 并展示相比上一次运行新进入和被剔除的股票",
+						TextAlign: "left",
+						TextSize:  "normal_v2",
+						Margin:    "0px 0px 0px 0px",
+					},
+				},
+			},
+		},
+	}
+	message.Card.Body.Elements = append(message.Card.Body.Elements, tableElement)
+	return message
+}
+
+func BuildWatcherAlertMessage(data []*model.WatcherAlert) *model.LarkMessage {
+	lineList := make([]string, 0, len(data))
+	for _, item := range data {
+		lineList = append(lineList, fmt.Sprintf("**%s** %s(%s) %s: %s", item.WatcherName, item.Name, item.Code, item.RuleTypeName, item.Message))
+	}
+
+	return &model.LarkMessage{
+		MsgType: "interactive",
+		Card: model.LarkCard{
+			Header: model.LarkHeader{
+				Title: model.LarkTitle{
+					Tag:     "plain_text",
+					Content: "盯盘提醒",
+				},
+				Subtitle: model.LarkTitle{
+					Tag:     "plain_text",
+					Content: fmt.Sprintf("时间: %s", utils.FormatTime(time.Now())),
+				},
+				Template: "red",
+				Padding:  "12px 12px 12px 12px",
+			},
+			Schema: "2.0",
+			Config: model.LarkConfig{
+				UpdateMulti: true,
+				Style: model.Style{
+					TextSize: model.TextSize{
+						NormalV2: model.NormalV2{
+							Default: "medium",
+							Pc:      "medium",
+							Mobile:  "heading",
+						},
+					},
+				},
+			},
+			Body: model.LarkBody{
+				Direction:         "vertical",
+				HorizontalSpacing: "8px",
+				VerticalSpacing:   "8px",
+				HorizontalAlign:   "left",
+				VerticalAlign:     "top",
+				Padding:           "12px 12px 12px 12px",
+				Elements: []model.Element{
+					model.MarkdownElement{
+						Tag:       "markdown",
+						Content:   strings.Join(lineList, "\n"),
+						TextAlign: "left",
+						TextSize:  "normal_v2",
+						Margin:    "0px 0px 0px 0px",
+					},
+				},
+			},
+		},
+	}
+}
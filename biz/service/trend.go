@@ -18,22 +18,43 @@ func AnalyzeTrendCode(ctx context.Context, req model.AnalyzeTrendCodeReq) (*mode
 	if req.EndDate == "" {
 		req.EndDate = utils.FormatDate(time.Now())
 	}
+	stockPriceList, err := getTrendStockPriceList(ctx, req.Code, req.StartDate, req.EndDate, req.KLineType)
+	if err != nil {
+		return nil, err
+	}
+	structure := analyzeTrendStructure(stockPriceList)
+
+	return toAnalyzeTrendCodeResp(stockPriceList, structure.FractalList, structure.PivotList, structure.DivergencePointList, req.KLineType), nil
+}
+
+// trendStructure 单一级别的缠论结构: 笔, 中枢, 买卖点
+type trendStructure struct {
+	FractalList         []*model.FractalInterval
+	PivotList           []*model.PivotInterval
+	DivergencePointList []*model.DivergencePoint
+}
+
+// getTrendStockPriceList 获取对应级别的正序股价数据
+func getTrendStockPriceList(ctx context.Context, code string, startDate string, endDate string, kLineType model.KLineType) ([]*dal.StockPrice, error) {
 	var stockPriceList []*dal.StockPrice
 	var err error
-	switch req.KLineType {
+	switch kLineType {
 	case model.KLineTypeDay:
-		stockPriceList, err = dal.GetStockPriceByDate(ctx, req.Code, req.StartDate, req.EndDate, utils.StockPriceMaxLimit)
+		stockPriceList, err = dal.GetStockPriceByDate(ctx, code, startDate, endDate, utils.StockPriceMaxLimit)
 	case model.KLineType30Min:
-		startTime := utils.ParseDate(req.StartDate)
-		endTime := utils.ParseDate(req.EndDate)
+		startTime := utils.ParseDate(startDate)
+		endTime := utils.ParseDate(endDate)
 		var stockPriceListTmp []*dal.StockPrice
-		stockPriceListTmp, err = GetStockPrice(ctx, req.Code, startTime, endTime, model.KLineType30Min)
+		stockPriceListTmp, err = GetStockPrice(ctx, code, startTime, endTime, model.KLineType30Min)
 		stockPriceList = utils.ListSwap(stockPriceListTmp)
 	}
 	if err != nil {
 		return nil, err
 	}
-	stockPriceList = utils.ListSwap(stockPriceList)
+	return utils.ListSwap(stockPriceList), nil
+}
+
+func analyzeTrendStructure(stockPriceList []*dal.StockPrice) *trendStructure {
 	// 分析股价趋势，根据收盘价来划分为不同的上涨/下跌趋势, 划分出不同的区间出来
 	trendRangeList := calTrendRangeByPriceClose(stockPriceList)
 	trendRangeList = preprocessTrendRange2(stockPriceList, trendRangeList)
@@ -47,7 +68,11 @@ func AnalyzeTrendCode(ctx context.Context, req model.AnalyzeTrendCodeReq) (*mode
 	// 根据计算的背驰点，判断一二三类买卖点
 	divergencePointList := calTrendDivergence(stockPriceList, trendFractalList, pivotFractalList)
 
-	return toAnalyzeTrendCodeResp(stockPriceList, trendFractalList, pivotFractalList, divergencePointList, req.KLineType), nil
+	return &trendStructure{
+		FractalList:         trendFractalList,
+		PivotList:           pivotFractalList,
+		DivergencePointList: divergencePointList,
+	}
 }
 
 func toAnalyzeTrendCodeResp(stockPriceList []*dal.StockPrice, intervalList []*model.FractalInterval, pivotList []*model.PivotInterval, divergencePointList []*model.DivergencePoint, kLineType model.KLineType) *model.AnalyzeTrendCodeResp {
@@ -108,20 +133,7 @@ func toAnalyzeTrendCodeResp(stockPriceList []*dal.StockPrice, intervalList []*mo
 	}
 
 	for _, item := range divergencePointList {
-		divergenceItem := &model.DivergencePointItem{
-			Date:      utils.FormatDate(stockPriceList[item.Index].Date),
-			PointType: item.PointType.ToString(),
-		}
-		if kLineType == model.KLineType30Min {
-			divergenceItem.Date = utils.FormatShortTime(stockPriceList[item.Index].Date)
-		}
-		switch item.PointType {
-		case model.DivergencePointBuy1, model.DivergencePointBuy2, model.DivergencePointBuy3:
-			divergenceItem.Price = stockPriceList[item.Index].PriceLow
-		case model.DivergencePointSell1, model.DivergencePointSell2, model.DivergencePointSell3:
-			divergenceItem.Price = stockPriceList[item.Index].PriceHigh
-		}
-		ret.DivergencePointData = append(ret.DivergencePointData, divergenceItem)
+		ret.DivergencePointData = append(ret.DivergencePointData, toDivergencePointItem(stockPriceList, item, kLineType))
 	}
 
 	return ret
@@ -503,6 +515,7 @@ func getPriceLowAndHigh(stockPriceList []*dal.StockPrice, frac *model.FractalInt
 	}
 }
 
+// calTrendDivergence 计算第一、二类买卖点, 以及最后一个中枢之后的第三类买卖点
 func calTrendDivergence(stockPriceList []*dal.StockPrice, intervalList []*model.FractalInterval, pivotFractalList []*model.PivotInterval) []*model.DivergencePoint {
 	if len(pivotFractalList) == 0 {
 		return nil
@@ -550,9 +563,47 @@ func calTrendDivergence(stockPriceList []*dal.StockPrice, intervalList []*model.
 		ret = append(ret, retDown...)
 	}
 
+	// 第三类买卖点只看最后一个中枢
+	thirdDivergence := findThirdDivergence(stockPriceList, pivotFractalList[len(pivotFractalList)-1], intervalList)
+	if thirdDivergence != nil {
+		ret = append(ret, thirdDivergence)
+	}
+
 	return ret
 }
 
+// findThirdDivergence 离开中枢的一笔之后, 回抽的一笔不再回到中枢区间内, 构成第三类买卖点
+func findThirdDivergence(stockPriceList []*dal.StockPrice, pivotFractal *model.PivotInterval, intervalList []*model.FractalInterval) *model.DivergencePoint {
+	for i := 0; i < len(intervalList)-1; i++ {
+		leave := intervalList[i]
+		if leave.StartIndex < pivotFractal.EndIndex {
+			continue
+		}
+		back := intervalList[i+1]
+		// 向上离开中枢, 回抽的低点不低于中枢上沿
+		if leave.Class == model.ClassBottom && back.Class == model.ClassTop &&
+			stockPriceList[leave.EndIndex].PriceHigh > pivotFractal.PriceHigh &&
+			stockPriceList[back.EndIndex].PriceLow > pivotFractal.PriceHigh {
+			return &model.DivergencePoint{
+				Index:     back.EndIndex,
+				PointType: model.DivergencePointBuy3,
+			}
+		}
+		// 向下离开中枢, 反抽的高点不高于中枢下沿
+		if leave.Class == model.ClassTop && back.Class == model.ClassBottom &&
+			stockPriceList[leave.EndIndex].PriceLow < pivotFractal.PriceLow &&
+			stockPriceList[back.EndIndex].PriceHigh < pivotFractal.PriceLow {
+			return &model.DivergencePoint{
+				Index:     back.EndIndex,
+				PointType: model.DivergencePointSell3,
+			}
+		}
+		// 只判断离开中枢后的第一笔
+		return nil
+	}
+	return nil
+}
+
 func findDivergence(stockPriceList []*dal.StockPrice, pivotFractal *model.PivotInterval, intervalList []*model.FractalInterval, direction model.TrendType) []*model.DivergencePoint {
 	ret := make([]*model.DivergencePoint, 0)
 	// 计算第一类买点
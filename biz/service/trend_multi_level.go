@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	// 区间套确认时, 日线买卖点前后允许的交易日数量
+	MultiLevelConfirmDays = 1
+)
+
+// AnalyzeMultiLevelTrendCode 同时分析日线和30分钟级别的结构, 将次级别中枢映射到本级别的笔中, 并用区间套确认买卖点
+func AnalyzeMultiLevelTrendCode(ctx context.Context, req model.AnalyzeMultiLevelTrendReq) (*model.AnalyzeMultiLevelTrendResp, error) {
+	if req.EndDate == "" {
+		req.EndDate = utils.FormatDate(time.Now())
+	}
+	dayPriceList, err := getTrendStockPriceList(ctx, req.Code, req.StartDate, req.EndDate, model.KLineTypeDay)
+	if err != nil {
+		return nil, err
+	}
+	if len(dayPriceList) == 0 {
+		return nil, fmt.Errorf("no stock price data, please sync first")
+	}
+	minPriceList, err := getTrendStockPriceList(ctx, req.Code, req.StartDate, req.EndDate, model.KLineType30Min)
+	if err != nil {
+		return nil, err
+	}
+	dayStructure := analyzeTrendStructure(dayPriceList)
+	minStructure := analyzeTrendStructure(minPriceList)
+
+	return &model.AnalyzeMultiLevelTrendResp{
+		Day:          toAnalyzeTrendCodeResp(dayPriceList, dayStructure.FractalList, dayStructure.PivotList, dayStructure.DivergencePointList, model.KLineTypeDay),
+		Min30:        toAnalyzeTrendCodeResp(minPriceList, minStructure.FractalList, minStructure.PivotList, minStructure.DivergencePointList, model.KLineType30Min),
+		Segments:     mapMultiLevelSegments(dayPriceList, dayStructure, minPriceList, minStructure),
+		NestedPoints: calNestedDivergencePoints(dayPriceList, dayStructure, minPriceList, minStructure),
+	}, nil
+}
+
+// mapMultiLevelSegments 将30分钟级别的中枢和买卖点归入所在的日线笔
+func mapMultiLevelSegments(dayPriceList []*dal.StockPrice, dayStructure *trendStructure, minPriceList []*dal.StockPrice, minStructure *trendStructure) []*model.MultiLevelSegmentItem {
+	ret := make([]*model.MultiLevelSegmentItem, 0, len(dayStructure.FractalList))
+	for _, fractal := range dayStructure.FractalList {
+		startDate := utils.FormatDate(dayPriceList[fractal.StartIndex].Date)
+		endDate := utils.FormatDate(dayPriceList[fractal.EndIndex].Date)
+		item := &model.MultiLevelSegmentItem{
+			StartDate:           startDate,
+			EndDate:             endDate,
+			Class:               fractal.Class,
+			SubPivots:           make([]*model.PivotItem, 0),
+			SubDivergencePoints: make([]*model.DivergencePointItem, 0),
+		}
+		if fractal.Class == model.ClassTop {
+			item.PriceStart = dayPriceList[fractal.StartIndex].PriceHigh
+			item.PriceEnd = dayPriceList[fractal.EndIndex].PriceLow
+		} else {
+			item.PriceStart = dayPriceList[fractal.StartIndex].PriceLow
+			item.PriceEnd = dayPriceList[fractal.EndIndex].PriceHigh
+		}
+		for _, pivot := range minStructure.PivotList {
+			// 次级别中枢完全落在本级别笔的区间内
+			pivotStart := utils.FormatDate(minPriceList[pivot.StartIndex].Date)
+			pivotEnd := utils.FormatDate(minPriceList[pivot.EndIndex].Date)
+			if pivotStart < startDate || pivotEnd > endDate {
+				continue
+			}
+			item.SubPivots = append(item.SubPivots, &model.PivotItem{
+				StartDate: utils.FormatShortTime(minPriceList[pivot.StartIndex].Date),
+				EndDate:   utils.FormatShortTime(minPriceList[pivot.EndIndex].Date),
+				PriceHigh: pivot.PriceHigh,
+				PriceLow:  pivot.PriceLow,
+			})
+		}
+		for _, point := range minStructure.DivergencePointList {
+			pointDate := utils.FormatDate(minPriceList[point.Index].Date)
+			if pointDate < startDate || pointDate > endDate {
+				continue
+			}
+			item.SubDivergencePoints = append(item.SubDivergencePoints, toDivergencePointItem(minPriceList, point, model.KLineType30Min))
+		}
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+// calNestedDivergencePoints 区间套: 日线买卖点附近出现同方向的30分钟买卖点时, 认为该买卖点得到确认
+func calNestedDivergencePoints(dayPriceList []*dal.StockPrice, dayStructure *trendStructure, minPriceList []*dal.StockPrice, minStructure *trendStructure) []*model.NestedDivergencePointItem {
+	ret := make([]*model.NestedDivergencePointItem, 0)
+	for _, point := range dayStructure.DivergencePointList {
+		startDate := utils.FormatDate(dayPriceList[max(point.Index-MultiLevelConfirmDays, 0)].Date)
+		endDate := utils.FormatDate(dayPriceList[min(point.Index+MultiLevelConfirmDays, len(dayPriceList)-1)].Date)
+		for _, minPoint := range minStructure.DivergencePointList {
+			if !isSameDivergenceDirection(point.PointType, minPoint.PointType) {
+				continue
+			}
+			minPointDate := utils.FormatDate(minPriceList[minPoint.Index].Date)
+			if minPointDate < startDate || minPointDate > endDate {
+				continue
+			}
+			ret = append(ret, &model.NestedDivergencePointItem{
+				Point:      toDivergencePointItem(dayPriceList, point, model.KLineTypeDay),
+				LowerPoint: toDivergencePointItem(minPriceList, minPoint, model.KLineType30Min),
+			})
+			break
+		}
+	}
+	return ret
+}
+
+func isSameDivergenceDirection(a, b model.DivergencePointType) bool {
+	return (a > 0 && b > 0) || (a < 0 && b < 0)
+}
+
+func toDivergencePointItem(stockPriceList []*dal.StockPrice, point *model.DivergencePoint, kLineType model.KLineType) *model.DivergencePointItem {
+	item := &model.DivergencePointItem{
+		Date:      utils.FormatDate(stockPriceList[point.Index].Date),
+		PointType: point.PointType.ToString(),
+	}
+	if kLineType == model.KLineType30Min {
+		item.Date = utils.FormatShortTime(stockPriceList[point.Index].Date)
+	}
+	switch point.PointType {
+	case model.DivergencePointBuy1, model.DivergencePointBuy2, model.DivergencePointBuy3:
+		item.Price = stockPriceList[point.Index].PriceLow
+	case model.DivergencePointSell1, model.DivergencePointSell2, model.DivergencePointSell3:
+		item.Price = stockPriceList[point.Index].PriceHigh
+	}
+	return item
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	// 全市场扫描时每只股票使用的日线数量
+	TrendSignalScanDays = 120
+)
+
+// ScanTrendSignals 对全市场股票执行缠论分析, 保存新出现的买卖点
+func ScanTrendSignals(ctx context.Context, req *model.ScanTrendSignalReq) (*model.ScanTrendSignalResp, error) {
+	startTime := time.Now()
+	stockCodeList, err := dal.GetAllStockCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codeList := make([]string, 0, len(stockCodeList))
+	for _, stockCode := range stockCodeList {
+		codeList = append(codeList, stockCode.CompanyCode)
+	}
+	priceMap, err := BatchGetLastNStockPrice(ctx, codeList, req.Date, TrendSignalScanDays)
+	if err != nil {
+		return nil, err
+	}
+
+	// 补扫历史日期时以该日期作为发现日期
+	scanDate := utils.ParseDate(utils.GetDateOfToday())
+	if req.Date != "" {
+		scanDate = utils.ParseDate(req.Date)
+	}
+	// 第一次扫描时历史上的买卖点无法知道何时被发现, 以买卖点所在K线的日期作为发现日期, 避免全部堆在同一天
+	lastScanDate, err := dal.GetLastTrendSignalScanDate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	initial := lastScanDate == ""
+	jobs := make([]func() (interface{}, error), 0, len(codeList))
+	for _, code := range codeList {
+		priceList := priceMap[code]
+		if len(priceList) == 0 {
+			continue
+		}
+		jobs = append(jobs, func(code string, priceList []*dal.StockPrice) func() (interface{}, error) {
+			return func() (interface{}, error) {
+				return scanTrendSignal(code, priceList, scanDate, initial), nil
+			}
+		}(code, priceList))
+	}
+	results, err := utils.ConcurrentActuator(jobs, runtime.NumCPU())
+	if err != nil {
+		return nil, err
+	}
+	signalList := make([]*dal.TrendSignal, 0)
+	for _, result := range results {
+		signalList = append(signalList, result.([]*dal.TrendSignal)...)
+	}
+	created, err := dal.CreateTrendSignalList(ctx, signalList)
+	if err != nil {
+		return nil, err
+	}
+	resp := &model.ScanTrendSignalResp{
+		Total:    len(codeList),
+		Analyzed: len(jobs),
+		Created:  int(created),
+		Cost:     time.Since(startTime).String(),
+	}
+	hlog.Infof("scan trend signals finished, analyzed: %d, created: %d, cost: %s", resp.Analyzed, resp.Created, resp.Cost)
+	return resp, nil
+}
+
+// scanTrendSignal 分析单只股票的日线结构, priceList为倒序数据, initial为true时发现日期使用买卖点所在K线的日期
+func scanTrendSignal(code string, priceList []*dal.StockPrice, scanDate time.Time, initial bool) []*dal.TrendSignal {
+	ascPriceList := utils.ListSwap(append([]*dal.StockPrice{}, priceList...))
+	structure := analyzeTrendStructure(ascPriceList)
+	ret := make([]*dal.TrendSignal, 0, len(structure.DivergencePointList))
+	for _, point := range structure.DivergencePointList {
+		item := toDivergencePointItem(ascPriceList, point, model.KLineTypeDay)
+		signal := &dal.TrendSignal{
+			Code:      code,
+			Date:      ascPriceList[point.Index].Date,
+			PointType: int(point.PointType),
+			Price:     item.Price,
+			ScanDate:  scanDate,
+		}
+		if initial {
+			signal.ScanDate = signal.Date
+		}
+		ret = append(ret, signal)
+	}
+	return ret
+}
+
+// GetTrendSignals 查询某一天新发现的买卖点, 日期为空时使用最近一次发现新信号的日期
+// 第三类买卖点要等回抽的一笔结束才能确认, 所以按发现日期而不是K线日期筛选
+func GetTrendSignals(ctx context.Context, req *model.GetTrendSignalReq) ([]*model.TrendSignalItem, error) {
+	var pointType *int
+	if req.Type != "" {
+		t, ok := model.ParseDivergencePointType(req.Type)
+		if !ok {
+			return nil, fmt.Errorf("invalid type %s", req.Type)
+		}
+		v := int(t)
+		pointType = &v
+	}
+	date := req.Date
+	if date == "" && req.Code == "" {
+		lastDate, err := dal.GetLastTrendSignalScanDate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		date = lastDate
+	}
+	signalList, err := dal.GetTrendSignalList(ctx, date, pointType, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	nameMap, industryMap, err := getStockNameAndIndustryMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*model.TrendSignalItem, 0, len(signalList))
+	for _, signal := range signalList {
+		ret = append(ret, &model.TrendSignalItem{
+			Code:         signal.Code,
+			Name:         nameMap[signal.Code],
+			IndustryName: industryMap[signal.Code],
+			Date:         utils.FormatDate(signal.Date),
+			PointType:    model.DivergencePointType(signal.PointType).ToString(),
+			Price:        signal.Price,
+			ScanDate:     utils.FormatDate(signal.ScanDate),
+		})
+	}
+	return ret, nil
+}
+
+// getStockNameAndIndustryMap 获取股票代码到名称以及行业名称的映射
+func getStockNameAndIndustryMap(ctx context.Context) (map[string]string, map[string]string, error) {
+	stockCodeList, err := dal.GetAllStockCode(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	nameMap := make(map[string]string)
+	for _, stockCode := range stockCodeList {
+		nameMap[stockCode.CompanyCode] = stockCode.CompanyName
+	}
+	industryList, err := dal.GetAllStockIndustry(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	industryNameMap := make(map[string]string)
+	for _, industry := range industryList {
+		industryNameMap[industry.Code] = industry.Name
+	}
+	relationList, err := dal.GetAllStockIndustryRelation(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	industryMap := make(map[string]string)
+	for _, relation := range relationList {
+		industryMap[relation.CompanyCode] = industryNameMap[relation.IndustryCode]
+	}
+	return nameMap, industryMap, nil
+}
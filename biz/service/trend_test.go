@@ -0,0 +1,94 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+)
+
+func TestFindThirdDivergence(t *testing.T) {
+	// 中枢区间为 [10, 12], 在索引 4 结束
+	pivot := &model.PivotInterval{StartIndex: 0, EndIndex: 4, PriceLow: 10, PriceHigh: 12}
+	toPriceList := func(prices ...[2]float64) []*dal.StockPrice {
+		ret := make([]*dal.StockPrice, 0, len(prices))
+		for _, p := range prices {
+			ret = append(ret, &dal.StockPrice{PriceLow: p[0], PriceHigh: p[1]})
+		}
+		return ret
+	}
+	// 索引 0-4 为中枢内的K线, 5-7 为离开中枢和回抽的K线
+	inPivot := [][2]float64{{10, 12}, {10.5, 11.5}, {10, 11}, {11, 12}, {10.5, 11.5}}
+
+	testCases := []struct {
+		name      string
+		prices    [][2]float64
+		intervals []*model.FractalInterval
+		wantNil   bool
+		wantIndex int
+		wantType  model.DivergencePointType
+	}{
+		{
+			name:   "leave up and pull back above pivot",
+			prices: [][2]float64{{11, 11.5}, {13, 14}, {12.5, 13}},
+			intervals: []*model.FractalInterval{
+				{StartIndex: 4, EndIndex: 6, Class: model.ClassBottom},
+				{StartIndex: 6, EndIndex: 7, Class: model.ClassTop},
+			},
+			wantIndex: 7,
+			wantType:  model.DivergencePointBuy3,
+		},
+		{
+			name:   "leave down and pull back below pivot",
+			prices: [][2]float64{{10, 10.5}, {8, 9}, {9, 9.5}},
+			intervals: []*model.FractalInterval{
+				{StartIndex: 4, EndIndex: 6, Class: model.ClassTop},
+				{StartIndex: 6, EndIndex: 7, Class: model.ClassBottom},
+			},
+			wantIndex: 7,
+			wantType:  model.DivergencePointSell3,
+		},
+		{
+			name:   "pull back re-enters pivot",
+			prices: [][2]float64{{11, 11.5}, {13, 14}, {11.5, 13}},
+			intervals: []*model.FractalInterval{
+				{StartIndex: 4, EndIndex: 6, Class: model.ClassBottom},
+				{StartIndex: 6, EndIndex: 7, Class: model.ClassTop},
+			},
+			wantNil: true,
+		},
+		{
+			name: "only first stroke after pivot is checked",
+			// 离开后的第一笔回到中枢内, 之后的两笔虽然满足条件也不再判断
+			prices: [][2]float64{{10.5, 11}, {13, 14}, {12.5, 13}},
+			intervals: []*model.FractalInterval{
+				{StartIndex: 4, EndIndex: 5, Class: model.ClassTop},
+				{StartIndex: 5, EndIndex: 6, Class: model.ClassBottom},
+				{StartIndex: 6, EndIndex: 7, Class: model.ClassTop},
+			},
+			wantNil: true,
+		},
+		{
+			name:   "no pull back stroke yet",
+			prices: [][2]float64{{11, 11.5}, {12, 13}, {13, 14}},
+			intervals: []*model.FractalInterval{
+				{StartIndex: 4, EndIndex: 7, Class: model.ClassBottom},
+			},
+			wantNil: true,
+		},
+	}
+	for _, tc := range testCases {
+		stockPriceList := toPriceList(append(append([][2]float64{}, inPivot...), tc.prices...)...)
+		intervalList := append([]*model.FractalInterval{{StartIndex: 0, EndIndex: 4, Class: model.ClassTop}}, tc.intervals...)
+		point := findThirdDivergence(stockPriceList, pivot, intervalList)
+		if tc.wantNil {
+			if point != nil {
+				t.Errorf("%s: point = %+v, want nil", tc.name, point)
+			}
+			continue
+		}
+		if point == nil || point.Index != tc.wantIndex || point.PointType != tc.wantType {
+			t.Errorf("%s: point = %+v, want index %d type %s", tc.name, point, tc.wantIndex, tc.wantType.ToString())
+		}
+	}
+}
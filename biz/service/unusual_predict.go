@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"sort"
-	"strings"
 
 	"github.com/zhikongming/stock/biz/dal"
 	"github.com/zhikongming/stock/biz/model"
@@ -12,8 +11,12 @@ import (
 )
 
 func TryToAddUnusualPredict(ctx context.Context, predicts []*model.UnusualPredict) error {
+	riskTypeMap, err := getStockRiskTypeMap(ctx, "")
+	if err != nil {
+		return err
+	}
 	for _, item := range predicts {
-		if strings.Contains(item.Name, "退市") || strings.Contains(item.Name, "ST") {
+		if isUnusualExcludedStock(riskTypeMap, item.Code, item.Name) {
 			continue
 		}
 		record, err := dal.GetUnusualPredictByDateTypeRule(ctx, item.Code, item.Date, int(item.PredictType), item.RuleType)
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	DefaultUnusualReconcileDays = 30
+	DefaultUnusualAccuracyDays  = 90
+)
+
+var UnusualForwardDays = []int{1, 3, 5, 10}
+
+// ReconcileUnusualPredict 核对次日异动预测在下一个交易日是否实际出现严重异常波动, 并记录实际异动是否被预测到
+func ReconcileUnusualPredict(ctx context.Context, req *model.ReconcileUnusualPredictReq) (*model.ReconcileUnusualPredictResp, error) {
+	startDate := req.StartDate
+	if startDate == "" {
+		startDate = utils.FormatDate(time.Now().AddDate(0, 0, -DefaultUnusualReconcileDays))
+	}
+	dateList, err := dal.GetUnusualPredictDateList(ctx, startDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	tradeDateList, err := dal.GetTradeDateList(ctx, startDate, "")
+	if err != nil {
+		return nil, err
+	}
+	ret := &model.ReconcileUnusualPredictResp{}
+	for _, date := range dateList {
+		idx := sort.Search(len(tradeDateList), func(i int) bool {
+			return tradeDateList[i].After(date)
+		})
+		// 下一个交易日的数据还没有同步
+		if idx >= len(tradeDateList) {
+			continue
+		}
+		triggerDate := tradeDateList[idx]
+		predictList, err := dal.GetUnusualPredictByDate(ctx, utils.FormatDate(date))
+		if err != nil {
+			return nil, err
+		}
+		noticeList, err := dal.GetUnusualStockByTypeEndDate(ctx, int(model.UnusualTypeSpecial), utils.FormatDate(triggerDate))
+		if err != nil {
+			return nil, err
+		}
+		resultList := buildUnusualPredictResultList(date, triggerDate, predictList, noticeList)
+		for _, result := range resultList {
+			if result.Source == model.UnusualResultSourcePredict {
+				ret.Predicted++
+				if result.Actual {
+					ret.Hit++
+				}
+			} else {
+				ret.Actual++
+			}
+		}
+		err = dal.SaveUnusualPredictResultList(ctx, date, resultList)
+		if err != nil {
+			return nil, err
+		}
+		ret.Dates++
+	}
+	return ret, nil
+}
+
+// buildUnusualPredictResultList 按股票和规则匹配次日异动预测与实际的严重异常波动
+// 公告中无法识别规则时, 只要该股票有任意规则的预测就认为命中
+func buildUnusualPredictResultList(date time.Time, triggerDate time.Time, predictList []*dal.UnusualPredict, noticeList []*dal.UnusualStock) []*dal.UnusualPredictResult {
+	// 股票实际触发的规则, 0表示无法识别
+	noticeRuleMap := make(map[string]map[int]bool)
+	noticeRuleTypeList := make([]int, 0, len(noticeList))
+	for _, notice := range noticeList {
+		ruleType := parseUnusualRuleType(notice.UnusualType + notice.UnusualReason)
+		noticeRuleTypeList = append(noticeRuleTypeList, ruleType)
+		if _, ok := noticeRuleMap[notice.Code]; !ok {
+			noticeRuleMap[notice.Code] = make(map[int]bool)
+		}
+		noticeRuleMap[notice.Code][ruleType] = true
+	}
+
+	resultList := make([]*dal.UnusualPredictResult, 0)
+	predictRuleMap := make(map[string]int)
+	predictKeyMap := make(map[string]bool)
+	for _, predict := range predictList {
+		if model.PredictType(predict.PredictType) != model.PredictTypeNextDayUnusual {
+			continue
+		}
+		key := predict.Code + "_" + utils.ToString(predict.RuleType)
+		if predictKeyMap[key] {
+			continue
+		}
+		predictKeyMap[key] = true
+		if _, ok := predictRuleMap[predict.Code]; !ok {
+			predictRuleMap[predict.Code] = predict.RuleType
+		}
+		ruleMap := noticeRuleMap[predict.Code]
+		resultList = append(resultList, &dal.UnusualPredictResult{
+			PredictDate: date,
+			TriggerDate: triggerDate,
+			Code:        predict.Code,
+			Name:        predict.Name,
+			Source:      model.UnusualResultSourcePredict,
+			RuleType:    predict.RuleType,
+			Predicted:   true,
+			Actual:      ruleMap[predict.RuleType] || ruleMap[0],
+		})
+	}
+	for i, notice := range noticeList {
+		ruleType := noticeRuleTypeList[i]
+		var predicted bool
+		if ruleType > 0 {
+			predicted = predictKeyMap[notice.Code+"_"+utils.ToString(ruleType)]
+		} else {
+			ruleType, predicted = predictRuleMap[notice.Code]
+		}
+		resultList = append(resultList, &dal.UnusualPredictResult{
+			PredictDate: date,
+			TriggerDate: triggerDate,
+			Code:        notice.Code,
+			Name:        notice.Name,
+			Source:      model.UnusualResultSourceNotice,
+			RuleType:    ruleType,
+			Predicted:   predicted,
+			Actual:      true,
+		})
+	}
+	return resultList
+}
+
+// parseUnusualRuleType 根据严重异常波动的原因识别对应的预测规则, 无法识别时返回0
+func parseUnusualRuleType(reason string) int {
+	switch {
+	case strings.Contains(reason, "-70%"):
+		return model.PredictRuleDeviation30Down
+	case strings.Contains(reason, "-50%"):
+		return model.PredictRuleDeviation10Down
+	case strings.Contains(reason, "200%"):
+		return model.PredictRuleDeviation30Up
+	case strings.Contains(reason, "100%"):
+		return model.PredictRuleDeviation10Up
+	case strings.Contains(reason, "4次"):
+		return model.PredictRuleMainSameDirection
+	case strings.Contains(reason, "3次"):
+		return model.PredictRuleStarSameDirection
+	default:
+		return 0
+	}
+}
+
+// GetUnusualPredictAccuracy 统计各规则异动预测的精确率和召回率, 以及预测和实际异动之后的走势
+func GetUnusualPredictAccuracy(ctx context.Context, req *model.GetUnusualPredictAccuracyReq) (*model.UnusualPredictAccuracyResp, error) {
+	startDate := req.StartDate
+	if startDate == "" {
+		startDate = utils.FormatDate(time.Now().AddDate(0, 0, -DefaultUnusualAccuracyDays))
+	}
+	resultList, err := dal.GetUnusualPredictResultList(ctx, startDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	ret := &model.UnusualPredictAccuracyResp{
+		StartDate:    startDate,
+		EndDate:      req.EndDate,
+		Total:        &model.UnusualPredictRuleStat{},
+		ByRule:       make([]*model.UnusualPredictRuleStat, 0),
+		AfterPredict: make([]*model.UnusualForwardReturnGroup, 0),
+		AfterNotice:  make([]*model.UnusualForwardReturnGroup, 0),
+	}
+	if len(resultList) == 0 {
+		return ret, nil
+	}
+	ret.StartDate = utils.FormatDate(resultList[0].PredictDate)
+	ret.EndDate = utils.FormatDate(resultList[len(resultList)-1].PredictDate)
+
+	ruleStatMap := make(map[int]*model.UnusualPredictRuleStat)
+	dateSet := make(map[time.Time]bool)
+	codeSet := make(map[string]bool)
+	for _, result := range resultList {
+		dateSet[result.PredictDate] = true
+		codeSet[result.Code] = true
+		stat, ok := ruleStatMap[result.RuleType]
+		if !ok {
+			stat = &model.UnusualPredictRuleStat{
+				RuleType: result.RuleType,
+				Rule:     model.GetUnusualPredictRuleDesc(result.RuleType),
+			}
+			ruleStatMap[result.RuleType] = stat
+			ret.ByRule = append(ret.ByRule, stat)
+		}
+		for _, s := range []*model.UnusualPredictRuleStat{stat, ret.Total} {
+			if result.Source == model.UnusualResultSourcePredict {
+				s.Predicted++
+				if result.Actual {
+					s.Hit++
+				}
+			} else {
+				s.Actual++
+			}
+		}
+	}
+	ret.Dates = len(dateSet)
+	// 召回率的分子为被预测到的实际异动数量
+	recallMap := make(map[int]int)
+	totalRecall := 0
+	for _, result := range resultList {
+		if result.Source == model.UnusualResultSourceNotice && result.Predicted {
+			recallMap[result.RuleType]++
+			totalRecall++
+		}
+	}
+	fillUnusualPredictRuleStat(ret.Total, totalRecall)
+	for _, stat := range ret.ByRule {
+		fillUnusualPredictRuleStat(stat, recallMap[stat.RuleType])
+	}
+	sort.Slice(ret.ByRule, func(i, j int) bool {
+		return ret.ByRule[i].RuleType < ret.ByRule[j].RuleType
+	})
+
+	// 预测和实际异动之后的走势
+	codeList := make([]string, 0, len(codeSet))
+	for code := range codeSet {
+		codeList = append(codeList, code)
+	}
+	priceMap, err := BatchGetStockPriceByDate(ctx, codeList, ret.StartDate, "")
+	if err != nil {
+		return nil, err
+	}
+	returnMap := make(map[string]map[int][]float64)
+	for _, result := range resultList {
+		var group string
+		var baseDate time.Time
+		switch {
+		case result.Source == model.UnusualResultSourcePredict && result.Actual:
+			group, baseDate = model.UnusualReturnGroupPredictHit, result.PredictDate
+		case result.Source == model.UnusualResultSourcePredict:
+			group, baseDate = model.UnusualReturnGroupPredictMiss, result.PredictDate
+		case result.Predicted:
+			group, baseDate = model.UnusualReturnGroupNoticePredicted, result.TriggerDate
+		default:
+			group, baseDate = model.UnusualReturnGroupNoticeUnpredicted, result.TriggerDate
+		}
+		if _, ok := returnMap[group]; !ok {
+			returnMap[group] = make(map[int][]float64)
+		}
+		priceList := priceMap[result.Code]
+		idx := sort.Search(len(priceList), func(i int) bool {
+			return !priceList[i].Date.Before(baseDate)
+		})
+		if idx >= len(priceList) || !priceList[idx].Date.Equal(baseDate) || priceList[idx].PriceClose <= 0 {
+			continue
+		}
+		for _, days := range UnusualForwardDays {
+			if idx+days < len(priceList) {
+				returnMap[group][days] = append(returnMap[group][days], (priceList[idx+days].PriceClose/priceList[idx].PriceClose-1)*100)
+			}
+		}
+	}
+	for _, group := range []string{model.UnusualReturnGroupPredictHit, model.UnusualReturnGroupPredictMiss} {
+		ret.AfterPredict = append(ret.AfterPredict, toUnusualForwardReturnGroup(group, returnMap[group]))
+	}
+	for _, group := range []string{model.UnusualReturnGroupNoticePredicted, model.UnusualReturnGroupNoticeUnpredicted} {
+		ret.AfterNotice = append(ret.AfterNotice, toUnusualForwardReturnGroup(group, returnMap[group]))
+	}
+	return ret, nil
+}
+
+func fillUnusualPredictRuleStat(stat *model.UnusualPredictRuleStat, recall int) {
+	if stat.Predicted > 0 {
+		stat.Precision = utils.Float64KeepDecimal(float64(stat.Hit)/float64(stat.Predicted)*100, 2)
+	}
+	if stat.Actual > 0 {
+		stat.Recall = utils.Float64KeepDecimal(float64(recall)/float64(stat.Actual)*100, 2)
+	}
+}
+
+func toUnusualForwardReturnGroup(group string, returnMap map[int][]float64) *model.UnusualForwardReturnGroup {
+	return &model.UnusualForwardReturnGroup{
+		Group:    group,
+		Horizons: toUnusualForwardReturnStatList(UnusualForwardDays, returnMap),
+	}
+}
+
+func toUnusualForwardReturnStatList(daysList []int, returnMap map[int][]float64) []*model.UnusualForwardReturnStat {
+	ret := make([]*model.UnusualForwardReturnStat, 0, len(daysList))
+	for _, days := range daysList {
+		returnList := returnMap[days]
+		stat := &model.UnusualForwardReturnStat{
+			Days:  days,
+			Count: len(returnList),
+		}
+		if len(returnList) > 0 {
+			up := 0
+			for _, r := range returnList {
+				if r > 0 {
+					up++
+				}
+			}
+			stat.AvgReturn = utils.Float64KeepDecimal(utils.ListFloat64Average(returnList), 2)
+			stat.UpRate = utils.Float64KeepDecimal(float64(up)/float64(len(returnList))*100, 2)
+		}
+		ret = append(ret, stat)
+	}
+	return ret
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+)
+
+func TestBuildUnusualPredictResultList(t *testing.T) {
+	nextDay := int(model.PredictTypeNextDayUnusual)
+	predictList := []*dal.UnusualPredict{
+		{Code: "SH600000", PredictType: nextDay, RuleType: model.PredictRuleDeviation10Up},
+		{Code: "SH600000", PredictType: nextDay, RuleType: model.PredictRuleMainSameDirection},
+		{Code: "SH600001", PredictType: nextDay, RuleType: model.PredictRuleDeviation10Up},
+		{Code: "SH600002", PredictType: nextDay, RuleType: model.PredictRuleDeviation10Up},
+		{Code: "SH600003", PredictType: int(model.PredictTypeTodayUnusual), RuleType: model.PredictRuleDeviation10Up},
+	}
+	noticeList := []*dal.UnusualStock{
+		// 规则相同
+		{Code: "SH600000", UnusualReason: "连续10个交易日内收盘价格涨幅偏离值累计达到100%"},
+		// 预测的是10日偏离值, 实际触发的是30日偏离值
+		{Code: "SH600001", UnusualReason: "连续30个交易日内收盘价格涨幅偏离值累计达到200%"},
+		// 无法识别规则时按股票匹配
+		{Code: "SH600002", UnusualReason: "其他"},
+		{Code: "SH600003", UnusualReason: "连续10个交易日内收盘价格涨幅偏离值累计达到100%"},
+	}
+	resultList := buildUnusualPredictResultList(time.Time{}, time.Time{}, predictList, noticeList)
+
+	type result struct {
+		predicted bool
+		actual    bool
+	}
+	resultMap := make(map[string]result)
+	for _, r := range resultList {
+		resultMap[fmt.Sprintf("%v_%s_%d", r.Source, r.Code, r.RuleType)] = result{predicted: r.Predicted, actual: r.Actual}
+	}
+	expected := map[string]result{
+		fmt.Sprintf("%v_SH600000_%d", model.UnusualResultSourcePredict, model.PredictRuleDeviation10Up):     {true, true},
+		fmt.Sprintf("%v_SH600000_%d", model.UnusualResultSourcePredict, model.PredictRuleMainSameDirection): {true, false},
+		fmt.Sprintf("%v_SH600001_%d", model.UnusualResultSourcePredict, model.PredictRuleDeviation10Up):     {true, false},
+		fmt.Sprintf("%v_SH600002_%d", model.UnusualResultSourcePredict, model.PredictRuleDeviation10Up):     {true, true},
+		fmt.Sprintf("%v_SH600000_%d", model.UnusualResultSourceNotice, model.PredictRuleDeviation10Up):      {true, true},
+		fmt.Sprintf("%v_SH600001_%d", model.UnusualResultSourceNotice, model.PredictRuleDeviation30Up):      {false, true},
+		fmt.Sprintf("%v_SH600002_%d", model.UnusualResultSourceNotice, model.PredictRuleDeviation10Up):      {true, true},
+		fmt.Sprintf("%v_SH600003_%d", model.UnusualResultSourceNotice, model.PredictRuleDeviation10Up):      {false, true},
+	}
+	if len(resultMap) != len(expected) {
+		t.Fatalf("result count = %d, want %d: %+v", len(resultMap), len(expected), resultMap)
+	}
+	for key, want := range expected {
+		if got, ok := resultMap[key]; !ok || got != want {
+			t.Errorf("%s = %+v, want %+v", key, got, want)
+		}
+	}
+}
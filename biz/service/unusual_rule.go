@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	// 异常波动: 连续3个交易日内收盘价涨跌幅偏离值累计达到阈值
+	UnusualAbnormalDays = 3
+	// 同向异常波动次数的统计周期
+	UnusualCountDays = 10
+	// 最长的偏离值统计周期为30个交易日, 需要多一天的价格计算涨跌幅
+	UnusualPriceDays = 31
+)
+
+// unusualBoardRule 不同板块的基准指数和异常波动规则
+type unusualBoardRule struct {
+	IndexCode         string
+	AbnormalThreshold float64
+	// 同向异常波动的规则类型和次数, 为0表示没有该规则
+	CountRuleType int
+	CountTimes    int
+}
+
+type unusualDeviationRule struct {
+	RuleType  int
+	Days      int
+	Threshold float64
+}
+
+var unusualDeviationRuleList = []*unusualDeviationRule{
+	{RuleType: model.PredictRuleDeviation10Up, Days: 10, Threshold: 100},
+	{RuleType: model.PredictRuleDeviation10Down, Days: 10, Threshold: -50},
+	{RuleType: model.PredictRuleDeviation30Up, Days: 30, Threshold: 200},
+	{RuleType: model.PredictRuleDeviation30Down, Days: 30, Threshold: -70},
+}
+
+func getUnusualBoardRule(code string) *unusualBoardRule {
+	number := utils.GetStockCodeNumber(code)
+	switch {
+	case strings.HasPrefix(number, "688") || strings.HasPrefix(number, "689"):
+		return &unusualBoardRule{IndexCode: "SH000688", AbnormalThreshold: 30, CountRuleType: model.PredictRuleStarSameDirection, CountTimes: 3}
+	case strings.HasPrefix(number, "30"):
+		return &unusualBoardRule{IndexCode: "SZ399006", AbnormalThreshold: 30, CountRuleType: model.PredictRuleStarSameDirection, CountTimes: 3}
+	case strings.HasPrefix(code, "BJ"):
+		return &unusualBoardRule{IndexCode: "BJ899050", AbnormalThreshold: 40}
+	case strings.HasPrefix(number, "60"):
+		return &unusualBoardRule{IndexCode: utils.ShanghaiCompositeIndex, AbnormalThreshold: 20, CountRuleType: model.PredictRuleMainSameDirection, CountTimes: 4}
+	case strings.HasPrefix(number, "00"):
+		return &unusualBoardRule{IndexCode: "SZ399001", AbnormalThreshold: 20, CountRuleType: model.PredictRuleMainSameDirection, CountTimes: 4}
+	default:
+		return nil
+	}
+}
+
+// GetLocalUnusualPredict 根据本地股价和基准指数计算偏离值, 判断当日是否触发严重异常波动以及次日触发需要的价格
+func GetLocalUnusualPredict(ctx context.Context, req *model.GetLocalUnusualPredictReq) (*model.GetLocalUnusualPredictResp, error) {
+	dateList, err := dal.GetLastNTradeDate(ctx, req.Date, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(dateList) == 0 {
+		return nil, errors.New("no trade date found")
+	}
+	date := utils.FormatDate(dateList[0])
+	stockCodeList, err := dal.GetAllStockCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	riskTypeMap, err := getStockRiskTypeMap(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	nameMap := make(map[string]string)
+	codeList := make([]string, 0, len(stockCodeList))
+	for _, stockCode := range stockCodeList {
+		if req.Code != "" && stockCode.CompanyCode != req.Code {
+			continue
+		}
+		// ST和退市股票的涨跌幅限制及异动规则不同, 与东方财富的数据保持一致不做计算
+		if isUnusualExcludedStock(riskTypeMap, stockCode.CompanyCode, stockCode.CompanyName) {
+			continue
+		}
+		nameMap[stockCode.CompanyCode] = stockCode.CompanyName
+		codeList = append(codeList, stockCode.CompanyCode)
+	}
+	priceMap, err := BatchGetLastNStockPrice(ctx, codeList, date, UnusualPriceDays)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &model.GetLocalUnusualPredictResp{
+		Date:  date,
+		Items: make([]*model.LocalUnusualPredict, 0),
+	}
+	indexChangeMap := make(map[string]map[string]float64)
+	for _, code := range codeList {
+		boardRule := getUnusualBoardRule(code)
+		priceList := priceMap[code]
+		if boardRule == nil || len(priceList) < 2 || utils.FormatDate(priceList[0].Date) != date {
+			continue
+		}
+		if _, ok := indexChangeMap[boardRule.IndexCode]; !ok {
+			changeMap, err := getIndexChangeMap(ctx, boardRule.IndexCode, dateList[0])
+			if err != nil {
+				return nil, err
+			}
+			indexChangeMap[boardRule.IndexCode] = changeMap
+		}
+		deviationList, ok := calDailyDeviationList(priceList, indexChangeMap[boardRule.IndexCode])
+		if !ok {
+			hlog.Warnf("skip unusual predict for %s, index %s change is incomplete", code, boardRule.IndexCode)
+			continue
+		}
+		current := priceList[0]
+		base := &model.UnusualPredict{
+			Date:       date,
+			Code:       code,
+			Name:       nameMap[code],
+			ChangeRate: utils.Float64KeepDecimal((current.PriceClose/priceList[1].PriceClose-1)*100, 2),
+		}
+		limitRate := (GetLimitUpRate(utils.GetStockCodeNumber(code)) - 1) * 100
+		itemList := calCountRulePredict(deviationList, boardRule, limitRate)
+		for _, rule := range unusualDeviationRuleList {
+			itemList = append(itemList, calDeviationRulePredict(deviationList, rule, limitRate)...)
+		}
+		for _, item := range itemList {
+			ret.Items = append(ret.Items, fillLocalUnusualPredict(item, base, boardRule, current.PriceClose))
+		}
+	}
+	sort.SliceStable(ret.Items, func(i, j int) bool {
+		if ret.Items[i].Code != ret.Items[j].Code {
+			return ret.Items[i].Code < ret.Items[j].Code
+		}
+		return ret.Items[i].RuleType < ret.Items[j].RuleType
+	})
+	return ret, nil
+}
+
+// calDailyDeviationList 计算每日的偏离值, 按日期正序, priceList为倒序数据
+// 统计周期按交易日计算, 某一天缺少指数涨跌幅或者收盘价时无法对齐, 返回false
+func calDailyDeviationList(priceList []*dal.StockPrice, indexChangeMap map[string]float64) ([]float64, bool) {
+	deviationList := make([]float64, 0, len(priceList))
+	for i := len(priceList) - 2; i >= 0; i-- {
+		indexChange, ok := indexChangeMap[utils.FormatDate(priceList[i].Date)]
+		if !ok || priceList[i+1].PriceClose <= 0 {
+			return nil, false
+		}
+		change := (priceList[i].PriceClose/priceList[i+1].PriceClose - 1) * 100
+		deviationList = append(deviationList, change-indexChange)
+	}
+	return deviationList, len(deviationList) > 0
+}
+
+func fillLocalUnusualPredict(item *model.LocalUnusualPredict, base *model.UnusualPredict, boardRule *unusualBoardRule, close float64) *model.LocalUnusualPredict {
+	item.Date = base.Date
+	item.Code = base.Code
+	item.Name = base.Name
+	item.ChangeRate = base.ChangeRate
+	item.IndexCode = boardRule.IndexCode
+	item.Close = close
+	item.Rule = model.GetUnusualPredictRuleDesc(item.RuleType)
+	item.DeviationRate = utils.Float64KeepDecimal(item.DeviationRate, 2)
+	if item.PredictType == model.PredictTypeNextDayUnusual {
+		item.TriggerPrice = utils.Float64KeepDecimal(close*(1+item.PredictRate/100), 2)
+		item.PredictRate = utils.Float64KeepDecimal(item.PredictRate, 2)
+	}
+	return item
+}
+
+// getIndexChangeMap 获取基准指数截止到date的每日涨跌幅
+func getIndexChangeMap(ctx context.Context, indexCode string, date time.Time) (map[string]float64, error) {
+	client := NewEastMoneyClient()
+	dailyData, err := client.GetRemoteStockDaily(ctx, indexCode, date)
+	if err != nil {
+		return nil, err
+	}
+	if dailyData == nil {
+		return nil, fmt.Errorf("index %s data not found", indexCode)
+	}
+	ret := make(map[string]float64)
+	for _, item := range model.TransferDatePriceToPriceTrend(dailyData.ToDatePriceList()) {
+		ret[item.DateString] = item.Diff
+	}
+	return ret, nil
+}
+
+// calDeviationRulePredict 计算偏离值累计规则, 统计周期内以今天结束的任意连续交易日的累计偏离值达到阈值即触发
+func calDeviationRulePredict(deviationList []float64, rule *unusualDeviationRule, limitRate float64) []*model.LocalUnusualPredict {
+	ret := make([]*model.LocalUnusualPredict, 0)
+	up := rule.Threshold > 0
+	current := calBestSuffixSum(deviationList, rule.Days, up)
+	if (up && current >= rule.Threshold) || (!up && current <= rule.Threshold) {
+		ret = append(ret, &model.LocalUnusualPredict{
+			UnusualPredict: model.UnusualPredict{
+				PredictType:   model.PredictTypeTodayUnusual,
+				DeviationDay:  rule.Days,
+				DeviationRate: current,
+				RuleType:      rule.RuleType,
+			},
+		})
+	}
+	// 次日的统计周期包含今天在内的前Days-1个交易日
+	need := rule.Threshold - calBestSuffixSum(deviationList, rule.Days-1, up)
+	if (up && need <= limitRate) || (!up && need >= -limitRate) {
+		ret = append(ret, &model.LocalUnusualPredict{
+			UnusualPredict: model.UnusualPredict{
+				PredictType:   model.PredictTypeNextDayUnusual,
+				DeviationDay:  rule.Days,
+				DeviationRate: current,
+				PredictRate:   need,
+				RuleType:      rule.RuleType,
+			},
+		})
+	}
+	return ret
+}
+
+// calCountRulePredict 计算同向异常波动次数规则, 统计周期内第CountTimes次同向异常波动当天触发
+func calCountRulePredict(deviationList []float64, boardRule *unusualBoardRule, limitRate float64) []*model.LocalUnusualPredict {
+	ret := make([]*model.LocalUnusualPredict, 0)
+	n := len(deviationList)
+	if boardRule.CountRuleType == 0 || n < UnusualCountDays {
+		return ret
+	}
+	eventList := calAbnormalEventList(deviationList, boardRule.AbnormalThreshold)
+	// 上一次异常波动之后重新开始累计偏离值
+	segmentStart := 0
+	for i := n - 1; i >= 0; i-- {
+		if eventList[i] != 0 {
+			segmentStart = i + 1
+			break
+		}
+	}
+	for _, direction := range []int{1, -1} {
+		up := direction > 0
+		countToday := 0
+		countPrev := 0
+		for i := n - UnusualCountDays; i < n; i++ {
+			if eventList[i] == direction {
+				countToday++
+				if i > n-UnusualCountDays {
+					countPrev++
+				}
+			}
+		}
+		if eventList[n-1] == direction && countToday >= boardRule.CountTimes {
+			ret = append(ret, &model.LocalUnusualPredict{
+				UnusualPredict: model.UnusualPredict{
+					PredictType:   model.PredictTypeTodayUnusual,
+					DeviationDay:  UnusualCountDays,
+					DeviationRate: calBestSuffixSum(deviationList, UnusualAbnormalDays, up),
+					RuleType:      boardRule.CountRuleType,
+				},
+				Count: countToday,
+			})
+		}
+		if countPrev != boardRule.CountTimes-1 {
+			continue
+		}
+		segment := deviationList[max(segmentStart, n-UnusualAbnormalDays+1):]
+		threshold := boardRule.AbnormalThreshold * float64(direction)
+		need := threshold - calBestSuffixSum(segment, UnusualAbnormalDays-1, up)
+		if (up && need <= limitRate) || (!up && need >= -limitRate) {
+			ret = append(ret, &model.LocalUnusualPredict{
+				UnusualPredict: model.UnusualPredict{
+					PredictType:   model.PredictTypeNextDayUnusual,
+					DeviationDay:  UnusualCountDays,
+					DeviationRate: calBestSuffixSum(segment, UnusualAbnormalDays-1, up),
+					PredictRate:   need,
+					RuleType:      boardRule.CountRuleType,
+				},
+				Count: countPrev,
+			})
+		}
+	}
+	return ret
+}
+
+// calAbnormalEventList 标记每天是否出现异常波动, 1为上涨, -1为下跌; 出现异常波动后从次日重新累计
+func calAbnormalEventList(deviationList []float64, threshold float64) []int {
+	ret := make([]int, len(deviationList))
+	start := 0
+	for t := range deviationList {
+		sum := 0.0
+		for k := t; k >= max(start, t-UnusualAbnormalDays+1); k-- {
+			sum += deviationList[k]
+			if sum >= threshold {
+				ret[t] = 1
+				break
+			}
+			if sum <= -threshold {
+				ret[t] = -1
+				break
+			}
+		}
+		if ret[t] != 0 {
+			start = t + 1
+		}
+	}
+	return ret
+}
+
+// calBestSuffixSum 计算以最后一天结束、长度不超过maxDays的累计值中的最大值(up)或最小值, 不包含任何一天时为0
+func calBestSuffixSum(valueList []float64, maxDays int, up bool) float64 {
+	best := 0.0
+	sum := 0.0
+	for k := len(valueList) - 1; k >= 0 && k >= len(valueList)-maxDays; k-- {
+		sum += valueList[k]
+		if (up && sum > best) || (!up && sum < best) {
+			best = sum
+		}
+	}
+	return best
+}
+
+// CheckUnusualPredict 对比本地计算的异动预测与东方财富的异动预测
+func CheckUnusualPredict(ctx context.Context, req *model.CheckUnusualPredictReq) (*model.CheckUnusualPredictResp, error) {
+	local, err := GetLocalUnusualPredict(ctx, &model.GetLocalUnusualPredictReq{Date: req.Date})
+	if err != nil {
+		return nil, err
+	}
+	remoteList, err := dal.GetUnusualPredictByDate(ctx, local.Date)
+	if err != nil {
+		return nil, err
+	}
+	ret := &model.CheckUnusualPredictResp{
+		Date:        local.Date,
+		LocalCount:  len(local.Items),
+		RemoteCount: len(remoteList),
+		Matched:     make([]*model.UnusualPredictCheckItem, 0),
+		LocalOnly:   make([]*model.LocalUnusualPredict, 0),
+		RemoteOnly:  make([]*model.UnusualPredict, 0),
+	}
+	remoteMap := make(map[string]*dal.UnusualPredict)
+	for _, remote := range remoteList {
+		remoteMap[fmt.Sprintf("%s_%d_%d", remote.Code, remote.PredictType, remote.RuleType)] = remote
+	}
+	matchedKeyMap := make(map[string]bool)
+	for _, item := range local.Items {
+		key := fmt.Sprintf("%s_%d_%d", item.Code, item.PredictType, item.RuleType)
+		remote, ok := remoteMap[key]
+		if !ok {
+			ret.LocalOnly = append(ret.LocalOnly, item)
+			continue
+		}
+		matchedKeyMap[key] = true
+		ret.Matched = append(ret.Matched, &model.UnusualPredictCheckItem{
+			Code:                item.Code,
+			Name:                item.Name,
+			PredictType:         item.PredictType,
+			RuleType:            item.RuleType,
+			LocalDeviationRate:  item.DeviationRate,
+			RemoteDeviationRate: remote.DeviationRate,
+			LocalPredictRate:    item.PredictRate,
+			RemotePredictRate:   remote.PredictRate,
+		})
+	}
+	for key, remote := range remoteMap {
+		if matchedKeyMap[key] {
+			continue
+		}
+		ret.RemoteOnly = append(ret.RemoteOnly, &model.UnusualPredict{
+			Date:          utils.FormatDate(utils.ParseDateWithRegion(remote.Date)),
+			Code:          remote.Code,
+			Name:          remote.Name,
+			PredictType:   model.PredictType(remote.PredictType),
+			ChangeRate:    remote.ChangeRate,
+			DeviationDay:  remote.DeviationDay,
+			DeviationRate: remote.DeviationRate,
+			PredictRate:   remote.PredictRate,
+			RuleType:      remote.RuleType,
+			Rule:          model.GetUnusualPredictRuleDesc(remote.RuleType),
+		})
+	}
+	sort.SliceStable(ret.RemoteOnly, func(i, j int) bool {
+		return ret.RemoteOnly[i].Code < ret.RemoteOnly[j].Code
+	})
+	return ret, nil
+}
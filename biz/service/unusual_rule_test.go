@@ -0,0 +1,85 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/zhikongming/stock/biz/model"
+)
+
+func TestCalAbnormalEventList(t *testing.T) {
+	// 第3天累计偏离值达到20%, 之后从第4天重新累计, 第4~6天累计达到20%
+	deviationList := []float64{5, 6, 10, 8, 7, 6, -1}
+	eventList := calAbnormalEventList(deviationList, 20)
+	expected := []int{0, 0, 1, 0, 0, 1, 0}
+	for i := range expected {
+		if eventList[i] != expected[i] {
+			t.Fatalf("event %d = %d, want %d", i, eventList[i], expected[i])
+		}
+	}
+}
+
+func TestCalDeviationRulePredict(t *testing.T) {
+	// 前9天累计偏离值为90%, 次日需要再偏离10%
+	deviationList := []float64{-3, 10, 10, 10, 10, 10, 10, 10, 10, 10}
+	rule := &unusualDeviationRule{RuleType: model.PredictRuleDeviation10Up, Days: 10, Threshold: 100}
+	itemList := calDeviationRulePredict(deviationList, rule, 10)
+	if len(itemList) != 1 || itemList[0].PredictType != model.PredictTypeNextDayUnusual {
+		t.Fatalf("unexpected predict list: %+v", itemList)
+	}
+	if itemList[0].PredictRate != 10 {
+		t.Fatalf("predict rate = %v, want 10", itemList[0].PredictRate)
+	}
+}
+
+func TestCalCountRulePredict(t *testing.T) {
+	boardRule := &unusualBoardRule{AbnormalThreshold: 20, CountRuleType: model.PredictRuleMainSameDirection, CountTimes: 4}
+	testCases := []struct {
+		name          string
+		deviationList []float64
+		predictType   model.PredictType
+		count         int
+		predictRate   float64
+	}{
+		{
+			// 第2、4、6天和今天各出现一次上涨异常波动, 今天是10个交易日内的第4次
+			name:          "today",
+			deviationList: []float64{0, 20, 0, 20, 0, 20, 0, 0, 0, 20},
+			predictType:   model.PredictTypeTodayUnusual,
+			count:         4,
+		},
+		{
+			// 近9个交易日已经有3次, 上一次异常波动之后累计了11%, 次日再偏离9%触发
+			name:          "next day",
+			deviationList: []float64{0, 20, 0, 20, 0, 20, 0, 0, 5, 6},
+			predictType:   model.PredictTypeNextDayUnusual,
+			count:         3,
+			predictRate:   9,
+		},
+		{
+			// 第1天的异常波动在次日移出统计周期
+			name:          "out of window",
+			deviationList: []float64{20, 0, 20, 0, 20, 0, 0, 0, 5, 6},
+		},
+		{
+			name:          "not enough days",
+			deviationList: []float64{20, 20, 20, 20},
+		},
+	}
+	for _, tc := range testCases {
+		itemList := calCountRulePredict(tc.deviationList, boardRule, 10)
+		if tc.count == 0 {
+			if len(itemList) != 0 {
+				t.Errorf("%s: unexpected predict list: %+v", tc.name, itemList)
+			}
+			continue
+		}
+		if len(itemList) != 1 {
+			t.Errorf("%s: predict list length = %d, want 1", tc.name, len(itemList))
+			continue
+		}
+		item := itemList[0]
+		if item.PredictType != tc.predictType || item.Count != tc.count || item.PredictRate != tc.predictRate {
+			t.Errorf("%s: predict = %+v", tc.name, item)
+		}
+	}
+}
@@ -2,22 +2,38 @@ package service
 
 import (
 	"context"
+	"errors"
 	"sort"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/zhikongming/stock/biz/dal"
 	"github.com/zhikongming/stock/biz/model"
 	"github.com/zhikongming/stock/utils"
 )
 
+const (
+	// 异常记录前后价格走势的交易日数量
+	UnusualPathBefore = 5
+	UnusualPathAfter  = 20
+	// 全市场严重异常波动统计的自然日数量
+	UnusualMarketStatDays = 365
+)
+
+var UnusualHistoryForwardDays = []int{1, 5, 10, 20}
+
 func TryToAddUnusualStock(ctx context.Context, dataList []*model.UnusualStock) error {
+	riskTypeMap, err := getStockRiskTypeMap(ctx, "")
+	if err != nil {
+		return err
+	}
 	// 主要是去除重复的数据
 	for _, item := range dataList {
 		// 去除掉板块
 		if !utils.IsStockCodeWithPrefix(item.Code) {
 			continue
 		}
-		if strings.Contains(item.Name, "退市") || strings.Contains(item.Name, "ST") {
+		if isUnusualExcludedStock(riskTypeMap, item.Code, item.Name) {
 			continue
 		}
 		record, err := dal.GetUnusualStockByCodeTypeEndDate(ctx, item.Code, int(item.Type), item.EndDate)
@@ -71,6 +87,8 @@ func CreateUnusualStock(ctx context.Context) error {
 	if err := TryToAddUnusualStock(ctx, marketRiskStocks); err != nil {
 		return err
 	}
+	// 新增的严重异常波动需要重新统计
+	defaultUnusualSeriousStatCache.reset()
 	return nil
 }
 
@@ -88,22 +106,187 @@ func GetUnusualStockList(ctx context.Context) ([]*model.UnusualStock, error) {
 		if utils.IsDateGreaterThan(utils.GetDateOfToday(), endDate) {
 			continue
 		}
-		noticeDate := utils.FormatDate(utils.ParseDateWithRegion(stock.NoticeDate))
-		if noticeDate == "0001-01-01" {
-			noticeDate = ""
-		}
-		startDate := utils.FormatDate(utils.ParseDateWithRegion(stock.StartDate))
-		result = append(result, &model.UnusualStock{
-			Code:          stock.Code,
-			Name:          stock.Name,
-			Type:          model.UnusualType(stock.Type),
-			StartDate:     startDate,
-			EndDate:       endDate,
-			NoticeDate:    noticeDate,
-			UnusualType:   stock.UnusualType,
-			UnusualReason: stock.UnusualReason,
-		})
+		result = append(result, toUnusualStock(stock))
 	}
 	sort.Sort(model.UnusualStockSorter(result))
 	return result, nil
 }
+
+// GetUnusualStockHistory 获取股票全部的异常记录以及前后的价格走势, 并统计严重异常波动之后的收益, 全市场的统计见GetUnusualSeriousStat
+func GetUnusualStockHistory(ctx context.Context, req *model.GetUnusualStockHistoryReq) (*model.GetUnusualStockHistoryResp, error) {
+	if req.Code == "" {
+		return nil, errors.New("code is required")
+	}
+	stockCode, err := dal.GetStockCodeByCode(ctx, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	recordList, err := dal.GetUnusualStockListByCode(ctx, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	ret := &model.GetUnusualStockHistoryResp{
+		Code:    req.Code,
+		Records: make([]*model.UnusualStockHistoryItem, 0, len(recordList)),
+	}
+	if stockCode != nil {
+		ret.Name = stockCode.CompanyName
+	}
+	anchorList := make([]time.Time, 0, len(recordList))
+	var earliest time.Time
+	for _, record := range recordList {
+		anchor := utils.ParseDateWithRegion(record.EndDate)
+		if model.UnusualType(record.Type) == model.UnusualTypeMarketRisk {
+			anchor = utils.ParseDateWithRegion(record.StartDate)
+		}
+		anchor = utils.ParseDate(utils.FormatDate(anchor))
+		anchorList = append(anchorList, anchor)
+		if earliest.IsZero() || anchor.Before(earliest) {
+			earliest = anchor
+		}
+		if ret.Name == "" {
+			ret.Name = record.Name
+		}
+	}
+	var priceList []*dal.StockPrice
+	if len(recordList) > 0 {
+		// 按交易日向前取, 长假前后也能保证基准日之前有足够的交易日
+		dateList, err := dal.GetLastNTradeDate(ctx, utils.FormatDate(earliest), UnusualPathBefore+1)
+		if err != nil {
+			return nil, err
+		}
+		startDate := earliest
+		if len(dateList) > 0 {
+			startDate = dateList[len(dateList)-1]
+		}
+		priceMap, err := BatchGetStockPriceByDate(ctx, []string{req.Code}, utils.FormatDate(startDate), "")
+		if err != nil {
+			return nil, err
+		}
+		priceList = priceMap[req.Code]
+	}
+	seriousReturnMap := make(map[int][]float64)
+	for idx, record := range recordList {
+		item := &model.UnusualStockHistoryItem{
+			UnusualStock: *toUnusualStock(record),
+			AnchorDate:   utils.FormatDate(anchorList[idx]),
+			PricePath:    make([]*model.UnusualPricePoint, 0),
+		}
+		anchorIdx := findUnusualAnchorIndex(priceList, anchorList[idx])
+		if anchorIdx >= 0 {
+			base := priceList[anchorIdx].PriceClose
+			for i := max(anchorIdx-UnusualPathBefore, 0); i <= anchorIdx+UnusualPathAfter && i < len(priceList); i++ {
+				item.PricePath = append(item.PricePath, &model.UnusualPricePoint{
+					Offset: i - anchorIdx,
+					Date:   utils.FormatDate(priceList[i].Date),
+					Close:  priceList[i].PriceClose,
+					Change: utils.Float64KeepDecimal((priceList[i].PriceClose/base-1)*100, 2),
+				})
+			}
+			if model.UnusualType(record.Type) == model.UnusualTypeSpecial {
+				appendUnusualForwardReturn(seriousReturnMap, priceList, anchorIdx)
+			}
+		}
+		ret.Records = append(ret.Records, item)
+	}
+	ret.SeriousStat = toUnusualForwardReturnStatList(UnusualHistoryForwardDays, seriousReturnMap)
+
+	return ret, nil
+}
+
+// unusualSeriousStatCache 全市场的统计需要加载一年的数据, 按天缓存, 同步异常记录后失效
+type unusualSeriousStatCache struct {
+	sync.Mutex
+	date string
+	resp *model.GetUnusualSeriousStatResp
+}
+
+var defaultUnusualSeriousStatCache = &unusualSeriousStatCache{}
+
+func (c *unusualSeriousStatCache) reset() {
+	c.Lock()
+	defer c.Unlock()
+	c.date = ""
+	c.resp = nil
+}
+
+// GetUnusualSeriousStat 统计全市场最近一年严重异常波动之后的收益, 以触发日收盘价为基准
+func GetUnusualSeriousStat(ctx context.Context) (*model.GetUnusualSeriousStatResp, error) {
+	today := utils.GetDateOfToday()
+	defaultUnusualSeriousStatCache.Lock()
+	defer defaultUnusualSeriousStatCache.Unlock()
+	if defaultUnusualSeriousStatCache.date == today {
+		return defaultUnusualSeriousStatCache.resp, nil
+	}
+
+	startDate := utils.FormatDate(time.Now().AddDate(0, 0, -UnusualMarketStatDays))
+	seriousList, err := dal.GetUnusualStockListByType(ctx, int(model.UnusualTypeSpecial), startDate)
+	if err != nil {
+		return nil, err
+	}
+	codeSet := make(map[string]bool)
+	codeList := make([]string, 0)
+	for _, record := range seriousList {
+		if !codeSet[record.Code] {
+			codeSet[record.Code] = true
+			codeList = append(codeList, record.Code)
+		}
+	}
+	priceMap, err := BatchGetStockPriceByDate(ctx, codeList, startDate, "")
+	if err != nil {
+		return nil, err
+	}
+	returnMap := make(map[int][]float64)
+	for _, record := range seriousList {
+		anchor := utils.ParseDate(utils.FormatDate(utils.ParseDateWithRegion(record.EndDate)))
+		anchorIdx := findUnusualAnchorIndex(priceMap[record.Code], anchor)
+		if anchorIdx >= 0 {
+			appendUnusualForwardReturn(returnMap, priceMap[record.Code], anchorIdx)
+		}
+	}
+	ret := &model.GetUnusualSeriousStatResp{
+		StartDate: startDate,
+		Count:     len(seriousList),
+		Stat:      toUnusualForwardReturnStatList(UnusualHistoryForwardDays, returnMap),
+	}
+	defaultUnusualSeriousStatCache.date = today
+	defaultUnusualSeriousStatCache.resp = ret
+	return ret, nil
+}
+
+func toUnusualStock(stock *dal.UnusualStock) *model.UnusualStock {
+	noticeDate := utils.FormatDate(utils.ParseDateWithRegion(stock.NoticeDate))
+	if noticeDate == "0001-01-01" {
+		noticeDate = ""
+	}
+	return &model.UnusualStock{
+		Code:          stock.Code,
+		Name:          stock.Name,
+		Type:          model.UnusualType(stock.Type),
+		StartDate:     utils.FormatDate(utils.ParseDateWithRegion(stock.StartDate)),
+		EndDate:       utils.FormatDate(utils.ParseDateWithRegion(stock.EndDate)),
+		NoticeDate:    noticeDate,
+		UnusualType:   stock.UnusualType,
+		UnusualReason: stock.UnusualReason,
+	}
+}
+
+// findUnusualAnchorIndex 找到基准日当天或之后的第一个交易日, priceList为正序
+func findUnusualAnchorIndex(priceList []*dal.StockPrice, anchor time.Time) int {
+	anchorDate := utils.FormatDate(anchor)
+	idx := sort.Search(len(priceList), func(i int) bool {
+		return utils.FormatDate(priceList[i].Date) >= anchorDate
+	})
+	if idx >= len(priceList) || priceList[idx].PriceClose <= 0 {
+		return -1
+	}
+	return idx
+}
+
+func appendUnusualForwardReturn(returnMap map[int][]float64, priceList []*dal.StockPrice, anchorIdx int) {
+	for _, days := range UnusualHistoryForwardDays {
+		if anchorIdx+days < len(priceList) {
+			returnMap[days] = append(returnMap[days], (priceList[anchorIdx+days].PriceClose/priceList[anchorIdx].PriceClose-1)*100)
+		}
+	}
+}
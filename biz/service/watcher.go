@@ -162,3 +162,142 @@ func ToModel(ctx context.Context, watch *dal.Watcher) (*model.Watcher, error) {
 func DeleteWatcher(ctx context.Context, req *model.DeleteWatcherReq) error {
 	return dal.DeleteWatcher(ctx, uint(req.ID))
 }
+
+func AddWatcherRule(ctx context.Context, req *model.AddWatcherRuleReq) error {
+	watcher, err := dal.GetWatcher(ctx, uint(req.WatcherID))
+	if err != nil {
+		return err
+	}
+	if watcher == nil {
+		return fmt.Errorf("watcher not found: %d", req.WatcherID)
+	}
+	switch req.RuleType {
+	case model.WatcherRuleTypeChange, model.WatcherRuleTypePriceCross, model.WatcherRuleTypeVolumeRatio:
+	default:
+		return fmt.Errorf("rule type is invalid: %d", req.RuleType)
+	}
+	if req.Threshold <= 0 {
+		return errors.New("threshold must be positive")
+	}
+
+	// 规则的股票必须在盯盘列表中
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if utils.IsStockNumber(code) {
+		code = utils.GetFullStockCodeOfNumber(code)
+	}
+	if code != "" && !utils.In(code, strings.Split(watcher.Stocks, ",")) {
+		return fmt.Errorf("code not in watcher: %s", req.Code)
+	}
+	if code == "" && req.RuleType == model.WatcherRuleTypePriceCross {
+		return errors.New("code is required for price cross rule")
+	}
+	cooldown := req.Cooldown
+	if cooldown <= 0 {
+		cooldown = model.DefaultWatcherRuleCooldown
+	}
+
+	rule := &dal.WatcherRule{
+		WatcherID:  watcher.ID,
+		Code:       code,
+		RuleType:   int(req.RuleType),
+		Threshold:  req.Threshold,
+		Cooldown:   cooldown,
+		CreateTime: time.Now(),
+		Status:     dal.StatusEnabled,
+	}
+	return dal.CreateWatcherRule(ctx, rule)
+}
+
+func GetWatcherRules(ctx context.Context, req *model.GetWatcherRulesReq) ([]*model.WatcherRule, error) {
+	ruleList, err := dal.GetWatcherRuleList(ctx, uint(req.WatcherID))
+	if err != nil {
+		return nil, err
+	}
+	codeList := make([]string, 0)
+	for _, rule := range ruleList {
+		if rule.Code != "" {
+			codeList = append(codeList, rule.Code)
+		}
+	}
+	nameMap, err := getWatcherCodeNameMap(ctx, codeList)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*model.WatcherRule, 0, len(ruleList))
+	for _, rule := range ruleList {
+		ret = append(ret, &model.WatcherRule{
+			ID:           rule.ID,
+			WatcherID:    rule.WatcherID,
+			Code:         rule.Code,
+			Name:         nameMap[rule.Code],
+			RuleType:     model.WatcherRuleType(rule.RuleType),
+			RuleTypeName: model.WatcherRuleType(rule.RuleType).String(),
+			Threshold:    rule.Threshold,
+			Cooldown:     rule.Cooldown,
+		})
+	}
+	return ret, nil
+}
+
+func DeleteWatcherRule(ctx context.Context, req *model.DeleteWatcherRuleReq) error {
+	return dal.DeleteWatcherRule(ctx, uint(req.ID))
+}
+
+func GetWatcherAlerts(ctx context.Context, req *model.GetWatcherAlertsReq) ([]*model.WatcherAlert, error) {
+	date := req.Date
+	if date == "" {
+		date = utils.GetDateOfToday()
+	}
+	start, err := time.ParseInLocation(utils.DateFormat, date, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("date is invalid: %s", req.Date)
+	}
+	alertList, err := dal.GetWatcherAlertList(ctx, uint(req.WatcherID), start, start.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	watcherList, err := dal.GetWatchers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	watcherNameMap := make(map[uint]string)
+	for _, watcher := range watcherList {
+		watcherNameMap[watcher.ID] = watcher.Name
+	}
+
+	ret := make([]*model.WatcherAlert, 0, len(alertList))
+	for _, alert := range alertList {
+		ret = append(ret, toWatcherAlert(alert, watcherNameMap[alert.WatcherID]))
+	}
+	return ret, nil
+}
+
+// getWatcherCodeNameMap 获取股票和板块代码对应的名称
+func getWatcherCodeNameMap(ctx context.Context, codeList []string) (map[string]string, error) {
+	nameMap := make(map[string]string)
+	stockCodeList := make([]string, 0)
+	for _, code := range utils.Uniq(codeList) {
+		if utils.IsIndustryCode(code) {
+			industry, err := dal.GetStockIndustry(ctx, code)
+			if err != nil {
+				return nil, err
+			}
+			if industry != nil {
+				nameMap[code] = industry.Name
+			}
+		} else {
+			stockCodeList = append(stockCodeList, code)
+		}
+	}
+	if len(stockCodeList) > 0 {
+		stockList, err := dal.GetStockCodeByCodeList(ctx, stockCodeList)
+		if err != nil {
+			return nil, err
+		}
+		for _, stock := range stockList {
+			nameMap[stock.CompanyCode] = stock.CompanyName
+		}
+	}
+	return nameMap, nil
+}
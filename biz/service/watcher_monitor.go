@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	// 同一股票每小时最多提醒的次数, 所有规则和盯盘合并计算
+	MaxWatcherAlertPerStockHour = 4
+	// 开盘后前几分钟的量比波动较大, 不参与判断
+	WatcherVolumeRatioWarmupMinutes = 5
+)
+
+// watcherMonitor 盯盘轮询的状态, 只保存在内存中, 服务重启后重新开始计算
+type watcherMonitor struct {
+	sync.Mutex
+	date string
+	// 上一次轮询的价格, 用于判断价格穿越
+	lastPriceMap map[string]float64
+	// 规则和股票最近一次提醒的时间
+	ruleAlertMap map[string]time.Time
+	// 股票最近一小时内的提醒时间
+	stockAlertMap map[string][]time.Time
+}
+
+var defaultWatcherMonitor = &watcherMonitor{}
+
+func (m *watcherMonitor) reset(date string) {
+	m.date = date
+	m.lastPriceMap = make(map[string]float64)
+	m.ruleAlertMap = make(map[string]time.Time)
+	m.stockAlertMap = make(map[string][]time.Time)
+}
+
+// allowAlert 检查规则的提醒间隔和股票每小时的提醒次数, 允许时记录本次提醒
+func (m *watcherMonitor) allowAlert(ruleKey string, code string, cooldown time.Duration, now time.Time) bool {
+	if last, ok := m.ruleAlertMap[ruleKey]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+	timeList := make([]time.Time, 0)
+	for _, t := range m.stockAlertMap[code] {
+		if now.Sub(t) < time.Hour {
+			timeList = append(timeList, t)
+		}
+	}
+	if len(timeList) >= MaxWatcherAlertPerStockHour {
+		m.stockAlertMap[code] = timeList
+		return false
+	}
+	m.ruleAlertMap[ruleKey] = now
+	m.stockAlertMap[code] = append(timeList, now)
+	return true
+}
+
+// RunWatcherMonitor 轮询盯盘股票的实时行情, 按规则发送提醒
+func RunWatcherMonitor(ctx context.Context, req *model.RunWatcherMonitorReq) (*model.RunWatcherMonitorResp, error) {
+	resp := &model.RunWatcherMonitorResp{
+		Alerts: make([]*model.WatcherAlert, 0),
+	}
+	if !req.Force && !utils.IsTradingTime() {
+		return resp, nil
+	}
+	// 上一次轮询还没有结束时跳过, 避免重复提醒
+	if !defaultWatcherMonitor.TryLock() {
+		resp.Skipped = true
+		return resp, nil
+	}
+	defer defaultWatcherMonitor.Unlock()
+
+	today := utils.GetDateOfToday()
+	if defaultWatcherMonitor.date != today {
+		defaultWatcherMonitor.reset(today)
+	}
+
+	watcherList, err := dal.GetWatchers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ruleList, err := dal.GetWatcherRuleList(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	watcherMap := make(map[uint]*dal.Watcher)
+	for _, watcher := range watcherList {
+		watcherMap[watcher.ID] = watcher
+	}
+	codeList := make([]string, 0)
+	ruleCodeMap := make(map[uint][]string)
+	for _, rule := range ruleList {
+		watcher, ok := watcherMap[rule.WatcherID]
+		if !ok {
+			continue
+		}
+		ruleCodeMap[rule.ID] = getWatcherRuleCodeList(watcher, rule)
+		codeList = append(codeList, ruleCodeMap[rule.ID]...)
+	}
+	codeList = utils.Uniq(codeList)
+	if len(codeList) == 0 {
+		return resp, nil
+	}
+
+	quoteList, err := NewEastMoneyClient().GetRemoteStockQuote(ctx, codeList)
+	if err != nil {
+		return nil, err
+	}
+	quoteMap := make(map[string]*model.StockQuote)
+	for _, quote := range quoteList {
+		// 停牌或者节假日拿到的是之前的行情, 不参与判断
+		if quote.Price <= 0 {
+			continue
+		}
+		if !req.Force && utils.TimestampToDate(quote.UpdateTime) != today {
+			continue
+		}
+		quoteMap[quote.Code] = quote
+	}
+	resp.QuoteCount = len(quoteMap)
+
+	now := time.Now()
+	volumeRatioReady := !utils.IsBeforeHourMinute(9, 30+WatcherVolumeRatioWarmupMinutes)
+	alertList := make([]*dal.WatcherAlert, 0)
+	for _, rule := range ruleList {
+		watcher, ok := watcherMap[rule.WatcherID]
+		if !ok {
+			continue
+		}
+		cooldown := rule.Cooldown
+		if cooldown <= 0 {
+			cooldown = model.DefaultWatcherRuleCooldown
+		}
+		for _, code := range ruleCodeMap[rule.ID] {
+			quote, ok := quoteMap[code]
+			if !ok {
+				continue
+			}
+			message, hit := checkWatcherRule(rule, quote, defaultWatcherMonitor.lastPriceMap[code], volumeRatioReady)
+			if !hit {
+				continue
+			}
+			ruleKey := fmt.Sprintf("%d|%s", rule.ID, code)
+			if !defaultWatcherMonitor.allowAlert(ruleKey, code, time.Duration(cooldown)*time.Minute, now) {
+				resp.Suppressed++
+				continue
+			}
+			alert := &dal.WatcherAlert{
+				WatcherID: watcher.ID,
+				RuleID:    rule.ID,
+				Code:      code,
+				Name:      quote.Name,
+				RuleType:  rule.RuleType,
+				Price:     quote.Price,
+				Percent:   quote.Percent,
+				Message:   message,
+				AlertTime: now,
+			}
+			alertList = append(alertList, alert)
+			resp.Alerts = append(resp.Alerts, toWatcherAlert(alert, watcher.Name))
+		}
+	}
+	for code, quote := range quoteMap {
+		defaultWatcherMonitor.lastPriceMap[code] = quote.Price
+	}
+
+	if len(alertList) == 0 {
+		return resp, nil
+	}
+	if err := dal.CreateWatcherAlertList(ctx, alertList); err != nil {
+		hlog.Errorf("CreateWatcherAlertList failed, err: %v", err)
+	}
+	if err := SendLarkMessage(ctx, BuildWatcherAlertMessage(resp.Alerts)); err != nil {
+		hlog.Errorf("send watcher alert message failed, err: %v", err)
+	}
+	return resp, nil
+}
+
+// getWatcherRuleCodeList 规则作用的股票, 未指定股票时为盯盘内的全部股票和板块
+func getWatcherRuleCodeList(watcher *dal.Watcher, rule *dal.WatcherRule) []string {
+	if rule.Code != "" {
+		return []string{rule.Code}
+	}
+	return utils.ListStringIgnoreEmpty(strings.Split(watcher.Stocks, ","))
+}
+
+// checkWatcherRule 判断行情是否触发规则, 返回提醒内容
+func checkWatcherRule(rule *dal.WatcherRule, quote *model.StockQuote, lastPrice float64, volumeRatioReady bool) (string, bool) {
+	switch model.WatcherRuleType(rule.RuleType) {
+	case model.WatcherRuleTypeChange:
+		if math.Abs(quote.Percent) < rule.Threshold {
+			return "", false
+		}
+		if quote.Percent >= 0 {
+			return fmt.Sprintf("日内上涨%.2f%%, 超过%.2f%%", quote.Percent, rule.Threshold), true
+		}
+		return fmt.Sprintf("日内下跌%.2f%%, 超过%.2f%%", -quote.Percent, rule.Threshold), true
+	case model.WatcherRuleTypePriceCross:
+		// 第一次轮询没有上一次的价格, 无法判断穿越
+		if lastPrice <= 0 {
+			return "", false
+		}
+		if lastPrice < rule.Threshold && quote.Price >= rule.Threshold {
+			return fmt.Sprintf("价格%.2f向上突破%.2f", quote.Price, rule.Threshold), true
+		}
+		if lastPrice > rule.Threshold && quote.Price <= rule.Threshold {
+			return fmt.Sprintf("价格%.2f向下跌破%.2f", quote.Price, rule.Threshold), true
+		}
+		return "", false
+	case model.WatcherRuleTypeVolumeRatio:
+		if !volumeRatioReady || quote.VolumeRatio < rule.Threshold {
+			return "", false
+		}
+		return fmt.Sprintf("量比%.2f, 超过%.2f", quote.VolumeRatio, rule.Threshold), true
+	default:
+		return "", false
+	}
+}
+
+func toWatcherAlert(alert *dal.WatcherAlert, watcherName string) *model.WatcherAlert {
+	return &model.WatcherAlert{
+		WatcherID:    alert.WatcherID,
+		WatcherName:  watcherName,
+		RuleID:       alert.RuleID,
+		Code:         alert.Code,
+		Name:         alert.Name,
+		RuleType:     model.WatcherRuleType(alert.RuleType),
+		RuleTypeName: model.WatcherRuleType(alert.RuleType).String(),
+		Price:        alert.Price,
+		Percent:      alert.Percent,
+		Message:      alert.Message,
+		AlertTime:    utils.FormatTime(alert.AlertTime),
+	}
+}
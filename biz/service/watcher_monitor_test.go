@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+)
+
+func TestCheckWatcherRule(t *testing.T) {
+	quote := &model.StockQuote{Code: "SH600000", Price: 10.5, Percent: -5.2, VolumeRatio: 2.5}
+
+	changeRule := &dal.WatcherRule{RuleType: int(model.WatcherRuleTypeChange), Threshold: 5}
+	if _, hit := checkWatcherRule(changeRule, quote, 0, true); !hit {
+		t.Errorf("change rule should hit on -5.2%%")
+	}
+
+	crossRule := &dal.WatcherRule{RuleType: int(model.WatcherRuleTypePriceCross), Threshold: 10.6}
+	if _, hit := checkWatcherRule(crossRule, quote, 0, true); hit {
+		t.Errorf("price cross should not hit without last price")
+	}
+	if _, hit := checkWatcherRule(crossRule, quote, 10.7, true); !hit {
+		t.Errorf("price cross should hit when falling through 10.6")
+	}
+	if _, hit := checkWatcherRule(crossRule, quote, 10.4, true); hit {
+		t.Errorf("price cross should not hit when staying below 10.6")
+	}
+
+	volumeRule := &dal.WatcherRule{RuleType: int(model.WatcherRuleTypeVolumeRatio), Threshold: 2}
+	if _, hit := checkWatcherRule(volumeRule, quote, 0, false); hit {
+		t.Errorf("volume ratio rule should wait for warmup")
+	}
+	if _, hit := checkWatcherRule(volumeRule, quote, 0, true); !hit {
+		t.Errorf("volume ratio rule should hit on 2.5")
+	}
+}
+
+func TestWatcherMonitorAllowAlert(t *testing.T) {
+	m := &watcherMonitor{}
+	m.reset("2024-01-02")
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local)
+	cooldown := 30 * time.Minute
+
+	if !m.allowAlert("1|SH600000", "SH600000", cooldown, now) {
+		t.Fatalf("first alert should be allowed")
+	}
+	if m.allowAlert("1|SH600000", "SH600000", cooldown, now.Add(10*time.Minute)) {
+		t.Errorf("alert within cooldown should be suppressed")
+	}
+	if !m.allowAlert("1|SH600000", "SH600000", cooldown, now.Add(31*time.Minute)) {
+		t.Errorf("alert after cooldown should be allowed")
+	}
+
+	// 不同规则共用同一股票每小时的提醒次数
+	count := 0
+	for i := 2; i < 10; i++ {
+		if m.allowAlert(fmt.Sprintf("%d|SH600001", i), "SH600001", cooldown, now) {
+			count++
+		}
+	}
+	if count != MaxWatcherAlertPerStockHour {
+		t.Errorf("alerts per stock hour = %d, want %d", count, MaxWatcherAlertPerStockHour)
+	}
+}
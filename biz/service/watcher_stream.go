@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/zhikongming/stock/biz/dal"
+	"github.com/zhikongming/stock/biz/model"
+	"github.com/zhikongming/stock/utils"
+)
+
+const (
+	// 交易时段内行情和分时数据的轮询间隔
+	WatcherStreamQuoteInterval  = 5 * time.Second
+	WatcherStreamMinuteInterval = time.Minute
+	// 没有数据时发送心跳的间隔, 同时用于发现断开的连接
+	WatcherStreamHeartbeatInterval = 15 * time.Second
+	// 客户端消费不及时时最多缓存的消息数量, 超出后丢弃
+	WatcherStreamBufferSize = 16
+	MaxWatcherStreamJobNum  = 5
+)
+
+// WatcherStreamSubscriber 一个 SSE 连接的订阅
+type WatcherStreamSubscriber struct {
+	codeList []string
+	ch       chan *model.WatcherStreamEvent
+	// 每个股票已经推送的分时数据条数, 用于增量推送
+	minuteSentMap map[string]int
+}
+
+func (s *WatcherStreamSubscriber) Events() <-chan *model.WatcherStreamEvent {
+	return s.ch
+}
+
+// watcherStreamHub 所有连接共用一个轮询, 按各自的股票列表分发行情
+type watcherStreamHub struct {
+	sync.Mutex
+	subscriberMap  map[*WatcherStreamSubscriber]struct{}
+	quoteMap       map[string]*model.StockQuote
+	minuteMap      map[string][]*model.StockMinuteData
+	lastMinuteTime time.Time
+	stopCh         chan struct{}
+	refreshCh      chan struct{}
+	// 拉取行情和分时数据, 测试时可以替换
+	fetchQuote  func(ctx context.Context, codeList []string) ([]*model.StockQuote, error)
+	fetchMinute func(ctx context.Context, codeList []string) map[string][]*model.StockMinuteData
+}
+
+var defaultWatcherStreamHub = newWatcherStreamHub(
+	func(ctx context.Context, codeList []string) ([]*model.StockQuote, error) {
+		return NewEastMoneyClient().GetRemoteStockQuote(ctx, codeList)
+	},
+	getWatcherStreamMinuteMap,
+)
+
+func newWatcherStreamHub(fetchQuote func(ctx context.Context, codeList []string) ([]*model.StockQuote, error),
+	fetchMinute func(ctx context.Context, codeList []string) map[string][]*model.StockMinuteData) *watcherStreamHub {
+	return &watcherStreamHub{
+		subscriberMap: make(map[*WatcherStreamSubscriber]struct{}),
+		quoteMap:      make(map[string]*model.StockQuote),
+		minuteMap:     make(map[string][]*model.StockMinuteData),
+		refreshCh:     make(chan struct{}, 1),
+		fetchQuote:    fetchQuote,
+		fetchMinute:   fetchMinute,
+	}
+}
+
+func newWatcherStreamSubscriber(codeList []string) *WatcherStreamSubscriber {
+	return &WatcherStreamSubscriber{
+		codeList:      codeList,
+		ch:            make(chan *model.WatcherStreamEvent, WatcherStreamBufferSize),
+		minuteSentMap: make(map[string]int),
+	}
+}
+
+// SubscribeWatcherStream 订阅盯盘股票的实时行情, 使用完后必须调用 UnsubscribeWatcherStream
+func SubscribeWatcherStream(ctx context.Context, req *model.WatcherStreamReq) (*WatcherStreamSubscriber, error) {
+	watcher, err := dal.GetWatcher(ctx, uint(req.ID))
+	if err != nil {
+		return nil, err
+	}
+	if watcher == nil {
+		return nil, fmt.Errorf("watcher not found: %d", req.ID)
+	}
+	codeList := utils.ListStringIgnoreEmpty(strings.Split(watcher.Stocks, ","))
+	if len(codeList) == 0 {
+		return nil, fmt.Errorf("watcher has no stocks: %d", req.ID)
+	}
+
+	subscriber := newWatcherStreamSubscriber(codeList)
+	defaultWatcherStreamHub.subscribe(subscriber)
+	return subscriber, nil
+}
+
+func UnsubscribeWatcherStream(subscriber *WatcherStreamSubscriber) {
+	defaultWatcherStreamHub.unsubscribe(subscriber)
+}
+
+func (h *watcherStreamHub) subscribe(subscriber *WatcherStreamSubscriber) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.subscriberMap[subscriber] = struct{}{}
+	if h.stopCh == nil {
+		// 第一个订阅者, 启动轮询, 启动时会立即拉取一次
+		h.stopCh = make(chan struct{})
+		go h.run(h.stopCh)
+		return
+	}
+
+	// 先用缓存的数据推送一次, 缓存中没有的股票触发立即拉取
+	missing := false
+	for _, code := range subscriber.codeList {
+		if _, ok := h.quoteMap[code]; !ok {
+			missing = true
+			break
+		}
+	}
+	h.publish(subscriber)
+	if missing {
+		select {
+		case h.refreshCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (h *watcherStreamHub) unsubscribe(subscriber *WatcherStreamSubscriber) {
+	h.Lock()
+	defer h.Unlock()
+
+	if _, ok := h.subscriberMap[subscriber]; !ok {
+		return
+	}
+	delete(h.subscriberMap, subscriber)
+	close(subscriber.ch)
+	if len(h.subscriberMap) == 0 && h.stopCh != nil {
+		close(h.stopCh)
+		h.stopCh = nil
+		h.quoteMap = make(map[string]*model.StockQuote)
+		h.minuteMap = make(map[string][]*model.StockMinuteData)
+		h.lastMinuteTime = time.Time{}
+	}
+}
+
+func (h *watcherStreamHub) run(stopCh chan struct{}) {
+	ticker := time.NewTicker(WatcherStreamQuoteInterval)
+	defer ticker.Stop()
+
+	h.poll(stopCh, true)
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-h.refreshCh:
+			h.poll(stopCh, true)
+		case <-ticker.C:
+			// 非交易时段行情不会变化, 只在订阅时拉取一次
+			if utils.IsTradingTime() {
+				h.poll(stopCh, false)
+			}
+		}
+	}
+}
+
+// poll 拉取所有订阅者股票的行情, 分时数据按分钟更新, force 时忽略分时数据的更新间隔
+func (h *watcherStreamHub) poll(stopCh chan struct{}, force bool) {
+	ctx := context.Background()
+	h.Lock()
+	codeList := make([]string, 0)
+	for subscriber := range h.subscriberMap {
+		codeList = append(codeList, subscriber.codeList...)
+	}
+	fetchMinute := force || time.Since(h.lastMinuteTime) >= WatcherStreamMinuteInterval
+	h.Unlock()
+	codeList = utils.Uniq(codeList)
+	if len(codeList) == 0 {
+		return
+	}
+
+	quoteList, err := h.fetchQuote(ctx, codeList)
+	if err != nil {
+		hlog.Warnf("watcher stream get quote failed, err: %v", err)
+	}
+	var minuteMap map[string][]*model.StockMinuteData
+	if fetchMinute {
+		minuteMap = h.fetchMinute(ctx, codeList)
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	// 拉取期间所有订阅者都已经断开
+	select {
+	case <-stopCh:
+		return
+	default:
+	}
+	for _, quote := range quoteList {
+		h.quoteMap[quote.Code] = quote
+	}
+	if fetchMinute {
+		for code, minuteList := range minuteMap {
+			h.minuteMap[code] = minuteList
+		}
+		h.lastMinuteTime = time.Now()
+	}
+	for subscriber := range h.subscriberMap {
+		h.publish(subscriber)
+	}
+}
+
+// getWatcherStreamMinuteMap 获取股票的分时数据, 板块没有分时数据
+func getWatcherStreamMinuteMap(ctx context.Context, codeList []string) map[string][]*model.StockMinuteData {
+	jobList := make([]func() (interface{}, error), 0)
+	for _, code := range codeList {
+		if utils.IsIndustryCode(code) {
+			continue
+		}
+		jobList = append(jobList, func(code string) func() (interface{}, error) {
+			return func() (interface{}, error) {
+				minuteList, err := NewBaiduClient().GetRemoteStockMinute(ctx, code)
+				if err != nil {
+					hlog.Warnf("watcher stream get minute failed, code: %s, err: %v", code, err)
+					return nil, nil
+				}
+				return &model.WatcherMinuteBars{
+					Code: code,
+					Bars: minuteList,
+				}, nil
+			}
+		}(code))
+	}
+	ret := make(map[string][]*model.StockMinuteData)
+	dataList, _ := utils.ConcurrentActuator(jobList, MaxWatcherStreamJobNum)
+	for _, item := range dataList {
+		if item == nil {
+			continue
+		}
+		bars := item.(*model.WatcherMinuteBars)
+		ret[bars.Code] = bars.Bars
+	}
+	return ret
+}
+
+// publish 把缓存中订阅者关心的行情和新增的分时数据推送给订阅者, 调用方需要持有锁
+func (h *watcherStreamHub) publish(subscriber *WatcherStreamSubscriber) {
+	quoteList := make([]*model.StockQuote, 0, len(subscriber.codeList))
+	barsList := make([]*model.WatcherMinuteBars, 0)
+	sentMap := make(map[string]int)
+	for _, code := range subscriber.codeList {
+		if quote, ok := h.quoteMap[code]; ok {
+			quoteList = append(quoteList, quote)
+		}
+		minuteList, ok := h.minuteMap[code]
+		if !ok {
+			continue
+		}
+		sent := subscriber.minuteSentMap[code]
+		sentMap[code] = len(minuteList)
+		// 新的一天分时数据会变少, 此时重新推送全部数据
+		if sent == 0 || len(minuteList) < sent {
+			barsList = append(barsList, &model.WatcherMinuteBars{Code: code, Reset: true, Bars: minuteList})
+		} else if len(minuteList) > sent {
+			barsList = append(barsList, &model.WatcherMinuteBars{Code: code, Bars: minuteList[sent:]})
+		}
+	}
+
+	if len(quoteList) > 0 && !subscriber.send(&model.WatcherStreamEvent{Event: model.WatcherStreamEventQuote, Data: quoteList}) {
+		return
+	}
+	if len(barsList) > 0 {
+		if !subscriber.send(&model.WatcherStreamEvent{Event: model.WatcherStreamEventMinute, Data: barsList}) {
+			return
+		}
+		for code, sent := range sentMap {
+			subscriber.minuteSentMap[code] = sent
+		}
+	}
+}
+
+// send 不阻塞轮询, 缓冲区满时丢弃消息, 并在下一次推送全部分时数据
+func (s *WatcherStreamSubscriber) send(event *model.WatcherStreamEvent) bool {
+	select {
+	case s.ch <- event:
+		return true
+	default:
+		s.minuteSentMap = make(map[string]int)
+		return false
+	}
+}
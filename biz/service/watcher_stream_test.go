@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zhikongming/stock/biz/model"
+)
+
+func newWatcherStreamMinuteList(n int) []*model.StockMinuteData {
+	ret := make([]*model.StockMinuteData, 0, n)
+	for i := 0; i < n; i++ {
+		ret = append(ret, &model.StockMinuteData{Time: fmt.Sprintf("09:%02d", 30+i), Price: 10})
+	}
+	return ret
+}
+
+// receiveWatcherStreamEvent 等待订阅者收到下一条消息
+func receiveWatcherStreamEvent(t *testing.T, subscriber *WatcherStreamSubscriber) *model.WatcherStreamEvent {
+	t.Helper()
+	select {
+	case event := <-subscriber.Events():
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("no event received")
+		return nil
+	}
+}
+
+func TestWatcherStreamHubLifecycle(t *testing.T) {
+	fetchCh := make(chan []string, 4)
+	hub := newWatcherStreamHub(
+		func(ctx context.Context, codeList []string) ([]*model.StockQuote, error) {
+			fetchCh <- codeList
+			ret := make([]*model.StockQuote, 0, len(codeList))
+			for _, code := range codeList {
+				ret = append(ret, &model.StockQuote{Code: code, Price: 10})
+			}
+			return ret, nil
+		},
+		func(ctx context.Context, codeList []string) map[string][]*model.StockMinuteData {
+			return map[string][]*model.StockMinuteData{"SH600000": newWatcherStreamMinuteList(2)}
+		},
+	)
+
+	// 第一个订阅者启动轮询并立即拉取一次
+	subscriber := newWatcherStreamSubscriber([]string{"SH600000"})
+	hub.subscribe(subscriber)
+	if codeList := <-fetchCh; len(codeList) != 1 || codeList[0] != "SH600000" {
+		t.Errorf("fetch code list = %v", codeList)
+	}
+	if event := receiveWatcherStreamEvent(t, subscriber); event.Event != model.WatcherStreamEventQuote {
+		t.Errorf("first event = %s, want quote", event.Event)
+	}
+	event := receiveWatcherStreamEvent(t, subscriber)
+	barsList := event.Data.([]*model.WatcherMinuteBars)
+	if event.Event != model.WatcherStreamEventMinute || len(barsList) != 1 || !barsList[0].Reset || len(barsList[0].Bars) != 2 {
+		t.Errorf("minute event = %+v", event)
+	}
+
+	// 缓存中没有的股票会触发立即拉取, 两个订阅者的股票合并拉取
+	other := newWatcherStreamSubscriber([]string{"SZ000001"})
+	hub.subscribe(other)
+	if codeList := <-fetchCh; len(codeList) != 2 {
+		t.Errorf("refresh code list = %v", codeList)
+	}
+
+	// 最后一个订阅者断开后停止轮询并清空缓存
+	hub.unsubscribe(subscriber)
+	hub.unsubscribe(other)
+	hub.unsubscribe(other)
+	// 取消订阅会关闭消息通道
+	for range other.Events() {
+	}
+	hub.Lock()
+	defer hub.Unlock()
+	if hub.stopCh != nil || len(hub.subscriberMap) != 0 || len(hub.quoteMap) != 0 {
+		t.Errorf("hub should be stopped after all subscribers leave")
+	}
+}
+
+func TestWatcherStreamHubPublish(t *testing.T) {
+	hub := newWatcherStreamHub(nil, nil)
+	subscriber := newWatcherStreamSubscriber([]string{"SH600000"})
+	publish := func() *model.WatcherMinuteBars {
+		hub.publish(subscriber)
+		select {
+		case event := <-subscriber.Events():
+			return event.Data.([]*model.WatcherMinuteBars)[0]
+		default:
+			return nil
+		}
+	}
+
+	hub.minuteMap["SH600000"] = newWatcherStreamMinuteList(2)
+	if bars := publish(); bars == nil || !bars.Reset || len(bars.Bars) != 2 {
+		t.Errorf("first publish = %+v, want reset with 2 bars", bars)
+	}
+	// 没有新增数据时不推送
+	if bars := publish(); bars != nil {
+		t.Errorf("publish without new bars = %+v", bars)
+	}
+	hub.minuteMap["SH600000"] = newWatcherStreamMinuteList(3)
+	if bars := publish(); bars == nil || bars.Reset || len(bars.Bars) != 1 || bars.Bars[0].Time != "09:32" {
+		t.Errorf("incremental publish = %+v, want 1 new bar", bars)
+	}
+	// 新的一天数据变少, 重新推送全部数据
+	hub.minuteMap["SH600000"] = newWatcherStreamMinuteList(1)
+	if bars := publish(); bars == nil || !bars.Reset || len(bars.Bars) != 1 {
+		t.Errorf("new day publish = %+v, want reset with 1 bar", bars)
+	}
+}
+
+func TestWatcherStreamSubscriberDropOnFull(t *testing.T) {
+	hub := newWatcherStreamHub(nil, nil)
+	subscriber := &WatcherStreamSubscriber{
+		codeList:      []string{"SH600000"},
+		ch:            make(chan *model.WatcherStreamEvent, 1),
+		minuteSentMap: make(map[string]int),
+	}
+	hub.minuteMap["SH600000"] = newWatcherStreamMinuteList(2)
+	hub.publish(subscriber)
+	// 缓冲区已满, 新增的数据被丢弃, 下一次推送全部数据
+	hub.minuteMap["SH600000"] = newWatcherStreamMinuteList(3)
+	hub.publish(subscriber)
+	if len(subscriber.minuteSentMap) != 0 {
+		t.Errorf("sent map should be reset after drop, got %v", subscriber.minuteSentMap)
+	}
+	<-subscriber.Events()
+	hub.publish(subscriber)
+	event := <-subscriber.Events()
+	bars := event.Data.([]*model.WatcherMinuteBars)[0]
+	if !bars.Reset || len(bars.Bars) != 3 {
+		t.Errorf("publish after drop = %+v, want reset with 3 bars", bars)
+	}
+}
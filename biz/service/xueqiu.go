@@ -126,6 +126,10 @@ func (c *XueqiuClient) GetRemoteStockMinute(ctx context.Context, code string) ([
 	return nil, fmt.Errorf("not implemented")
 }
 
+func (c *XueqiuClient) GetRemoteStockQuote(ctx context.Context, codeList []string) ([]*model.StockQuote, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func (c *XueqiuClient) GetRemoteUnusualStock(ctx context.Context) ([]*model.UnusualStock, error) {
 	return nil, fmt.Errorf("not implemented")
 }
@@ -136,6 +140,10 @@ func (c *XueqiuClient) GetRemoteMarketRisk(ctx context.Context) ([]*model.Unusua
 	return nil, fmt.Errorf("not implemented")
 }
 
+func (c *XueqiuClient) GetRemoteStockShares(ctx context.Context) ([]*model.StockShareData, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func (c *XueqiuClient) GetRemoteUnusualPredict(ctx context.Context) ([]*model.UnusualPredict, error) {
 	return nil, fmt.Errorf("not implemented")
 }
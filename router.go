@@ -46,14 +46,25 @@ func customizedRegister(r *server.Hertz) {
 	r.GET("/ping", handler.Ping)
 
 	r.GET("/stock/code", handler.GetAllCode)
+	r.GET("/stock/risk", handler.GetStockRisk)
+	r.GET("/stock/lifecycle", handler.GetStockLifecycle)
 	r.POST("/task/stock/code", handler.SyncStockCode)
 	r.POST("/task/stock/industry", handler.SyncStockIndustry)
 	r.POST("/task/stock/fund/flow", handler.SyncFundFlow)
+	r.POST("/task/stock/shares", handler.SyncStockShares)
+	r.POST("/task/stock/lifecycle", handler.SyncStockLifecycle)
+	r.POST("/task/stock/risk", handler.SyncStockRisk)
+	r.POST("/task/stock/rps", handler.CalculateStockRps)
+	r.POST("/task/watcher/monitor", handler.RunWatcherMonitor)
 	r.POST("/task/cron", handler.StartCronTask)
 	r.POST("/analyze/stock/code", handler.AnalyzeStockCode)
 	r.POST("/filter/stock/code", handler.FilterStockCode)
 	r.POST("/filter/third/buy", handler.FilterThirdBuyCode)
 	r.POST("/analyze/trend/code", handler.AnalyzeTrendCode)
+	r.POST("/analyze/trend/multi_level", handler.AnalyzeMultiLevelTrendCode)
+	r.POST("/task/trend/signals", handler.ScanTrendSignals)
+	r.GET("/analyze/trend/signals", handler.GetTrendSignals)
+	r.POST("/analyze/signal/evaluate", handler.EvaluateSignals)
 	r.GET("/analyze/third/buy", handler.AnalyzeThirdBuyCode)
 	r.GET("/stock/report", handler.GetStockReport)
 	r.POST("/stock/report", handler.AddStockReport)
@@ -62,6 +73,8 @@ func customizedRegister(r *server.Hertz) {
 	r.GET("/industry/basic", handler.GetIndustryBasicData)
 	r.GET("/industry/trend", handler.GetIndustryTrendData)
 	r.GET("/industry/relation", handler.GetIndustryRelationData)
+	r.GET("/industry/correlation", handler.GetCorrelationMatrix)
+	r.GET("/industry/lead_lag", handler.AnalyzeLeadLag)
 	r.POST("/subscribe/strategy", handler.AddSubscribeStrategyData)
 	r.GET("/subscribe/strategy", handler.GetSubscribeStrategyData)
 	r.GET("/subscribe/strategy/report", handler.GetSubscribeStrategyReport)
@@ -70,7 +83,16 @@ func customizedRegister(r *server.Hertz) {
 	r.POST("/stock/watcher", handler.AddWatcher)
 	r.GET("/stock/watcher", handler.GetWatchers)
 	r.DELETE("/stock/watcher", handler.DeleteWatcher)
+	r.POST("/stock/watcher/rule", handler.AddWatcherRule)
+	r.GET("/stock/watcher/rule", handler.GetWatcherRules)
+	r.DELETE("/stock/watcher/rule", handler.DeleteWatcherRule)
+	r.GET("/stock/watcher/alert", handler.GetWatcherAlerts)
+	r.GET("/stock/watcher/:id/stream", handler.StreamWatcher)
 	r.GET("/analyze/report", handler.GetAnalyzeReport)
+	r.GET("/analyze/score/history", handler.GetIndustryScoreHistory)
+	r.GET("/analyze/score/movers", handler.GetIndustryScoreMovers)
+	r.POST("/analyze/score/factor_ic", handler.AnalyzeScoreFactorIC)
+	r.GET("/analyze/rps", handler.GetStockRps)
 	r.GET("/analyze/price/report", handler.GetPriceAnalyseReport)
 	r.POST("/analyze/price", handler.AddPriceAnalyse)
 	r.GET("/analyze/price", handler.GetPriceAnalyse)
@@ -78,6 +100,7 @@ func customizedRegister(r *server.Hertz) {
 	r.POST("/analyze/shareholder", handler.GetShareholderReport)
 	r.GET("/analyze/volume/report", handler.GetVolumeReport)
 	r.GET("/analyze/limitup/report", handler.GetLimitUpReport)
+	r.GET("/analyze/limitup/history", handler.GetLimitUpHistory)
 	r.GET("/analyze/up_trend/report", handler.GetUpTrendReport)
 
 	// 概念管理API
@@ -85,22 +108,39 @@ func customizedRegister(r *server.Hertz) {
 	r.POST("/concept/add", handler.AddConcept)
 	r.DELETE("/concept/delete", handler.DeleteConcept)
 	r.GET("/concept/stocks", handler.GetConceptStocks)
+	r.GET("/concept/trend", handler.GetConceptTrend)
 	r.POST("/concept/stock/add", handler.AddConceptStock)
 	r.DELETE("/concept/stock/delete", handler.DeleteConceptStock)
 
 	// 异常股票API
 	r.POST("/unusual/stock", handler.CreateUnusualStock)
 	r.GET("/unusual/stock", handler.GetUnusualStockList)
+	r.GET("/unusual/stock/history", handler.GetUnusualStockHistory)
+	r.GET("/unusual/stock/serious/stat", handler.GetUnusualSeriousStat)
 
 	// 异动预测API
 	r.POST("/unusual/predict", handler.CreateUnusualPredict)
 	r.GET("/unusual/predict", handler.GetUnusualPredictList)
+	r.GET("/unusual/predict/local", handler.GetLocalUnusualPredict)
+	r.GET("/unusual/predict/check", handler.CheckUnusualPredict)
+	r.POST("/unusual/predict/reconcile", handler.ReconcileUnusualPredict)
+	r.GET("/unusual/predict/accuracy", handler.GetUnusualPredictAccuracy)
 
 	// 事件管理API
 	r.POST("/event/create", handler.CreateEvent)
 	r.POST("/event/update", handler.UpdateEvent)
+	r.POST("/event/import", handler.ImportEvent)
 	r.DELETE("/event/delete", handler.DeleteEvent)
 	r.GET("/event/timeline", handler.GetEventTimeline)
+	r.GET("/event/impact", handler.AnalyzeEventImpact)
+
+	// 表达式选股API
+	r.POST("/screener/query", handler.QueryScreener)
+	r.POST("/screener", handler.AddScreener)
+	r.GET("/screener", handler.GetScreeners)
+	r.DELETE("/screener", handler.DeleteScreener)
+	r.POST("/screener/run", handler.RunScreener)
+	r.GET("/screener/runs", handler.GetScreenerRuns)
 }
 
 func registerPlatform(r *server.Hertz) {
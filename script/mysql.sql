@@ -87,3 +87,183 @@ CREATE TABLE `unusual_predict` (
   PRIMARY KEY (`id`),
   KEY `idx_date` (`date`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='严重异动预测';
+
+CREATE TABLE `screener` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'id',
+  `name` varchar(255) NOT NULL DEFAULT '' COMMENT '选股器名称',
+  `screener_type` tinyint NOT NULL DEFAULT '0' COMMENT '选股类型: 0: 指标筛选; 1: 第三类买点; 2: 表达式选股',
+  `params` text DEFAULT NULL COMMENT '选股参数',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `status` int NOT NULL DEFAULT '0' COMMENT '状态',
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='保存的选股器';
+
+CREATE TABLE `screener_run` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'id',
+  `screener_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '选股器id',
+  `date` DATE NOT NULL COMMENT '运行日期',
+  `run_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '运行时间',
+  `codes` text DEFAULT NULL COMMENT '选中的股票代码列表',
+  `result` mediumtext DEFAULT NULL COMMENT '选股结果',
+  PRIMARY KEY (`id`),
+  KEY `idx_screener_date` (`screener_id`, `date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='选股器运行结果';
+
+CREATE TABLE `trend_signal` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'id',
+  `code` varchar(255) NOT NULL DEFAULT '' COMMENT '股票代码',
+  `date` DATE NOT NULL COMMENT '买卖点日期',
+  `point_type` tinyint NOT NULL DEFAULT '0' COMMENT '买卖点类型: 1/2/3: 一二三类买点; -1/-2/-3: 一二三类卖点',
+  `price` float NOT NULL DEFAULT 0.0 COMMENT '买卖点价格',
+  `scan_date` DATE NOT NULL COMMENT '首次扫描到的日期',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_code_date_type` (`code`, `date`, `point_type`),
+  KEY `idx_date_type` (`date`, `point_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='缠论买卖点扫描结果';
+
+CREATE TABLE `industry_score` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'id',
+  `date` DATE NOT NULL COMMENT '打分日期',
+  `score_model` varchar(64) NOT NULL DEFAULT '' COMMENT '打分模型名称',
+  `industry_code` varchar(255) NOT NULL DEFAULT '' COMMENT '行业代码',
+  `industry_name` varchar(255) NOT NULL DEFAULT '' COMMENT '行业名称',
+  `rank` int NOT NULL DEFAULT '0' COMMENT '当日排名, 从1开始',
+  `score` float NOT NULL DEFAULT 0.0 COMMENT '总分',
+  `change_score` float NOT NULL DEFAULT 0.0 COMMENT '涨跌幅得分',
+  `slope5_score` float NOT NULL DEFAULT 0.0 COMMENT '5日均线斜率得分',
+  `slope10_score` float NOT NULL DEFAULT 0.0 COMMENT '10日均线斜率得分',
+  `slope20_score` float NOT NULL DEFAULT 0.0 COMMENT '20日均线斜率得分',
+  `score5gt10` float NOT NULL DEFAULT 0.0 COMMENT '5日均线大于10日均线得分',
+  `score10gt20` float NOT NULL DEFAULT 0.0 COMMENT '10日均线大于20日均线得分',
+  `score20gt30` float NOT NULL DEFAULT 0.0 COMMENT '20日均线大于30日均线得分',
+  `new_high_score` float NOT NULL DEFAULT 0.0 COMMENT '创新高得分',
+  `volume_score` float NOT NULL DEFAULT 0.0 COMMENT '放量得分',
+  `rps20_score` float NOT NULL DEFAULT 0.0 COMMENT 'RPS20得分',
+  `rps5_score` float NOT NULL DEFAULT 0.0 COMMENT 'RPS5得分',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_model_date_industry` (`score_model`, `date`, `industry_code`),
+  KEY `idx_industry_date` (`industry_code`, `date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='行业每日打分历史';
+
+ALTER TABLE `stock_code`
+  ADD COLUMN `total_shares` bigint NOT NULL DEFAULT '0' COMMENT '总股本',
+  ADD COLUMN `float_shares` bigint NOT NULL DEFAULT '0' COMMENT '流通股本';
+
+CREATE TABLE `stock_rps` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'id',
+  `code` varchar(255) NOT NULL DEFAULT '' COMMENT '股票代码',
+  `date` DATE NOT NULL COMMENT '计算日期',
+  `change50` float NOT NULL DEFAULT 0.0 COMMENT '50日涨幅',
+  `change120` float NOT NULL DEFAULT 0.0 COMMENT '120日涨幅',
+  `change250` float NOT NULL DEFAULT 0.0 COMMENT '250日涨幅',
+  `rps50` float NOT NULL DEFAULT 0.0 COMMENT '50日RPS, 数据不足时为0',
+  `rps120` float NOT NULL DEFAULT 0.0 COMMENT '120日RPS, 数据不足时为0',
+  `rps250` float NOT NULL DEFAULT 0.0 COMMENT '250日RPS, 数据不足时为0',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_code_date` (`code`, `date`),
+  KEY `idx_date` (`date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='个股相对强度';
+
+CREATE TABLE `limit_up_stat` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'id',
+  `date` DATE NOT NULL COMMENT '统计日期',
+  `limit_up_count` int NOT NULL DEFAULT '0' COMMENT '涨停数量',
+  `one_word_count` int NOT NULL DEFAULT '0' COMMENT '一字板数量',
+  `broken_count` int NOT NULL DEFAULT '0' COMMENT '炸板数量',
+  `limit_down_count` int NOT NULL DEFAULT '0' COMMENT '跌停数量',
+  `max_count` int NOT NULL DEFAULT '0' COMMENT '最高连板数',
+  `first_board_count` int NOT NULL DEFAULT '0' COMMENT '前一交易日首板数量',
+  `second_board_count` int NOT NULL DEFAULT '0' COMMENT '首板晋级二板数量',
+  `promotion_rate` float NOT NULL DEFAULT 0.0 COMMENT '首板晋级率',
+  `continuous_board_count` int NOT NULL DEFAULT '0' COMMENT '前一交易日涨停数量',
+  `continuous_promotion_count` int NOT NULL DEFAULT '0' COMMENT '前一交易日涨停当日继续涨停数量',
+  `continuous_promotion_rate` float NOT NULL DEFAULT 0.0 COMMENT '连板晋级率',
+  `broken_rate` float NOT NULL DEFAULT 0.0 COMMENT '炸板率',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_date` (`date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='每日涨停板统计';
+
+CREATE TABLE `unusual_predict_result` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'id',
+  `predict_date` DATE NOT NULL COMMENT '预测日期',
+  `trigger_date` DATE NOT NULL COMMENT '预测触发的交易日, 即预测日期的下一个交易日',
+  `code` varchar(255) NOT NULL DEFAULT '' COMMENT '股票代码',
+  `name` varchar(255) NOT NULL DEFAULT '' COMMENT '股票名称',
+  `source` tinyint NOT NULL DEFAULT '0' COMMENT '记录来源: 1: 次日异动预测, 2: 实际严重异常波动',
+  `rule_type` tinyint NOT NULL DEFAULT '0' COMMENT '规则类型, 实际异动无法识别规则时为0',
+  `predicted` tinyint NOT NULL DEFAULT '0' COMMENT '是否有次日异动预测',
+  `actual` tinyint NOT NULL DEFAULT '0' COMMENT '触发日是否实际出现严重异常波动',
+  PRIMARY KEY (`id`),
+  KEY `idx_predict_date` (`predict_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='严重异动预测的核对结果';
+
+CREATE TABLE `stock_risk` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'id',
+  `code` varchar(255) NOT NULL DEFAULT '' COMMENT '股票代码',
+  `name` varchar(255) NOT NULL DEFAULT '' COMMENT '股票名称',
+  `risk_type` tinyint NOT NULL DEFAULT '0' COMMENT '风险类型: 1: ST, 2: *ST, 3: 退市, 4: 交易所风险提示, 5: 停牌',
+  `start_date` DATE NOT NULL COMMENT '生效日期',
+  `end_date` DATE DEFAULT NULL COMMENT '截止日期, 为空表示仍然有效',
+  `reason` varchar(255) NOT NULL DEFAULT '' COMMENT '风险原因',
+  PRIMARY KEY (`id`),
+  KEY `idx_code` (`code`),
+  KEY `idx_date` (`start_date`, `end_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='风险股票名单';
+
+ALTER TABLE `stock_code`
+  ADD COLUMN `status` tinyint NOT NULL DEFAULT '0' COMMENT '状态: 0: 正常, 1: 停牌, 2: 退市',
+  ADD COLUMN `last_trade_date` DATE DEFAULT NULL COMMENT '最后一个有行情的交易日';
+
+CREATE TABLE `stock_lifecycle` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'id',
+  `code` varchar(255) NOT NULL DEFAULT '' COMMENT '股票代码',
+  `date` DATE NOT NULL COMMENT '发现变化的交易日',
+  `event_type` tinyint NOT NULL DEFAULT '0' COMMENT '事件类型: 1: 更名, 2: ST状态变化, 3: 停牌, 4: 复牌, 5: 退市, 6: 重新上市',
+  `old_value` varchar(255) NOT NULL DEFAULT '' COMMENT '变化前的值',
+  `new_value` varchar(255) NOT NULL DEFAULT '' COMMENT '变化后的值',
+  PRIMARY KEY (`id`),
+  KEY `idx_code_date` (`code`, `date`),
+  KEY `idx_date` (`date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='股票生命周期事件';
+
+ALTER TABLE `event`
+  ADD COLUMN `category` varchar(255) NOT NULL DEFAULT '' COMMENT '事件分类';
+
+ALTER TABLE `event`
+  ADD COLUMN `tags` varchar(1024) NOT NULL DEFAULT '' COMMENT '事件标签, 逗号分隔',
+  ADD COLUMN `industries` text DEFAULT NULL COMMENT '关联行业代码, 逗号分隔',
+  ADD COLUMN `concepts` text DEFAULT NULL COMMENT '关联概念ID, 逗号分隔';
+
+CREATE TABLE `watcher_rule` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'id',
+  `watcher_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '盯盘id',
+  `code` varchar(255) NOT NULL DEFAULT '' COMMENT '股票或板块代码, 为空表示盯盘内全部',
+  `rule_type` tinyint NOT NULL DEFAULT '0' COMMENT '规则类型: 1: 涨跌幅, 2: 价格穿越, 3: 量比放大',
+  `threshold` float NOT NULL DEFAULT 0.0 COMMENT '阈值: 涨跌幅绝对值(%), 价格, 量比',
+  `cooldown` int NOT NULL DEFAULT '0' COMMENT '同一股票两次提醒的最小间隔(分钟)',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `status` int NOT NULL DEFAULT '0' COMMENT '状态',
+  PRIMARY KEY (`id`),
+  KEY `idx_watcher_id` (`watcher_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='盯盘提醒规则';
+
+CREATE TABLE `watcher_alert` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT 'id',
+  `watcher_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '盯盘id',
+  `rule_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '规则id',
+  `code` varchar(255) NOT NULL DEFAULT '' COMMENT '股票或板块代码',
+  `name` varchar(255) NOT NULL DEFAULT '' COMMENT '股票或板块名称',
+  `rule_type` tinyint NOT NULL DEFAULT '0' COMMENT '规则类型',
+  `price` float NOT NULL DEFAULT 0.0 COMMENT '触发时价格',
+  `percent` float NOT NULL DEFAULT 0.0 COMMENT '触发时涨跌幅',
+  `message` varchar(1024) NOT NULL DEFAULT '' COMMENT '提醒内容',
+  `alert_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '提醒时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_watcher_time` (`watcher_id`, `alert_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='盯盘提醒记录';
+
+ALTER TABLE `trend_signal`
+  ADD KEY `idx_scan_date_type` (`scan_date`, `point_type`);
+
+ALTER TABLE `stock_code`
+  ADD COLUMN `remote_miss_times` int NOT NULL DEFAULT '0' COMMENT '连续不在远程股票列表中的次数';
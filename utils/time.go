@@ -103,3 +103,11 @@ func IsAfterHourMinute(hour, minute int) bool {
 	target := time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, t.Location())
 	return t.After(target)
 }
+
+// IsTradingTime 是否处于A股连续竞价时段, 不考虑节假日
+func IsTradingTime() bool {
+	if IsNowWeekend() {
+		return false
+	}
+	return (IsAfterHourMinute(9, 30) && IsBeforeHourMinute(11, 30)) || (IsAfterHourMinute(13, 0) && IsBeforeHourMinute(15, 0))
+}